@@ -0,0 +1,96 @@
+package events
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+type Event struct {
+	ID   uint64          `json:"id"`
+	Type string          `json:"type"`
+	Time time.Time       `json:"time"`
+	Data json.RawMessage `json:"data"`
+}
+
+type Broker struct {
+	mu      sync.Mutex
+	nextID  uint64
+	history []Event
+	max     int
+	subs    map[chan Event]struct{}
+}
+
+func NewBroker(max int) *Broker {
+	if max <= 0 {
+		max = 1000
+	}
+	return &Broker{
+		nextID:  1,
+		history: make([]Event, 0, max),
+		max:     max,
+		subs:    make(map[chan Event]struct{}),
+	}
+}
+
+func (b *Broker) Publish(kind string, payload any) {
+	if b == nil {
+		return
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	event := Event{
+		ID:   b.nextID,
+		Type: kind,
+		Time: time.Now().UTC(),
+		Data: data,
+	}
+	b.nextID++
+	if len(b.history) >= b.max {
+		copy(b.history, b.history[1:])
+		b.history[len(b.history)-1] = event
+	} else {
+		b.history = append(b.history, event)
+	}
+	for ch := range b.subs {
+		select {
+		case ch <- event:
+		default:
+			delete(b.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+func (b *Broker) Subscribe(lastID uint64) (ch chan Event, backlog []Event, complete bool) {
+	ch = make(chan Event, 64)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs[ch] = struct{}{}
+	complete = true
+	if lastID == 0 {
+		return ch, nil, complete
+	}
+	if lastID >= b.nextID || (len(b.history) > 0 && b.history[0].ID > lastID+1) {
+		complete = false
+	}
+	for _, event := range b.history {
+		if event.ID > lastID {
+			backlog = append(backlog, event)
+		}
+	}
+	return ch, backlog, complete
+}
+
+func (b *Broker) Unsubscribe(ch chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[ch]; ok {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
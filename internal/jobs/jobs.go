@@ -0,0 +1,329 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+var (
+	ErrJobNotFound = errors.New("job not found")
+	ErrJobFinished = errors.New("job has already finished")
+)
+
+type Runner func(ctx context.Context, node string) (any, error)
+
+type Publisher interface {
+	Publish(kind string, payload any)
+}
+
+type NodeResult struct {
+	Status     string `json:"status"`
+	Result     any    `json:"result,omitempty"`
+	Error      string `json:"error,omitempty"`
+	StartedAt  string `json:"startedAt,omitempty"`
+	FinishedAt string `json:"finishedAt,omitempty"`
+}
+
+type Job struct {
+	ID         string                `json:"id"`
+	Type       string                `json:"type"`
+	Board      string                `json:"board"`
+	Status     string                `json:"status"`
+	CreatedBy  string                `json:"createdBy,omitempty"`
+	CreatedAt  string                `json:"createdAt"`
+	FinishedAt string                `json:"finishedAt,omitempty"`
+	Total      int                   `json:"total"`
+	Completed  int                   `json:"completed"`
+	Failed     int                   `json:"failed"`
+	Nodes      []string              `json:"nodes"`
+	Results    map[string]NodeResult `json:"results,omitempty"`
+}
+
+type Config struct {
+	Concurrency int
+	Retention   time.Duration
+	MaxJobs     int
+}
+
+type Manager struct {
+	mu     sync.Mutex
+	cfg    Config
+	jobs   map[string]*entry
+	events Publisher
+}
+
+type entry struct {
+	job      Job
+	cancel   context.CancelFunc
+	finished time.Time
+}
+
+func NewManager(cfg Config, events Publisher) *Manager {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 4
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = time.Hour
+	}
+	if cfg.MaxJobs <= 0 {
+		cfg.MaxJobs = 200
+	}
+	return &Manager{
+		cfg:    cfg,
+		jobs:   make(map[string]*entry),
+		events: events,
+	}
+}
+
+func (m *Manager) Start(kind, board, author string, nodes []string, run Runner) (Job, error) {
+	id, err := newID()
+	if err != nil {
+		return Job{}, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	job := Job{
+		ID:        id,
+		Type:      kind,
+		Board:     board,
+		Status:    StatusRunning,
+		CreatedBy: author,
+		CreatedAt: now(),
+		Total:     len(nodes),
+		Nodes:     append([]string(nil), nodes...),
+		Results:   make(map[string]NodeResult, len(nodes)),
+	}
+	for _, node := range nodes {
+		job.Results[node] = NodeResult{Status: StatusPending}
+	}
+	m.mu.Lock()
+	m.prune()
+	m.jobs[id] = &entry{job: job, cancel: cancel}
+	snapshot := copyJob(job)
+	m.mu.Unlock()
+	m.publish(snapshot)
+
+	go m.run(ctx, id, nodes, run)
+	return snapshot, nil
+}
+
+func (m *Manager) Get(id string) (Job, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return copyJob(e.job), true
+}
+
+func (m *Manager) List(board string) []Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prune()
+	out := []Job{}
+	for _, e := range m.jobs {
+		if board == "" || e.job.Board == board {
+			job := copyJob(e.job)
+			job.Results = nil
+			out = append(out, job)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt > out[j].CreatedAt })
+	return out
+}
+
+func (m *Manager) Cancel(id string) (Job, error) {
+	m.mu.Lock()
+	e, ok := m.jobs[id]
+	if !ok {
+		m.mu.Unlock()
+		return Job{}, ErrJobNotFound
+	}
+	if e.job.Status != StatusRunning {
+		job := copyJob(e.job)
+		m.mu.Unlock()
+		return job, ErrJobFinished
+	}
+	e.cancel()
+	for node, result := range e.job.Results {
+		if result.Status == StatusPending {
+			result.Status = StatusCancelled
+			result.FinishedAt = now()
+			e.job.Results[node] = result
+			e.job.Completed++
+		}
+	}
+	e.job.Status = StatusCancelled
+	job := copyJob(e.job)
+	m.mu.Unlock()
+	m.publish(job)
+	return job, nil
+}
+
+func (m *Manager) CancelBoard(board string) {
+	m.mu.Lock()
+	var ids []string
+	for id, e := range m.jobs {
+		if e.job.Board == board && e.job.Status == StatusRunning {
+			ids = append(ids, id)
+		}
+	}
+	m.mu.Unlock()
+	for _, id := range ids {
+		_, _ = m.Cancel(id)
+	}
+}
+
+func (m *Manager) run(ctx context.Context, id string, nodes []string, run Runner) {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, m.cfg.Concurrency)
+	for _, node := range nodes {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(node string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			m.update(id, node, NodeResult{Status: StatusRunning, StartedAt: now()})
+			result, err := run(ctx, node)
+			res := NodeResult{Status: StatusSucceeded, Result: result, FinishedAt: now()}
+			if err != nil {
+				res.Status = StatusFailed
+				res.Error = err.Error()
+				if ctx.Err() != nil {
+					res.Status = StatusCancelled
+				}
+			}
+			m.update(id, node, res)
+		}(node)
+	}
+	wg.Wait()
+	m.finish(id)
+}
+
+func (m *Manager) update(id, node string, res NodeResult) {
+	m.mu.Lock()
+	e, ok := m.jobs[id]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+	prev := e.job.Results[node]
+	if prev.Status == StatusCancelled {
+		m.mu.Unlock()
+		return
+	}
+	if res.StartedAt == "" {
+		res.StartedAt = prev.StartedAt
+	}
+	e.job.Results[node] = res
+	switch res.Status {
+	case StatusSucceeded:
+		e.job.Completed++
+	case StatusFailed:
+		e.job.Completed++
+		e.job.Failed++
+	case StatusCancelled:
+		e.job.Completed++
+	}
+	job := copyJob(e.job)
+	m.mu.Unlock()
+	m.publish(job)
+}
+
+func (m *Manager) finish(id string) {
+	m.mu.Lock()
+	e, ok := m.jobs[id]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+	e.cancel()
+	if e.job.Status == StatusRunning {
+		e.job.Status = StatusSucceeded
+		if e.job.Failed > 0 && e.job.Failed == e.job.Total {
+			e.job.Status = StatusFailed
+		}
+	}
+	e.job.FinishedAt = now()
+	e.finished = time.Now()
+	job := copyJob(e.job)
+	m.mu.Unlock()
+	m.publish(job)
+}
+
+func (m *Manager) prune() {
+	cutoff := time.Now().Add(-m.cfg.Retention)
+	var finished []*entry
+	for id, e := range m.jobs {
+		if e.finished.IsZero() {
+			continue
+		}
+		if e.finished.Before(cutoff) {
+			delete(m.jobs, id)
+			continue
+		}
+		finished = append(finished, e)
+	}
+	if len(m.jobs) < m.cfg.MaxJobs {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool { return finished[i].finished.Before(finished[j].finished) })
+	for _, e := range finished {
+		if len(m.jobs) < m.cfg.MaxJobs {
+			break
+		}
+		delete(m.jobs, e.job.ID)
+	}
+}
+
+func (m *Manager) publish(job Job) {
+	if m.events == nil {
+		return
+	}
+	job.Results = nil
+	m.events.Publish("job", map[string]any{
+		"board": job.Board,
+		"job":   job,
+	})
+}
+
+func copyJob(job Job) Job {
+	results := make(map[string]NodeResult, len(job.Results))
+	for node, result := range job.Results {
+		results[node] = result
+	}
+	job.Results = results
+	job.Nodes = append([]string(nil), job.Nodes...)
+	return job
+}
+
+func newID() (string, error) {
+	raw := make([]byte, 8)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
+
+func now() string {
+	return time.Now().UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,196 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type recorder struct {
+	mu   sync.Mutex
+	jobs []Job
+}
+
+func (r *recorder) Publish(kind string, payload any) {
+	if kind != "job" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs = append(r.jobs, payload.(map[string]any)["job"].(Job))
+}
+
+func waitJob(t *testing.T, m *Manager, id string) Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job, ok := m.Get(id)
+		if !ok {
+			t.Fatalf("job %s not found", id)
+		}
+		if job.FinishedAt != "" {
+			return job
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("job %s did not finish", id)
+	return Job{}
+}
+
+func TestManagerRunsEveryNode(t *testing.T) {
+	events := &recorder{}
+	m := NewManager(Config{Concurrency: 2}, events)
+	job, err := m.Start("detect", "main", "root", []string{"a", "b", "c"}, func(ctx context.Context, node string) (any, error) {
+		if node == "b" {
+			return nil, errors.New("unreachable")
+		}
+		return node + "-ok", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != StatusRunning || job.Total != 3 {
+		t.Fatalf("started job = %+v", job)
+	}
+	job = waitJob(t, m, job.ID)
+	if job.Status != StatusSucceeded || job.Completed != 3 || job.Failed != 1 {
+		t.Fatalf("job = %+v", job)
+	}
+	if res := job.Results["a"]; res.Status != StatusSucceeded || res.Result != "a-ok" || res.StartedAt == "" {
+		t.Fatalf("result a = %+v", res)
+	}
+	if res := job.Results["b"]; res.Status != StatusFailed || res.Error != "unreachable" {
+		t.Fatalf("result b = %+v", res)
+	}
+	events.mu.Lock()
+	last := events.jobs[len(events.jobs)-1]
+	events.mu.Unlock()
+	if last.Status != StatusSucceeded || last.Results != nil {
+		t.Fatalf("last event = %+v", last)
+	}
+}
+
+func TestManagerFailsWhenEveryNodeFails(t *testing.T) {
+	m := NewManager(Config{}, nil)
+	job, err := m.Start("facts", "main", "", []string{"a", "b"}, func(ctx context.Context, node string) (any, error) {
+		return nil, errors.New("boom")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job = waitJob(t, m, job.ID); job.Status != StatusFailed || job.Failed != 2 {
+		t.Fatalf("job = %+v", job)
+	}
+}
+
+func TestManagerLimitsConcurrency(t *testing.T) {
+	var running, peak int32
+	m := NewManager(Config{Concurrency: 2}, nil)
+	job, err := m.Start("facts", "main", "", []string{"a", "b", "c", "d", "e"}, func(ctx context.Context, node string) (any, error) {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			seen := atomic.LoadInt32(&peak)
+			if current <= seen || atomic.CompareAndSwapInt32(&peak, seen, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitJob(t, m, job.ID)
+	if peak := atomic.LoadInt32(&peak); peak != 2 {
+		t.Fatalf("peak concurrency = %d, want 2", peak)
+	}
+}
+
+func TestManagerCancel(t *testing.T) {
+	started := make(chan string, 2)
+	m := NewManager(Config{Concurrency: 1}, nil)
+	job, err := m.Start("detect", "main", "", []string{"a", "b", "c"}, func(ctx context.Context, node string) (any, error) {
+		started <- node
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := <-started
+	cancelled, err := m.Cancel(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancelled.Status != StatusCancelled {
+		t.Fatalf("cancel returned %+v", cancelled)
+	}
+	job = waitJob(t, m, job.ID)
+	if job.Status != StatusCancelled || job.Completed != 3 || job.Failed != 0 {
+		t.Fatalf("job = %+v", job)
+	}
+	for node, res := range job.Results {
+		if res.Status != StatusCancelled {
+			t.Fatalf("result %s = %+v", node, res)
+		}
+	}
+	if res := job.Results[first]; res.Error != context.Canceled.Error() {
+		t.Fatalf("running node %s = %+v", first, res)
+	}
+	select {
+	case node := <-started:
+		t.Fatalf("node %s started after cancel", node)
+	default:
+	}
+	if _, err := m.Cancel(job.ID); !errors.Is(err, ErrJobFinished) {
+		t.Fatalf("second cancel err = %v", err)
+	}
+	if _, err := m.Cancel("missing"); !errors.Is(err, ErrJobNotFound) {
+		t.Fatalf("missing cancel err = %v", err)
+	}
+}
+
+func TestManagerCancelBoard(t *testing.T) {
+	m := NewManager(Config{}, nil)
+	block := func(ctx context.Context, node string) (any, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	mine, _ := m.Start("detect", "lab", "", []string{"a"}, block)
+	other, _ := m.Start("detect", "main", "", []string{"a"}, block)
+	m.CancelBoard("lab")
+	if job := waitJob(t, m, mine.ID); job.Status != StatusCancelled {
+		t.Fatalf("lab job = %+v", job)
+	}
+	if job, _ := m.Get(other.ID); job.Status != StatusRunning {
+		t.Fatalf("main job = %+v", job)
+	}
+	if list := m.List("main"); len(list) != 1 || list[0].ID != other.ID || list[0].Results != nil {
+		t.Fatalf("list = %+v", list)
+	}
+	_, _ = m.Cancel(other.ID)
+}
+
+func TestManagerPrunesFinishedJobs(t *testing.T) {
+	m := NewManager(Config{MaxJobs: 2}, nil)
+	done := func(ctx context.Context, node string) (any, error) { return nil, nil }
+	var ids []string
+	for i := 0; i < 3; i++ {
+		job, err := m.Start("facts", "main", "", []string{"a"}, done)
+		if err != nil {
+			t.Fatal(err)
+		}
+		waitJob(t, m, job.ID)
+		ids = append(ids, job.ID)
+	}
+	if _, ok := m.Get(ids[0]); ok {
+		t.Fatal("oldest finished job was not pruned")
+	}
+	if _, ok := m.Get(ids[2]); !ok {
+		t.Fatal("newest job was pruned")
+	}
+}
@@ -0,0 +1,183 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before any    `json:"before"`
+	After  any    `json:"after"`
+}
+
+type NodeChange struct {
+	ID      string        `json:"id"`
+	Changes []FieldChange `json:"changes"`
+}
+
+type LinkChange struct {
+	ID      string        `json:"id"`
+	Changes []FieldChange `json:"changes"`
+}
+
+type BoardDiff struct {
+	NodesAdded   []Node       `json:"nodesAdded"`
+	NodesRemoved []Node       `json:"nodesRemoved"`
+	NodesChanged []NodeChange `json:"nodesChanged"`
+	LinksAdded   []Link       `json:"linksAdded"`
+	LinksRemoved []Link       `json:"linksRemoved"`
+	LinksChanged []LinkChange `json:"linksChanged"`
+	MetaChanged  bool         `json:"metaChanged"`
+}
+
+func DiffBoards(before, after *Board) BoardDiff {
+	diff := BoardDiff{
+		NodesAdded:   []Node{},
+		NodesRemoved: []Node{},
+		NodesChanged: []NodeChange{},
+		LinksAdded:   []Link{},
+		LinksRemoved: []Link{},
+		LinksChanged: []LinkChange{},
+	}
+	if before == nil {
+		before = &Board{}
+	}
+	if after == nil {
+		after = &Board{}
+	}
+
+	beforeNodes := make(map[string]Node, len(before.Nodes))
+	for _, node := range before.Nodes {
+		beforeNodes[node.ID] = node
+	}
+	afterNodes := make(map[string]struct{}, len(after.Nodes))
+	for _, node := range after.Nodes {
+		afterNodes[node.ID] = struct{}{}
+		prev, ok := beforeNodes[node.ID]
+		if !ok {
+			diff.NodesAdded = append(diff.NodesAdded, node)
+			continue
+		}
+		if changes := diffFields(prev, node); len(changes) > 0 {
+			diff.NodesChanged = append(diff.NodesChanged, NodeChange{ID: node.ID, Changes: changes})
+		}
+	}
+	for _, node := range before.Nodes {
+		if _, ok := afterNodes[node.ID]; !ok {
+			diff.NodesRemoved = append(diff.NodesRemoved, node)
+		}
+	}
+
+	beforeLinks := make(map[string]Link, len(before.Links))
+	for _, link := range before.Links {
+		beforeLinks[linkKey(link)] = link
+	}
+	afterLinks := make(map[string]struct{}, len(after.Links))
+	for _, link := range after.Links {
+		key := linkKey(link)
+		afterLinks[key] = struct{}{}
+		prev, ok := beforeLinks[key]
+		if !ok {
+			diff.LinksAdded = append(diff.LinksAdded, link)
+			continue
+		}
+		if changes := diffFields(prev, link); len(changes) > 0 {
+			diff.LinksChanged = append(diff.LinksChanged, LinkChange{ID: key, Changes: changes})
+		}
+	}
+	for _, link := range before.Links {
+		if _, ok := afterLinks[linkKey(link)]; !ok {
+			diff.LinksRemoved = append(diff.LinksRemoved, link)
+		}
+	}
+
+	beforeMeta := before.Meta
+	afterMeta := after.Meta
+	beforeMeta.UpdatedAt = ""
+	afterMeta.UpdatedAt = ""
+	diff.MetaChanged = beforeMeta != afterMeta
+
+	return diff
+}
+
+func (d BoardDiff) Empty() bool {
+	return len(d.NodesAdded) == 0 && len(d.NodesRemoved) == 0 && len(d.NodesChanged) == 0 &&
+		len(d.LinksAdded) == 0 && len(d.LinksRemoved) == 0 && len(d.LinksChanged) == 0 &&
+		!d.MetaChanged
+}
+
+func (d BoardDiff) Summary() string {
+	var parts []string
+	add := func(count int, noun, verb string) {
+		if count == 0 {
+			return
+		}
+		if count > 1 {
+			noun += "s"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s %s", count, noun, verb))
+	}
+	add(len(d.NodesAdded), "node", "added")
+	add(len(d.NodesRemoved), "node", "removed")
+	add(len(d.NodesChanged), "node", "changed")
+	add(len(d.LinksAdded), "link", "added")
+	add(len(d.LinksRemoved), "link", "removed")
+	add(len(d.LinksChanged), "link", "changed")
+	if d.MetaChanged {
+		parts = append(parts, "settings changed")
+	}
+	if len(parts) == 0 {
+		return "no structural changes"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func linkKey(link Link) string {
+	if link.ID != "" {
+		return link.ID
+	}
+	return link.From + "->" + link.To
+}
+
+func diffFields(before, after any) []FieldChange {
+	beforeFields := toFieldMap(before)
+	afterFields := toFieldMap(after)
+	keys := make(map[string]struct{}, len(beforeFields)+len(afterFields))
+	for key := range beforeFields {
+		keys[key] = struct{}{}
+	}
+	for key := range afterFields {
+		keys[key] = struct{}{}
+	}
+	names := make([]string, 0, len(keys))
+	for key := range keys {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+
+	var changes []FieldChange
+	for _, name := range names {
+		prev, next := beforeFields[name], afterFields[name]
+		if reflect.DeepEqual(prev, next) {
+			continue
+		}
+		changes = append(changes, FieldChange{Field: name, Before: prev, After: next})
+	}
+	return changes
+}
+
+func toFieldMap(value any) map[string]any {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	fields := map[string]any{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil
+	}
+	return fields
+}
@@ -0,0 +1,110 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffBoards(t *testing.T) {
+	base := func() *Board {
+		return &Board{
+			Meta: BoardMeta{Name: "lab", UpdatedAt: "2026-01-01T00:00:00Z"},
+			Nodes: []Node{
+				{ID: "a", Type: "server", Label: "A", IPPrivate: "10.0.0.1"},
+				{ID: "b", Type: "router", Label: "B"},
+			},
+			Links: []Link{{ID: "l1", From: "a", To: "b"}},
+		}
+	}
+	tests := []struct {
+		name    string
+		edit    func(b *Board)
+		check   func(t *testing.T, d BoardDiff)
+		summary string
+	}{
+		{
+			name:    "unchanged apart from timestamp",
+			edit:    func(b *Board) { b.Meta.UpdatedAt = "2026-02-01T00:00:00Z" },
+			summary: "no structural changes",
+		},
+		{
+			name: "node added and removed",
+			edit: func(b *Board) {
+				b.Nodes = []Node{b.Nodes[0], {ID: "c", Type: "pc"}}
+				b.Links = nil
+			},
+			check: func(t *testing.T, d BoardDiff) {
+				if len(d.NodesAdded) != 1 || d.NodesAdded[0].ID != "c" {
+					t.Fatalf("added = %+v", d.NodesAdded)
+				}
+				if len(d.NodesRemoved) != 1 || d.NodesRemoved[0].ID != "b" {
+					t.Fatalf("removed = %+v", d.NodesRemoved)
+				}
+			},
+			summary: "1 node added, 1 node removed, 1 link removed",
+		},
+		{
+			name: "node fields changed",
+			edit: func(b *Board) {
+				b.Nodes[0].Label = "A2"
+				b.Nodes[0].IPPrivate = "10.0.0.2"
+			},
+			check: func(t *testing.T, d BoardDiff) {
+				want := []NodeChange{{ID: "a", Changes: []FieldChange{
+					{Field: "ipPrivate", Before: "10.0.0.1", After: "10.0.0.2"},
+					{Field: "label", Before: "A", After: "A2"},
+				}}}
+				if !reflect.DeepEqual(d.NodesChanged, want) {
+					t.Fatalf("changed = %+v, want %+v", d.NodesChanged, want)
+				}
+			},
+			summary: "1 node changed",
+		},
+		{
+			name: "link rewired and meta changed",
+			edit: func(b *Board) {
+				b.Links[0].To = "a"
+				b.Links = append(b.Links, Link{From: "b", To: "a"})
+				b.Meta.Name = "lab-2"
+			},
+			check: func(t *testing.T, d BoardDiff) {
+				if len(d.LinksAdded) != 1 || linkKey(d.LinksAdded[0]) != "b->a" {
+					t.Fatalf("links added = %+v", d.LinksAdded)
+				}
+				if len(d.LinksChanged) != 1 || d.LinksChanged[0].ID != "l1" {
+					t.Fatalf("links changed = %+v", d.LinksChanged)
+				}
+				if !d.MetaChanged {
+					t.Fatal("meta change not detected")
+				}
+			},
+			summary: "1 link added, 1 link changed, settings changed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			after := base()
+			tt.edit(after)
+			d := DiffBoards(base(), after)
+			if tt.check != nil {
+				tt.check(t, d)
+			}
+			if got := d.Summary(); got != tt.summary {
+				t.Fatalf("summary = %q, want %q", got, tt.summary)
+			}
+			if d.Empty() != (tt.summary == "no structural changes") {
+				t.Fatalf("Empty() = %t for %q", d.Empty(), d.Summary())
+			}
+		})
+	}
+}
+
+func TestDiffBoardsNil(t *testing.T) {
+	d := DiffBoards(nil, &Board{Nodes: []Node{{ID: "a", Type: "server"}}})
+	if len(d.NodesAdded) != 1 || d.Empty() {
+		t.Fatalf("diff = %+v", d)
+	}
+	if !DiffBoards(nil, nil).Empty() {
+		t.Fatal("nil boards should not differ")
+	}
+}
@@ -1,58 +1,219 @@
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type MonitoringSettings struct {
-	Enabled     bool `json:"enabled"`
-	IntervalSec int  `json:"intervalSec"`
-	ShowStatus  bool `json:"showStatus"`
+	Enabled          bool `json:"enabled"`
+	IntervalSec      int  `json:"intervalSec"`
+	ShowStatus       bool `json:"showStatus"`
+	PingCount        int  `json:"pingCount,omitempty"`
+	FailThreshold    int  `json:"failThreshold,omitempty"`
+	RecoverThreshold int  `json:"recoverThreshold,omitempty"`
+	FlapThreshold    int  `json:"flapThreshold,omitempty"`
+	FlapWindowSec    int  `json:"flapWindowSec,omitempty"`
 }
 
 type BoardMeta struct {
+	Name       string             `json:"name,omitempty"`
+	UpdatedAt  string             `json:"updatedAt,omitempty"`
 	Monitoring MonitoringSettings `json:"monitoring"`
 }
 
+type Viewport struct {
+	X    float64 `json:"x"`
+	Y    float64 `json:"y"`
+	Zoom float64 `json:"zoom"`
+}
+
 type Node struct {
-	ID              string `json:"id"`
-	Type            string `json:"type"`
-	IPPrivate       string `json:"ipPrivate"`
-	IPTailscale     string `json:"ipTailscale"`
-	IPPublic        string `json:"ipPublic"`
-	PingEnabled     *bool  `json:"pingEnabled,omitempty"`
-	PingIntervalSec int    `json:"pingIntervalSec,omitempty"`
-	ConnectEnabled  bool   `json:"connectEnabled,omitempty"`
+	ID               string         `json:"id"`
+	Type             string         `json:"type"`
+	Label            string         `json:"label"`
+	X                float64        `json:"x"`
+	Y                float64        `json:"y"`
+	Z                int            `json:"z,omitempty"`
+	Width            float64        `json:"width,omitempty"`
+	Height           float64        `json:"height,omitempty"`
+	Color            string         `json:"color,omitempty"`
+	Locked           bool           `json:"locked,omitempty"`
+	Notes            string         `json:"notes,omitempty"`
+	Tags             []string       `json:"tags,omitempty"`
+	Network          string         `json:"network,omitempty"`
+	NetworkID        string         `json:"networkId,omitempty"`
+	NetworkPublicIP  string         `json:"networkPublicIp,omitempty"`
+	NetworkHeaderPos string         `json:"networkHeaderPos,omitempty"`
+	Hostname         string         `json:"hostname,omitempty"`
+	IPPrivate        string         `json:"ipPrivate"`
+	IPTailscale      string         `json:"ipTailscale"`
+	IPPublic         string         `json:"ipPublic"`
+	AutoTailscale    *bool          `json:"autoTailscale,omitempty"`
+	IsInfraMapServer bool           `json:"isInfraMapServer,omitempty"`
+	LinkSpeedMbps    int            `json:"linkSpeedMbps,omitempty"`
+	PingEnabled      *bool          `json:"pingEnabled,omitempty"`
+	PingIntervalSec  int            `json:"pingIntervalSec,omitempty"`
+	PingShowStatus   *bool          `json:"pingShowStatus,omitempty"`
+	ConnectEnabled   bool           `json:"connectEnabled,omitempty"`
+	FactsIntervalSec int            `json:"factsIntervalSec,omitempty"`
+	Checks           []ServiceCheck `json:"checks,omitempty"`
 }
 
-type Board struct {
-	Meta  BoardMeta `json:"meta"`
-	Nodes []Node    `json:"nodes"`
+const (
+	CheckTCP  = "tcp"
+	CheckHTTP = "http"
+	CheckDNS  = "dns"
+	CheckTLS  = "tls"
+
+	CheckUp   = "up"
+	CheckWarn = "warn"
+	CheckDown = "down"
+)
+
+type ServiceCheck struct {
+	ID           string `json:"id"`
+	Name         string `json:"name,omitempty"`
+	Type         string `json:"type"`
+	Host         string `json:"host,omitempty"`
+	Port         int    `json:"port,omitempty"`
+	URL          string `json:"url,omitempty"`
+	ExpectStatus int    `json:"expectStatus,omitempty"`
+	ExpectBody   string `json:"expectBody,omitempty"`
+	Resolver     string `json:"resolver,omitempty"`
+	Query        string `json:"query,omitempty"`
+	RecordType   string `json:"recordType,omitempty"`
+	ExpectAnswer string `json:"expectAnswer,omitempty"`
+	WarnDays     int    `json:"warnDays,omitempty"`
+	Insecure     bool   `json:"insecure,omitempty"`
+	IntervalSec  int    `json:"intervalSec,omitempty"`
+	TimeoutSec   int    `json:"timeoutSec,omitempty"`
+	Disabled     bool   `json:"disabled,omitempty"`
 }
 
-type PingResult struct {
+type CheckResult struct {
+	Check       string    `json:"check"`
+	Type        string    `json:"type"`
+	Status      string    `json:"status"`
 	Online      bool      `json:"online"`
 	LastChecked time.Time `json:"lastChecked"`
-	RTTMs       int       `json:"rttMs,omitempty"`
 	Target      string    `json:"target"`
+	LatencyMs   float64   `json:"latencyMs,omitempty"`
+	Detail      string    `json:"detail,omitempty"`
 	Error       string    `json:"error,omitempty"`
+	DaysLeft    *int      `json:"daysLeft,omitempty"`
+}
+
+type Link struct {
+	ID   string `json:"id"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type Board struct {
+	Version  int       `json:"version"`
+	Meta     BoardMeta `json:"meta"`
+	Viewport Viewport  `json:"viewport"`
+	Nodes    []Node    `json:"nodes"`
+	Links    []Link    `json:"links"`
+}
+
+const (
+	AddressHostname  = "hostname"
+	AddressPublic    = "public"
+	AddressPrivate   = "private"
+	AddressTailscale = "tailscale"
+
+	PingUp       = "up"
+	PingDegraded = "degraded"
+	PingDown     = "down"
+)
+
+type PingProbe struct {
+	Online   bool    `json:"online"`
+	RTTMs    int     `json:"rttMs,omitempty"`
+	Target   string  `json:"target"`
+	Error    string  `json:"error,omitempty"`
+	Sent     int     `json:"sent,omitempty"`
+	Received int     `json:"received,omitempty"`
+	LossPct  float64 `json:"lossPct"`
+	RTTMinMs float64 `json:"rttMinMs,omitempty"`
+	RTTAvgMs float64 `json:"rttAvgMs,omitempty"`
+	RTTMaxMs float64 `json:"rttMaxMs,omitempty"`
+	JitterMs float64 `json:"jitterMs,omitempty"`
+}
+
+type DNSResolution struct {
+	Name      string   `json:"name"`
+	Addresses []string `json:"addresses,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+type PingResult struct {
+	PingProbe
+	LastChecked  time.Time            `json:"lastChecked"`
+	State        string               `json:"state"`
+	Addresses    map[string]PingProbe `json:"addresses,omitempty"`
+	DNS          *DNSResolution       `json:"dns,omitempty"`
+	Failures     int                  `json:"failures,omitempty"`
+	Successes    int                  `json:"successes,omitempty"`
+	StateChanges int                  `json:"stateChanges,omitempty"`
+	Flapping     bool                 `json:"flapping,omitempty"`
 }
 
 type SSHStatus struct {
-	Online      bool      `json:"online"`
-	LastChecked time.Time `json:"lastChecked"`
-	Error       string    `json:"error,omitempty"`
+	Online          bool      `json:"online"`
+	LastChecked     time.Time `json:"lastChecked"`
+	Error           string    `json:"error,omitempty"`
+	HostKeyMismatch bool      `json:"hostKeyMismatch,omitempty"`
+}
+
+type HostKey struct {
+	Address     string `json:"address"`
+	Type        string `json:"type"`
+	Fingerprint string `json:"fingerprint"`
+	Key         string `json:"key"`
+	SeenAt      string `json:"seenAt"`
+}
+
+type KnownHost struct {
+	Pinned  *HostKey `json:"pinned,omitempty"`
+	Offered *HostKey `json:"offered,omitempty"`
 }
 
 type DeviceSettings struct {
-	OS                   string `json:"os"`
-	Host                 string `json:"host"`
-	Port                 int    `json:"port"`
-	AuthMethod           string `json:"authMethod"`
-	Username             string `json:"username"`
-	Password             string `json:"password"`
-	PrivateKey           string `json:"privateKey"`
-	PrivateKeyPassphrase string `json:"privateKeyPassphrase"`
-	ConnectEnabled       bool   `json:"connectEnabled"`
-	LinkSpeedMbps        int    `json:"linkSpeedMbps"`
+	OS                   string     `json:"os"`
+	Host                 string     `json:"host"`
+	Port                 int        `json:"port"`
+	AuthMethod           string     `json:"authMethod"`
+	Username             string     `json:"username"`
+	Password             string     `json:"password"`
+	PrivateKey           string     `json:"privateKey"`
+	PrivateKeyPassphrase string     `json:"privateKeyPassphrase"`
+	Certificate          string     `json:"certificate,omitempty"`
+	ConnectEnabled       bool       `json:"connectEnabled"`
+	LinkSpeedMbps        int        `json:"linkSpeedMbps"`
+	JumpHosts            []JumpHost `json:"jumpHosts,omitempty"`
+}
+
+type JumpHost struct {
+	Device   string `json:"device,omitempty"`
+	Host     string `json:"host,omitempty"`
+	Port     int    `json:"port,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+type Fact struct {
+	Field       string          `json:"field"`
+	Value       json.RawMessage `json:"value,omitempty"`
+	CollectedAt string          `json:"collectedAt,omitempty"`
+	CheckedAt   string          `json:"checkedAt"`
+	Error       string          `json:"error,omitempty"`
+}
+
+type NodeFacts struct {
+	Probes    map[string]Fact `json:"probes"`
+	UpdatedAt string          `json:"updatedAt"`
 }
 
 type LogEntry struct {
@@ -61,3 +222,98 @@ type LogEntry struct {
 	Source  string `json:"source"`
 	Message string `json:"message"`
 }
+
+const (
+	AlertOffline       = "offline"
+	AlertRTTHigh       = "rtt_high"
+	AlertSSHDownPingUp = "ssh_down_ping_up"
+
+	AlertFiring   = "firing"
+	AlertResolved = "resolved"
+)
+
+type AlertRule struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Condition string   `json:"condition"`
+	Checks    int      `json:"checks,omitempty"`
+	RTTMs     int      `json:"rttMs,omitempty"`
+	ForSec    int      `json:"forSec,omitempty"`
+	Nodes     []string `json:"nodes,omitempty"`
+	Network   string   `json:"network,omitempty"`
+	Severity  string   `json:"severity"`
+	Enabled   bool     `json:"enabled"`
+	CreatedAt string   `json:"createdAt,omitempty"`
+	UpdatedAt string   `json:"updatedAt,omitempty"`
+}
+
+type Alert struct {
+	ID         string `json:"id"`
+	Rule       string `json:"rule"`
+	RuleName   string `json:"ruleName"`
+	Condition  string `json:"condition"`
+	Severity   string `json:"severity"`
+	Node       string `json:"node"`
+	State      string `json:"state"`
+	Message    string `json:"message"`
+	StartedAt  string `json:"startedAt"`
+	UpdatedAt  string `json:"updatedAt"`
+	ResolvedAt string `json:"resolvedAt,omitempty"`
+	AckedBy    string `json:"ackedBy,omitempty"`
+	AckedAt    string `json:"ackedAt,omitempty"`
+	Silenced   bool   `json:"silenced,omitempty"`
+}
+
+type Silence struct {
+	ID        string `json:"id"`
+	Rule      string `json:"rule,omitempty"`
+	Node      string `json:"node,omitempty"`
+	Until     string `json:"until"`
+	Comment   string `json:"comment,omitempty"`
+	CreatedBy string `json:"createdBy,omitempty"`
+	CreatedAt string `json:"createdAt"`
+}
+
+func (s Silence) Matches(alert Alert, now time.Time) bool {
+	until, err := time.Parse(time.RFC3339, s.Until)
+	if err != nil || !now.Before(until) {
+		return false
+	}
+	return (s.Rule == "" || s.Rule == alert.Rule) && (s.Node == "" || s.Node == alert.Node)
+}
+
+const (
+	ChannelWebhook = "webhook"
+	ChannelEmail   = "email"
+	ChannelSlack   = "slack"
+	ChannelDiscord = "discord"
+	ChannelTeams   = "teams"
+)
+
+type NotificationChannel struct {
+	ID              string            `json:"id"`
+	Name            string            `json:"name"`
+	Type            string            `json:"type"`
+	Enabled         bool              `json:"enabled"`
+	MinSeverity     string            `json:"minSeverity,omitempty"`
+	SendResolved    bool              `json:"sendResolved"`
+	Retries         int               `json:"retries"`
+	RetryBackoffSec int               `json:"retryBackoffSec"`
+	URL             string            `json:"url,omitempty"`
+	Secret          string            `json:"secret,omitempty"`
+	Template        string            `json:"template,omitempty"`
+	Headers         map[string]string `json:"headers,omitempty"`
+	SMTP            *SMTPSettings     `json:"smtp,omitempty"`
+	CreatedAt       string            `json:"createdAt,omitempty"`
+	UpdatedAt       string            `json:"updatedAt,omitempty"`
+}
+
+type SMTPSettings struct {
+	Host     string   `json:"host"`
+	Port     int      `json:"port"`
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	TLS      string   `json:"tls,omitempty"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+}
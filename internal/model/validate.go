@@ -0,0 +1,347 @@
+package model
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"strings"
+)
+
+var NodeTypes = map[string]struct{}{
+	"server":  {},
+	"pc":      {},
+	"router":  {},
+	"switch":  {},
+	"cloud":   {},
+	"network": {},
+}
+
+var networkHeaderPositions = map[string]struct{}{
+	"tl": {}, "tc": {}, "tr": {},
+	"ml": {}, "mc": {}, "mr": {},
+	"bl": {}, "bc": {}, "br": {},
+}
+
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e ValidationError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	parts := make([]string, 0, len(e))
+	for _, item := range e {
+		parts = append(parts, item.Error())
+	}
+	return strings.Join(parts, "; ")
+}
+
+func ValidateBoard(board *Board) ValidationErrors {
+	var errs ValidationErrors
+	add := func(field, format string, args ...any) {
+		errs = append(errs, ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if board.Meta.Monitoring.IntervalSec < 0 {
+		add("meta.monitoring.intervalSec", "must not be negative")
+	}
+	if board.Viewport.Zoom < 0 {
+		add("viewport.zoom", "must not be negative")
+	}
+
+	nodeIDs := make(map[string]struct{}, len(board.Nodes))
+	for i, node := range board.Nodes {
+		prefix := fmt.Sprintf("nodes[%d]", i)
+		id := strings.TrimSpace(node.ID)
+		if id == "" {
+			add(prefix+".id", "is required")
+		} else if _, dup := nodeIDs[id]; dup {
+			add(prefix+".id", "duplicate node id %q", id)
+		} else {
+			nodeIDs[id] = struct{}{}
+		}
+		if _, ok := NodeTypes[node.Type]; !ok {
+			add(prefix+".type", "unknown node type %q", node.Type)
+		}
+		if node.Width < 0 {
+			add(prefix+".width", "must not be negative")
+		}
+		if node.Height < 0 {
+			add(prefix+".height", "must not be negative")
+		}
+		if node.PingIntervalSec < 0 {
+			add(prefix+".pingIntervalSec", "must not be negative")
+		}
+		if node.FactsIntervalSec < 0 {
+			add(prefix+".factsIntervalSec", "must not be negative")
+		}
+		if node.LinkSpeedMbps < 0 {
+			add(prefix+".linkSpeedMbps", "must not be negative")
+		}
+		if node.NetworkHeaderPos != "" {
+			if _, ok := networkHeaderPositions[node.NetworkHeaderPos]; !ok {
+				add(prefix+".networkHeaderPos", "unknown position %q", node.NetworkHeaderPos)
+			}
+		}
+		checkIP := func(field, value string) {
+			if value == "" {
+				return
+			}
+			if net.ParseIP(strings.TrimSpace(value)) == nil {
+				add(prefix+"."+field, "invalid ip address %q", value)
+			}
+		}
+		if value := strings.TrimSpace(node.Hostname); value != "" && !validHostname(value) {
+			add(prefix+".hostname", "invalid hostname %q", node.Hostname)
+		}
+		checkIP("ipPrivate", node.IPPrivate)
+		checkIP("ipTailscale", node.IPTailscale)
+		checkIP("ipPublic", node.IPPublic)
+		if value := strings.TrimSpace(node.NetworkPublicIP); value != "" {
+			if _, _, err := net.ParseCIDR(value); err != nil && net.ParseIP(value) == nil {
+				add(prefix+".networkPublicIp", "invalid ip address or cidr %q", node.NetworkPublicIP)
+			}
+		}
+		checkIDs := make(map[string]struct{}, len(node.Checks))
+		for j, check := range node.Checks {
+			checkPrefix := fmt.Sprintf("%s.checks[%d]", prefix, j)
+			if check.ID == "" {
+				add(checkPrefix+".id", "is required")
+			} else if strings.ContainsAny(check.ID, "/ ") {
+				add(checkPrefix+".id", "must not contain spaces or slashes")
+			} else if _, dup := checkIDs[check.ID]; dup {
+				add(checkPrefix+".id", "duplicate check id %q", check.ID)
+			} else {
+				checkIDs[check.ID] = struct{}{}
+			}
+			for _, e := range ValidateServiceCheck(&check) {
+				add(checkPrefix+"."+e.Field, "%s", e.Message)
+			}
+		}
+	}
+
+	linkIDs := make(map[string]struct{}, len(board.Links))
+	for i, link := range board.Links {
+		prefix := fmt.Sprintf("links[%d]", i)
+		if link.ID != "" {
+			if _, dup := linkIDs[link.ID]; dup {
+				add(prefix+".id", "duplicate link id %q", link.ID)
+			}
+			linkIDs[link.ID] = struct{}{}
+		}
+		if _, ok := nodeIDs[link.From]; !ok {
+			add(prefix+".from", "unknown node %q", link.From)
+		}
+		if _, ok := nodeIDs[link.To]; !ok {
+			add(prefix+".to", "unknown node %q", link.To)
+		}
+		if link.From != "" && link.From == link.To {
+			add(prefix+".to", "link cannot connect a node to itself")
+		}
+	}
+
+	return errs
+}
+
+var CheckTypes = map[string]struct{}{
+	CheckTCP:  {},
+	CheckHTTP: {},
+	CheckDNS:  {},
+	CheckTLS:  {},
+}
+
+var DNSRecordTypes = map[string]struct{}{
+	"A":     {},
+	"AAAA":  {},
+	"CNAME": {},
+	"MX":    {},
+	"NS":    {},
+	"TXT":   {},
+}
+
+func validHostname(name string) bool {
+	name = strings.TrimSuffix(name, ".")
+	if name == "" || len(name) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(name, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func ValidateServiceCheck(check *ServiceCheck) ValidationErrors {
+	var errs ValidationErrors
+	add := func(field, format string, args ...any) {
+		errs = append(errs, ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+	if _, ok := CheckTypes[check.Type]; !ok {
+		add("type", "unknown check type %q", check.Type)
+	}
+	if check.Port < 0 || check.Port > 65535 {
+		add("port", "must be between 1 and 65535")
+	}
+	if check.IntervalSec != 0 && (check.IntervalSec < 10 || check.IntervalSec > 86400) {
+		add("intervalSec", "must be between 10 and 86400")
+	}
+	if check.TimeoutSec < 0 || check.TimeoutSec > 60 {
+		add("timeoutSec", "must be between 1 and 60")
+	}
+	switch check.Type {
+	case CheckTCP:
+		if check.Port == 0 {
+			add("port", "is required")
+		}
+	case CheckHTTP:
+		parsed, err := url.Parse(check.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			add("url", "must be an http or https url")
+		}
+		if check.ExpectStatus != 0 && (check.ExpectStatus < 100 || check.ExpectStatus > 599) {
+			add("expectStatus", "must be a valid http status")
+		}
+	case CheckDNS:
+		if strings.TrimSpace(check.Query) == "" {
+			add("query", "is required")
+		}
+		if check.RecordType != "" {
+			if _, ok := DNSRecordTypes[strings.ToUpper(check.RecordType)]; !ok {
+				add("recordType", "unsupported record type %q", check.RecordType)
+			}
+		}
+	case CheckTLS:
+		if check.WarnDays < 0 {
+			add("warnDays", "must not be negative")
+		}
+	}
+	return errs
+}
+
+var AlertConditions = map[string]struct{}{
+	AlertOffline:       {},
+	AlertRTTHigh:       {},
+	AlertSSHDownPingUp: {},
+}
+
+var AlertSeverities = map[string]struct{}{
+	"info":     {},
+	"warning":  {},
+	"critical": {},
+}
+
+func ValidateAlertRule(rule *AlertRule) ValidationErrors {
+	var errs ValidationErrors
+	add := func(field, format string, args ...any) {
+		errs = append(errs, ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if strings.TrimSpace(rule.Name) == "" {
+		add("name", "is required")
+	}
+	if _, ok := AlertConditions[rule.Condition]; !ok {
+		add("condition", "unknown condition %q (use offline, rtt_high or ssh_down_ping_up)", rule.Condition)
+	}
+	if _, ok := AlertSeverities[rule.Severity]; !ok {
+		add("severity", "unknown severity %q (use info, warning or critical)", rule.Severity)
+	}
+	if rule.Checks < 0 {
+		add("checks", "must not be negative")
+	}
+	if rule.ForSec < 0 {
+		add("forSec", "must not be negative")
+	}
+	if rule.Condition == AlertRTTHigh && rule.RTTMs <= 0 {
+		add("rttMs", "must be positive for rtt_high")
+	}
+	for i, node := range rule.Nodes {
+		if strings.TrimSpace(node) == "" {
+			add(fmt.Sprintf("nodes[%d]", i), "is empty")
+		}
+	}
+	return errs
+}
+
+var ChannelTypes = map[string]struct{}{
+	ChannelWebhook: {},
+	ChannelEmail:   {},
+	ChannelSlack:   {},
+	ChannelDiscord: {},
+	ChannelTeams:   {},
+}
+
+var smtpTLSModes = map[string]struct{}{
+	"":         {},
+	"starttls": {},
+	"tls":      {},
+	"none":     {},
+}
+
+func ValidateNotificationChannel(channel *NotificationChannel) ValidationErrors {
+	var errs ValidationErrors
+	add := func(field, format string, args ...any) {
+		errs = append(errs, ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if strings.TrimSpace(channel.Name) == "" {
+		add("name", "is required")
+	}
+	if _, ok := ChannelTypes[channel.Type]; !ok {
+		add("type", "unknown channel type %q (use webhook, email, slack, discord or teams)", channel.Type)
+	}
+	if channel.MinSeverity != "" {
+		if _, ok := AlertSeverities[channel.MinSeverity]; !ok {
+			add("minSeverity", "unknown severity %q (use info, warning or critical)", channel.MinSeverity)
+		}
+	}
+	if channel.Retries < 0 || channel.Retries > 10 {
+		add("retries", "must be between 0 and 10")
+	}
+	if channel.RetryBackoffSec < 0 {
+		add("retryBackoffSec", "must not be negative")
+	}
+	if channel.Type == ChannelEmail {
+		if channel.SMTP == nil {
+			add("smtp", "is required for email channels")
+			return errs
+		}
+		if strings.TrimSpace(channel.SMTP.Host) == "" {
+			add("smtp.host", "is required")
+		}
+		if channel.SMTP.Port < 0 || channel.SMTP.Port > 65535 {
+			add("smtp.port", "must be a valid port")
+		}
+		if _, ok := smtpTLSModes[channel.SMTP.TLS]; !ok {
+			add("smtp.tls", "unknown mode %q (use starttls, tls or none)", channel.SMTP.TLS)
+		}
+		if _, err := mail.ParseAddress(channel.SMTP.From); err != nil {
+			add("smtp.from", "invalid address %q", channel.SMTP.From)
+		}
+		if len(channel.SMTP.To) == 0 {
+			add("smtp.to", "at least one recipient is required")
+		}
+		for i, to := range channel.SMTP.To {
+			if _, err := mail.ParseAddress(to); err != nil {
+				add(fmt.Sprintf("smtp.to[%d]", i), "invalid address %q", to)
+			}
+		}
+		return errs
+	}
+	parsed, err := url.Parse(channel.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		add("url", "must be an http or https URL")
+	}
+	return errs
+}
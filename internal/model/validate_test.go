@@ -0,0 +1,115 @@
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestValidateBoard(t *testing.T) {
+	server := func(id string) Node {
+		return Node{ID: id, Type: "server"}
+	}
+	tests := []struct {
+		name   string
+		board  Board
+		fields []string
+	}{
+		{
+			name:  "valid",
+			board: Board{Nodes: []Node{server("a"), server("b")}, Links: []Link{{ID: "l1", From: "a", To: "b"}}},
+		},
+		{
+			name:   "missing and duplicate node ids",
+			board:  Board{Nodes: []Node{server(""), server("a"), server("a")}},
+			fields: []string{"nodes[0].id", "nodes[2].id"},
+		},
+		{
+			name:   "unknown node type",
+			board:  Board{Nodes: []Node{{ID: "a", Type: "toaster"}}},
+			fields: []string{"nodes[0].type"},
+		},
+		{
+			name: "negative numbers",
+			board: Board{
+				Meta:     BoardMeta{Monitoring: MonitoringSettings{IntervalSec: -1}},
+				Viewport: Viewport{Zoom: -1},
+				Nodes:    []Node{{ID: "a", Type: "network", Width: -1, Height: -1, PingIntervalSec: -1, LinkSpeedMbps: -1}},
+			},
+			fields: []string{
+				"meta.monitoring.intervalSec", "viewport.zoom",
+				"nodes[0].width", "nodes[0].height", "nodes[0].pingIntervalSec", "nodes[0].linkSpeedMbps",
+			},
+		},
+		{
+			name: "invalid addresses",
+			board: Board{Nodes: []Node{{
+				ID: "a", Type: "server",
+				Hostname: "bad host", IPPrivate: "10.0.0.300", IPTailscale: "100.64.0.1", IPPublic: "nope",
+			}}},
+			fields: []string{"nodes[0].hostname", "nodes[0].ipPrivate", "nodes[0].ipPublic"},
+		},
+		{
+			name:  "hostname and cidr accepted",
+			board: Board{Nodes: []Node{{ID: "a", Type: "network", Hostname: "web-1.example.com.", NetworkPublicIP: "203.0.113.0/24"}}},
+		},
+		{
+			name:   "unknown header position",
+			board:  Board{Nodes: []Node{{ID: "a", Type: "network", NetworkHeaderPos: "zz"}}},
+			fields: []string{"nodes[0].networkHeaderPos"},
+		},
+		{
+			name: "links",
+			board: Board{
+				Nodes: []Node{server("a"), server("b")},
+				Links: []Link{
+					{ID: "l1", From: "a", To: "b"},
+					{ID: "l1", From: "a", To: "missing"},
+					{ID: "l2", From: "a", To: "a"},
+				},
+			},
+			fields: []string{"links[1].id", "links[1].to", "links[2].to"},
+		},
+		{
+			name: "service checks",
+			board: Board{Nodes: []Node{{ID: "a", Type: "server", Checks: []ServiceCheck{
+				{ID: "web", Type: CheckHTTP, URL: "https://example.com"},
+				{ID: "web", Type: CheckTCP, Port: 22},
+				{ID: "a b", Type: CheckDNS},
+			}}}},
+			fields: []string{"nodes[0].checks[1].id", "nodes[0].checks[2].id", "nodes[0].checks[2].query"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateBoard(&tt.board)
+			var fields []string
+			for _, err := range errs {
+				fields = append(fields, err.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.fields) {
+				t.Fatalf("fields = %v, want %v (%v)", fields, tt.fields, errs)
+			}
+		})
+	}
+}
+
+func TestNodeKeepsPingShowStatus(t *testing.T) {
+	var board Board
+	if err := json.Unmarshal([]byte(`{"nodes":[{"id":"a","type":"server","pingShowStatus":false}]}`), &board); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := json.Marshal(board)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Nodes []map[string]any `json:"nodes"`
+	}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		t.Fatal(err)
+	}
+	if value, ok := out.Nodes[0]["pingShowStatus"]; !ok || value != false {
+		t.Fatalf("pingShowStatus = %v (present %t), want false", value, ok)
+	}
+}
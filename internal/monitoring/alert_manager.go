@@ -0,0 +1,395 @@
+package monitoring
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"inframap/internal/model"
+)
+
+type AlertStore interface {
+	Rules() ([]model.AlertRule, error)
+	Alerts() ([]model.Alert, error)
+	PutAlert(alert model.Alert) (model.Alert, error)
+	Acknowledge(id, author string) (model.Alert, error)
+	Silences() ([]model.Silence, error)
+}
+
+type AlertNotifier interface {
+	Notify(alert model.Alert)
+}
+
+type alertVerdict int
+
+const (
+	alertHold alertVerdict = iota
+	alertFire
+	alertResolve
+)
+
+type alertNodeState struct {
+	pingSeen  bool
+	pingUp    bool
+	pingFails int
+	pingError string
+	rttMs     int
+	sshSeen   bool
+	sshFails  int
+	sshError  string
+	rttSince  map[string]time.Time
+}
+
+type AlertManager struct {
+	mu       sync.Mutex
+	nodes    map[string]model.Node
+	rules    []model.AlertRule
+	state    map[string]*alertNodeState
+	active   map[string]model.Alert
+	store    AlertStore
+	notifier AlertNotifier
+	logger   Logger
+	events   EventPublisher
+}
+
+func NewAlertManager(store AlertStore, notifier AlertNotifier, logger Logger, events EventPublisher) *AlertManager {
+	m := &AlertManager{
+		nodes:    make(map[string]model.Node),
+		state:    make(map[string]*alertNodeState),
+		active:   make(map[string]model.Alert),
+		store:    store,
+		notifier: notifier,
+		logger:   logger,
+		events:   events,
+	}
+	if alerts, err := store.Alerts(); err != nil {
+		m.log("warn", fmt.Sprintf("failed to load alerts: %v", err))
+	} else {
+		for _, alert := range alerts {
+			if alert.State == model.AlertFiring {
+				m.active[alertKey(alert.Rule, alert.Node)] = alert
+			}
+		}
+	}
+	if rules, err := store.Rules(); err != nil {
+		m.log("warn", fmt.Sprintf("failed to load alert rules: %v", err))
+	} else {
+		m.rules = rules
+	}
+	return m
+}
+
+func (m *AlertManager) UpdateNodes(nodes []model.Node) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nodes = make(map[string]model.Node, len(nodes))
+	for _, node := range nodes {
+		if node.Type == "network" {
+			continue
+		}
+		m.nodes[node.ID] = node
+	}
+	for id := range m.state {
+		if _, ok := m.nodes[id]; !ok {
+			delete(m.state, id)
+		}
+	}
+	now := time.Now()
+	for _, alert := range m.active {
+		node, ok := m.nodes[alert.Node]
+		if !ok {
+			m.resolve(alert, "node removed from the board", now)
+			continue
+		}
+		if rule, ok := m.rule(alert.Rule); ok && !ruleApplies(rule, node) {
+			m.resolve(alert, "node no longer matches the rule", now)
+		}
+	}
+}
+
+func (m *AlertManager) ReloadRules() error {
+	rules, err := m.store.Rules()
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rules = rules
+	now := time.Now()
+	for _, alert := range m.active {
+		rule, ok := m.rule(alert.Rule)
+		switch {
+		case !ok:
+			m.resolve(alert, "rule deleted", now)
+		case !rule.Enabled:
+			m.resolve(alert, "rule disabled", now)
+		}
+	}
+	for _, state := range m.state {
+		for id := range state.rttSince {
+			if _, ok := m.rule(id); !ok {
+				delete(state.rttSince, id)
+			}
+		}
+	}
+	return nil
+}
+
+func (m *AlertManager) Acknowledge(id, author string) (model.Alert, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	alert, err := m.store.Acknowledge(id, author)
+	if err != nil {
+		return model.Alert{}, err
+	}
+	key := alertKey(alert.Rule, alert.Node)
+	if current, ok := m.active[key]; ok && current.ID == alert.ID {
+		m.active[key] = alert
+	}
+	m.log("info", fmt.Sprintf("alert %s for %s acknowledged by %s", alert.RuleName, alert.Node, author))
+	m.publish(alert)
+	return alert, nil
+}
+
+func (m *AlertManager) RecordPing(results map[string]model.PingResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for id, res := range results {
+		state := m.nodeState(id)
+		state.pingSeen = true
+		state.pingUp = res.Online
+		state.pingError = res.Error
+		state.rttMs = res.RTTMs
+		if res.Online {
+			state.pingFails = 0
+		} else {
+			state.pingFails++
+		}
+		m.evaluate(id, state, now)
+	}
+}
+
+func (m *AlertManager) RecordSSH(results map[string]model.SSHStatus) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	for id, res := range results {
+		state := m.nodeState(id)
+		state.sshSeen = true
+		state.sshError = res.Error
+		if res.Online {
+			state.sshFails = 0
+		} else {
+			state.sshFails++
+		}
+		m.evaluate(id, state, now)
+	}
+}
+
+func (m *AlertManager) nodeState(id string) *alertNodeState {
+	state, ok := m.state[id]
+	if !ok {
+		state = &alertNodeState{rttSince: make(map[string]time.Time)}
+		m.state[id] = state
+	}
+	return state
+}
+
+func (m *AlertManager) evaluate(nodeID string, state *alertNodeState, now time.Time) {
+	node, known := m.nodes[nodeID]
+	if !known {
+		node = model.Node{ID: nodeID}
+	}
+	for _, rule := range m.rules {
+		if !rule.Enabled || !ruleApplies(rule, node) {
+			continue
+		}
+		verdict, message := checkRule(rule, state, now)
+		key := alertKey(rule.ID, nodeID)
+		current, firing := m.active[key]
+		switch {
+		case verdict == alertFire && !firing:
+			m.fire(rule, nodeID, message, now)
+		case verdict == alertResolve && firing:
+			m.resolve(current, message, now)
+		}
+	}
+}
+
+func checkRule(rule model.AlertRule, state *alertNodeState, now time.Time) (alertVerdict, string) {
+	switch rule.Condition {
+	case model.AlertOffline:
+		if !state.pingSeen {
+			return alertHold, ""
+		}
+		checks := rule.Checks
+		if checks <= 0 {
+			checks = 3
+		}
+		if state.pingUp {
+			return alertResolve, "node is back online"
+		}
+		if state.pingFails >= checks {
+			message := fmt.Sprintf("offline for %d consecutive checks", state.pingFails)
+			if state.pingError != "" {
+				message += ": " + state.pingError
+			}
+			return alertFire, message
+		}
+	case model.AlertRTTHigh:
+		if !state.pingSeen || !state.pingUp {
+			delete(state.rttSince, rule.ID)
+			return alertHold, ""
+		}
+		if state.rttMs <= rule.RTTMs {
+			delete(state.rttSince, rule.ID)
+			return alertResolve, fmt.Sprintf("rtt %d ms is back under %d ms", state.rttMs, rule.RTTMs)
+		}
+		since, ok := state.rttSince[rule.ID]
+		if !ok {
+			since = now
+			state.rttSince[rule.ID] = since
+		}
+		forDuration := time.Duration(rule.ForSec) * time.Second
+		if rule.ForSec == 0 {
+			forDuration = 5 * time.Minute
+		}
+		if now.Sub(since) >= forDuration {
+			return alertFire, fmt.Sprintf("rtt %d ms over %d ms for %s", state.rttMs, rule.RTTMs, now.Sub(since).Round(time.Second))
+		}
+	case model.AlertSSHDownPingUp:
+		if !state.sshSeen || !state.pingSeen {
+			return alertHold, ""
+		}
+		checks := rule.Checks
+		if checks <= 0 {
+			checks = 2
+		}
+		if state.sshFails == 0 {
+			return alertResolve, "SSH is reachable again"
+		}
+		if !state.pingUp {
+			return alertResolve, "node is offline"
+		}
+		if state.sshFails >= checks {
+			message := fmt.Sprintf("SSH down for %d checks while ping is up", state.sshFails)
+			if state.sshError != "" {
+				message += ": " + state.sshError
+			}
+			return alertFire, message
+		}
+	}
+	return alertHold, ""
+}
+
+func (m *AlertManager) fire(rule model.AlertRule, nodeID, message string, now time.Time) {
+	stamp := now.UTC().Format(time.RFC3339)
+	alert := model.Alert{
+		Rule:      rule.ID,
+		RuleName:  rule.Name,
+		Condition: rule.Condition,
+		Severity:  rule.Severity,
+		Node:      nodeID,
+		State:     model.AlertFiring,
+		Message:   message,
+		StartedAt: stamp,
+		UpdatedAt: stamp,
+	}
+	alert.Silenced = m.silenced(alert, now)
+	stored, err := m.store.PutAlert(alert)
+	if err != nil {
+		m.log("warn", fmt.Sprintf("failed to store alert %s for %s: %v", rule.Name, nodeID, err))
+		return
+	}
+	m.active[alertKey(rule.ID, nodeID)] = stored
+	level := "warn"
+	if stored.Silenced {
+		level = "info"
+	}
+	m.log(level, fmt.Sprintf("alert %s firing for %s: %s", rule.Name, nodeID, message))
+	m.publish(stored)
+	m.notify(stored)
+}
+
+func (m *AlertManager) resolve(alert model.Alert, message string, now time.Time) {
+	stamp := now.UTC().Format(time.RFC3339)
+	alert.State = model.AlertResolved
+	alert.Message = message
+	alert.UpdatedAt = stamp
+	alert.ResolvedAt = stamp
+	alert.Silenced = m.silenced(alert, now)
+	delete(m.active, alertKey(alert.Rule, alert.Node))
+	stored, err := m.store.PutAlert(alert)
+	if err != nil {
+		m.log("warn", fmt.Sprintf("failed to store alert %s for %s: %v", alert.RuleName, alert.Node, err))
+		return
+	}
+	m.log("info", fmt.Sprintf("alert %s resolved for %s: %s", alert.RuleName, alert.Node, message))
+	m.publish(stored)
+	m.notify(stored)
+}
+
+func (m *AlertManager) silenced(alert model.Alert, now time.Time) bool {
+	silences, err := m.store.Silences()
+	if err != nil {
+		return false
+	}
+	for _, silence := range silences {
+		if silence.Matches(alert, now) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *AlertManager) rule(id string) (model.AlertRule, bool) {
+	for _, rule := range m.rules {
+		if rule.ID == id {
+			return rule, true
+		}
+	}
+	return model.AlertRule{}, false
+}
+
+func ruleApplies(rule model.AlertRule, node model.Node) bool {
+	if rule.Network != "" && node.Network != rule.Network {
+		return false
+	}
+	if len(rule.Nodes) == 0 {
+		return true
+	}
+	for _, id := range rule.Nodes {
+		if id == node.ID {
+			return true
+		}
+	}
+	return false
+}
+
+func alertKey(rule, node string) string {
+	return rule + "/" + node
+}
+
+func (m *AlertManager) log(level, message string) {
+	if m.logger == nil {
+		return
+	}
+	m.logger.Add(level, "alerts", message)
+}
+
+func (m *AlertManager) publish(alert model.Alert) {
+	if m.events == nil {
+		return
+	}
+	m.events.Publish("alert", map[string]any{
+		"alert": alert,
+	})
+}
+
+func (m *AlertManager) notify(alert model.Alert) {
+	if m.notifier == nil {
+		return
+	}
+	m.notifier.Notify(alert)
+}
@@ -0,0 +1,245 @@
+package monitoring
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"inframap/internal/model"
+)
+
+type memoryAlertStore struct {
+	mu       sync.Mutex
+	rules    []model.AlertRule
+	alerts   map[string]model.Alert
+	silences []model.Silence
+	next     int
+}
+
+func (s *memoryAlertStore) Rules() ([]model.AlertRule, error) { return s.rules, nil }
+
+func (s *memoryAlertStore) Alerts() ([]model.Alert, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []model.Alert
+	for _, alert := range s.alerts {
+		out = append(out, alert)
+	}
+	return out, nil
+}
+
+func (s *memoryAlertStore) PutAlert(alert model.Alert) (model.Alert, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if alert.ID == "" {
+		s.next++
+		alert.ID = fmt.Sprintf("alert-%d", s.next)
+	}
+	if s.alerts == nil {
+		s.alerts = make(map[string]model.Alert)
+	}
+	s.alerts[alert.ID] = alert
+	return alert, nil
+}
+
+func (s *memoryAlertStore) Acknowledge(id, author string) (model.Alert, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	alert, ok := s.alerts[id]
+	if !ok {
+		return model.Alert{}, errors.New("not found")
+	}
+	alert.AckedBy = author
+	s.alerts[id] = alert
+	return alert, nil
+}
+
+func (s *memoryAlertStore) Silences() ([]model.Silence, error) { return s.silences, nil }
+
+type recordingNotifier struct {
+	alerts []model.Alert
+}
+
+func (n *recordingNotifier) Notify(alert model.Alert) {
+	n.alerts = append(n.alerts, alert)
+}
+
+func newTestAlertManager(rules ...model.AlertRule) (*AlertManager, *memoryAlertStore, *recordingNotifier) {
+	store := &memoryAlertStore{rules: rules}
+	notifier := &recordingNotifier{}
+	m := NewAlertManager(store, notifier, nil, nil)
+	m.UpdateNodes([]model.Node{{ID: "a", Network: "lan"}, {ID: "b", Network: "dmz"}})
+	return m, store, notifier
+}
+
+func pingResults(online bool, rtt int) map[string]model.PingResult {
+	return map[string]model.PingResult{"a": {PingProbe: model.PingProbe{Online: online, RTTMs: rtt}}}
+}
+
+func sshResults(online bool) map[string]model.SSHStatus {
+	status := model.SSHStatus{Online: online}
+	if !online {
+		status.Error = "connection refused"
+	}
+	return map[string]model.SSHStatus{"a": status}
+}
+
+func TestAlertOfflineFiresResolvesAndDedups(t *testing.T) {
+	m, _, notifier := newTestAlertManager(model.AlertRule{ID: "r1", Name: "offline", Condition: model.AlertOffline, Checks: 2, Severity: "critical", Enabled: true})
+
+	m.RecordPing(pingResults(false, 0))
+	if len(notifier.alerts) != 0 {
+		t.Fatalf("fired after one failure: %+v", notifier.alerts)
+	}
+	m.RecordPing(pingResults(false, 0))
+	m.RecordPing(pingResults(false, 0))
+	m.RecordPing(pingResults(false, 0))
+	if len(notifier.alerts) != 1 {
+		t.Fatalf("notifications while down = %+v", notifier.alerts)
+	}
+	fired := notifier.alerts[0]
+	if fired.State != model.AlertFiring || fired.Node != "a" || fired.Severity != "critical" || fired.Message != "offline for 2 consecutive checks" {
+		t.Fatalf("fired = %+v", fired)
+	}
+
+	m.RecordPing(pingResults(true, 3))
+	m.RecordPing(pingResults(true, 3))
+	if len(notifier.alerts) != 2 {
+		t.Fatalf("notifications after recovery = %+v", notifier.alerts)
+	}
+	resolved := notifier.alerts[1]
+	if resolved.ID != fired.ID || resolved.State != model.AlertResolved || resolved.ResolvedAt == "" {
+		t.Fatalf("resolved = %+v", resolved)
+	}
+
+	m.RecordPing(pingResults(false, 0))
+	m.RecordPing(pingResults(false, 0))
+	if len(notifier.alerts) != 3 || notifier.alerts[2].ID == fired.ID {
+		t.Fatalf("second outage = %+v", notifier.alerts)
+	}
+}
+
+func TestAlertRuleScope(t *testing.T) {
+	m, _, notifier := newTestAlertManager(model.AlertRule{ID: "r1", Name: "dmz offline", Condition: model.AlertOffline, Checks: 1, Network: "dmz", Enabled: true})
+	m.RecordPing(pingResults(false, 0))
+	if len(notifier.alerts) != 0 {
+		t.Fatalf("rule for dmz fired on lan node: %+v", notifier.alerts)
+	}
+	m.RecordPing(map[string]model.PingResult{"b": {}})
+	if len(notifier.alerts) != 1 || notifier.alerts[0].Node != "b" {
+		t.Fatalf("alerts = %+v", notifier.alerts)
+	}
+
+	m.UpdateNodes([]model.Node{{ID: "a", Network: "lan"}})
+	if len(notifier.alerts) != 2 || notifier.alerts[1].State != model.AlertResolved || notifier.alerts[1].Message != "node removed from the board" {
+		t.Fatalf("alerts after node removal = %+v", notifier.alerts)
+	}
+}
+
+func TestAlertSilence(t *testing.T) {
+	m, store, notifier := newTestAlertManager(model.AlertRule{ID: "r1", Name: "offline", Condition: model.AlertOffline, Checks: 1, Enabled: true})
+	store.silences = []model.Silence{{ID: "s1", Node: "a", Until: time.Now().Add(time.Hour).UTC().Format(time.RFC3339)}}
+	m.RecordPing(pingResults(false, 0))
+	if len(notifier.alerts) != 1 || !notifier.alerts[0].Silenced {
+		t.Fatalf("silenced alert = %+v", notifier.alerts)
+	}
+
+	store.silences = []model.Silence{{ID: "s2", Node: "a", Until: time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)}}
+	m.RecordPing(pingResults(true, 1))
+	if len(notifier.alerts) != 2 || notifier.alerts[1].Silenced {
+		t.Fatalf("resolve after silence expired = %+v", notifier.alerts)
+	}
+}
+
+func TestAlertRTTHighNeedsDuration(t *testing.T) {
+	rule := model.AlertRule{ID: "rtt", Condition: model.AlertRTTHigh, RTTMs: 100, ForSec: 60}
+	state := &alertNodeState{pingSeen: true, pingUp: true, rttSince: make(map[string]time.Time)}
+	start := time.Now()
+	tests := []struct {
+		rtt     int
+		after   time.Duration
+		verdict alertVerdict
+	}{
+		{150, 0, alertHold},
+		{180, 30 * time.Second, alertHold},
+		{160, 60 * time.Second, alertFire},
+		{90, 70 * time.Second, alertResolve},
+		{150, 80 * time.Second, alertHold},
+		{150, 130 * time.Second, alertHold},
+		{150, 140 * time.Second, alertFire},
+	}
+	for i, tt := range tests {
+		state.rttMs = tt.rtt
+		if verdict, message := checkRule(rule, state, start.Add(tt.after)); verdict != tt.verdict {
+			t.Fatalf("step %d (%d ms at +%s) = %v %q, want %v", i, tt.rtt, tt.after, verdict, message, tt.verdict)
+		}
+	}
+
+	state.pingUp = false
+	if verdict, _ := checkRule(rule, state, start.Add(time.Hour)); verdict != alertHold {
+		t.Fatalf("offline node verdict = %v", verdict)
+	}
+	if _, ok := state.rttSince[rule.ID]; ok {
+		t.Fatal("going offline kept the rtt timer")
+	}
+
+	defaults := model.AlertRule{ID: "rtt", Condition: model.AlertRTTHigh, RTTMs: 100}
+	state = &alertNodeState{pingSeen: true, pingUp: true, rttMs: 200, rttSince: map[string]time.Time{"rtt": start}}
+	if verdict, _ := checkRule(defaults, state, start.Add(4*time.Minute)); verdict != alertHold {
+		t.Fatal("default duration fired before five minutes")
+	}
+	if verdict, _ := checkRule(defaults, state, start.Add(5*time.Minute)); verdict != alertFire {
+		t.Fatal("default duration did not fire after five minutes")
+	}
+}
+
+func TestAlertSSHDownPingUp(t *testing.T) {
+	m, _, notifier := newTestAlertManager(model.AlertRule{ID: "r1", Name: "ssh", Condition: model.AlertSSHDownPingUp, Enabled: true})
+
+	m.RecordSSH(sshResults(false))
+	m.RecordSSH(sshResults(false))
+	if len(notifier.alerts) != 0 {
+		t.Fatalf("fired before ping was seen: %+v", notifier.alerts)
+	}
+	m.RecordPing(pingResults(true, 1))
+	if len(notifier.alerts) != 1 || notifier.alerts[0].Message != "SSH down for 2 checks while ping is up: connection refused" {
+		t.Fatalf("alerts = %+v", notifier.alerts)
+	}
+	m.RecordPing(pingResults(false, 0))
+	if len(notifier.alerts) != 2 || notifier.alerts[1].Message != "node is offline" {
+		t.Fatalf("alerts after node went offline = %+v", notifier.alerts)
+	}
+
+	m.RecordPing(pingResults(true, 1))
+	m.RecordSSH(sshResults(false))
+	if len(notifier.alerts) != 3 || notifier.alerts[2].State != model.AlertFiring {
+		t.Fatalf("alerts after ping came back = %+v", notifier.alerts)
+	}
+	m.RecordSSH(sshResults(true))
+	if len(notifier.alerts) != 4 || notifier.alerts[3].Message != "SSH is reachable again" {
+		t.Fatalf("alerts after ssh came back = %+v", notifier.alerts)
+	}
+}
+
+func TestAlertManagerRestoresFiringAlerts(t *testing.T) {
+	rule := model.AlertRule{ID: "r1", Name: "offline", Condition: model.AlertOffline, Checks: 1, Enabled: true}
+	m, store, notifier := newTestAlertManager(rule)
+	m.RecordPing(pingResults(false, 0))
+
+	restarted := NewAlertManager(store, notifier, nil, nil)
+	restarted.UpdateNodes([]model.Node{{ID: "a"}})
+	restarted.RecordPing(pingResults(false, 0))
+	if len(notifier.alerts) != 1 {
+		t.Fatalf("restart fired a duplicate: %+v", notifier.alerts)
+	}
+
+	store.rules[0].Enabled = false
+	if err := restarted.ReloadRules(); err != nil {
+		t.Fatal(err)
+	}
+	if len(notifier.alerts) != 2 || notifier.alerts[1].Message != "rule disabled" {
+		t.Fatalf("alerts after disabling rule = %+v", notifier.alerts)
+	}
+}
@@ -0,0 +1,255 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"inframap/internal/model"
+)
+
+type CheckRecorder interface {
+	RecordChecks(results map[string]model.CheckResult)
+}
+
+type CheckManager struct {
+	mu       sync.RWMutex
+	nodes    []model.Node
+	status   map[string]map[string]model.CheckResult
+	running  map[string]struct{}
+	sem      chan struct{}
+	stats    CycleStats
+	updateCh chan struct{}
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	ctx      context.Context
+	cancel   context.CancelFunc
+	logger   Logger
+	events   EventPublisher
+	recorder CheckRecorder
+}
+
+func NewCheckManager(logger Logger, events EventPublisher, recorder CheckRecorder) *CheckManager {
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &CheckManager{
+		status:   make(map[string]map[string]model.CheckResult),
+		running:  make(map[string]struct{}),
+		sem:      make(chan struct{}, 8),
+		updateCh: make(chan struct{}, 1),
+		stopCh:   make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+		logger:   logger,
+		events:   events,
+		recorder: recorder,
+	}
+	go m.loop()
+	return m
+}
+
+func (m *CheckManager) UpdateNodes(nodes []model.Node) {
+	m.mu.Lock()
+	m.nodes = nodes
+	m.mu.Unlock()
+	m.signalUpdate()
+}
+
+func (m *CheckManager) GetStatus() map[string]map[string]model.CheckResult {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make(map[string]map[string]model.CheckResult, len(m.status))
+	for node, checks := range m.status {
+		copied := make(map[string]model.CheckResult, len(checks))
+		for id, res := range checks {
+			copied[id] = res
+		}
+		out[node] = copied
+	}
+	return out
+}
+
+func (m *CheckManager) Stats() CycleStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.stats
+}
+
+func (m *CheckManager) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.stopCh)
+		m.cancel()
+	})
+}
+
+func (m *CheckManager) signalUpdate() {
+	select {
+	case m.updateCh <- struct{}{}:
+	default:
+	}
+}
+
+func (m *CheckManager) loop() {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case <-ticker.C:
+			m.runChecks()
+		case <-m.updateCh:
+			m.runChecks()
+		}
+	}
+}
+
+type dueCheck struct {
+	node  model.Node
+	check model.ServiceCheck
+}
+
+func (m *CheckManager) runChecks() {
+	start := time.Now()
+	nodes := m.getNodesSnapshot()
+	configured := make(map[string]struct{})
+	var due []dueCheck
+
+	m.mu.Lock()
+	for _, node := range nodes {
+		if node.Type == "network" {
+			continue
+		}
+		for _, check := range node.Checks {
+			if check.Disabled {
+				continue
+			}
+			key := node.ID + "/" + check.ID
+			configured[key] = struct{}{}
+			if _, busy := m.running[key]; busy {
+				continue
+			}
+			if last, ok := m.status[node.ID][check.ID]; ok && last.Type == check.Type {
+				if time.Since(last.LastChecked) < checkInterval(check) {
+					continue
+				}
+			}
+			due = append(due, dueCheck{node: node, check: check})
+		}
+	}
+	removed := []string{}
+	for nodeID, checks := range m.status {
+		for id := range checks {
+			if _, ok := configured[nodeID+"/"+id]; !ok {
+				delete(checks, id)
+				removed = append(removed, nodeID+"/"+id)
+			}
+		}
+		if len(checks) == 0 {
+			delete(m.status, nodeID)
+		}
+	}
+	m.stats.observe(start)
+	m.mu.Unlock()
+	if len(removed) > 0 {
+		m.publish("check", map[string]any{
+			"results": map[string]map[string]model.CheckResult{},
+			"removed": removed,
+		})
+	}
+
+	for _, item := range due {
+		select {
+		case m.sem <- struct{}{}:
+		default:
+			return
+		}
+		m.mu.Lock()
+		m.running[item.node.ID+"/"+item.check.ID] = struct{}{}
+		m.mu.Unlock()
+		go func(item dueCheck) {
+			defer func() { <-m.sem }()
+			m.record(item.node, item.check, runServiceCheck(m.ctx, item.node, item.check))
+		}(item)
+	}
+}
+
+func (m *CheckManager) record(node model.Node, check model.ServiceCheck, res model.CheckResult) {
+	nodeID := node.ID
+	key := nodeID + "/" + check.ID
+	m.mu.Lock()
+	delete(m.running, key)
+	if m.ctx.Err() != nil || !m.configured(node, check) {
+		m.mu.Unlock()
+		return
+	}
+	if m.status[nodeID] == nil {
+		m.status[nodeID] = make(map[string]model.CheckResult)
+	}
+	prev, seen := m.status[nodeID][check.ID]
+	if seen && prev.Status != res.Status {
+		m.logTransition(nodeID, check.ID, prev, res)
+	}
+	m.status[nodeID][check.ID] = res
+	m.mu.Unlock()
+	if m.recorder != nil {
+		m.recorder.RecordChecks(map[string]model.CheckResult{key: res})
+	}
+	m.publish("check", map[string]any{
+		"results": map[string]map[string]model.CheckResult{nodeID: {check.ID: res}},
+		"removed": []string{},
+	})
+}
+
+func (m *CheckManager) configured(checked model.Node, check model.ServiceCheck) bool {
+	for _, node := range m.nodes {
+		if node.ID != checked.ID || node.Type == "network" {
+			continue
+		}
+		for _, current := range node.Checks {
+			if current.ID == check.ID {
+				return current == check && checkTarget(node, current) == checkTarget(checked, check)
+			}
+		}
+	}
+	return false
+}
+
+func (m *CheckManager) logTransition(nodeID, checkID string, prev, res model.CheckResult) {
+	if m.logger == nil {
+		return
+	}
+	level := "info"
+	if res.Status != model.CheckUp {
+		level = "warn"
+	}
+	message := fmt.Sprintf("%s check %s on %s: %s -> %s", res.Type, checkID, nodeID, prev.Status, res.Status)
+	if res.Error != "" {
+		message += " (" + res.Error + ")"
+	} else if res.Status == model.CheckWarn && res.Detail != "" {
+		message += " (" + res.Detail + ")"
+	}
+	m.logger.Add(level, "checks", message)
+}
+
+func (m *CheckManager) publish(kind string, payload any) {
+	if m.events == nil {
+		return
+	}
+	m.events.Publish(kind, payload)
+}
+
+func (m *CheckManager) getNodesSnapshot() []model.Node {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	nodes := make([]model.Node, len(m.nodes))
+	copy(nodes, m.nodes)
+	return nodes
+}
+
+func checkInterval(check model.ServiceCheck) time.Duration {
+	if check.IntervalSec > 0 {
+		return time.Duration(check.IntervalSec) * time.Second
+	}
+	return 60 * time.Second
+}
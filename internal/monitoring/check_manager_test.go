@@ -0,0 +1,148 @@
+package monitoring
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"inframap/internal/model"
+)
+
+type slowServer struct {
+	*httptest.Server
+	release chan struct{}
+	slowHit int32
+}
+
+func newSlowServer(t *testing.T) *slowServer {
+	srv := &slowServer{release: make(chan struct{})}
+	srv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			atomic.AddInt32(&srv.slowHit, 1)
+			<-srv.release
+		}
+	}))
+	t.Cleanup(func() {
+		srv.unblock()
+		srv.Close()
+	})
+	return srv
+}
+
+func (s *slowServer) unblock() {
+	select {
+	case <-s.release:
+	default:
+		close(s.release)
+	}
+}
+
+func (s *slowServer) node(checks ...string) model.Node {
+	node := model.Node{ID: "a", Type: "server"}
+	for _, id := range checks {
+		node.Checks = append(node.Checks, model.ServiceCheck{ID: id, Type: model.CheckHTTP, URL: s.URL + "/" + id, TimeoutSec: 10})
+	}
+	return node
+}
+
+func waitCheck(t *testing.T, m *CheckManager, node, check string) model.CheckResult {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if res, ok := m.GetStatus()[node][check]; ok {
+			return res
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("no result for %s/%s", node, check)
+	return model.CheckResult{}
+}
+
+func TestCheckManagerRecordsChecksAsTheyFinish(t *testing.T) {
+	srv := newSlowServer(t)
+	m := NewCheckManager(nil, nil, nil)
+	defer m.Stop()
+	m.UpdateNodes([]model.Node{srv.node("slow", "fast")})
+
+	if res := waitCheck(t, m, "a", "fast"); res.Status != model.CheckUp {
+		t.Fatalf("fast = %+v", res)
+	}
+	if _, ok := m.GetStatus()["a"]["slow"]; ok {
+		t.Fatal("slow check finished before it was released")
+	}
+
+	m.UpdateNodes([]model.Node{srv.node("slow", "fast")})
+	time.Sleep(50 * time.Millisecond)
+	if hits := atomic.LoadInt32(&srv.slowHit); hits != 1 {
+		t.Fatalf("slow check dispatched %d times while running", hits)
+	}
+
+	srv.unblock()
+	if res := waitCheck(t, m, "a", "slow"); res.Status != model.CheckUp {
+		t.Fatalf("slow = %+v", res)
+	}
+}
+
+func TestCheckManagerDropsResultsOfRemovedChecks(t *testing.T) {
+	srv := newSlowServer(t)
+	m := NewCheckManager(nil, nil, nil)
+	defer m.Stop()
+	m.UpdateNodes([]model.Node{srv.node("slow", "fast")})
+	waitCheck(t, m, "a", "fast")
+	for atomic.LoadInt32(&srv.slowHit) == 0 {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	m.UpdateNodes([]model.Node{srv.node("fast")})
+	srv.unblock()
+	time.Sleep(100 * time.Millisecond)
+	status := m.GetStatus()
+	if _, ok := status["a"]["slow"]; ok {
+		t.Fatal("result of a removed check was stored")
+	}
+	if _, ok := status["a"]["fast"]; !ok {
+		t.Fatal("fast check result was lost")
+	}
+}
+
+func TestCheckManagerDropsResultsOfChangedChecks(t *testing.T) {
+	srv := newSlowServer(t)
+	m := NewCheckManager(nil, nil, nil)
+	defer m.Stop()
+	m.UpdateNodes([]model.Node{srv.node("slow")})
+	for atomic.LoadInt32(&srv.slowHit) == 0 {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	changed := srv.node("slow")
+	changed.Checks[0].ExpectStatus = http.StatusNoContent
+	m.UpdateNodes([]model.Node{changed})
+	srv.unblock()
+	time.Sleep(100 * time.Millisecond)
+	if res, ok := m.GetStatus()["a"]["slow"]; ok {
+		t.Fatalf("result of the old check config was stored: %+v", res)
+	}
+}
+
+func TestCheckManagerStopCancelsRunningChecks(t *testing.T) {
+	srv := newSlowServer(t)
+	m := NewCheckManager(nil, nil, nil)
+	m.UpdateNodes([]model.Node{srv.node("slow")})
+	for atomic.LoadInt32(&srv.slowHit) == 0 {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	m.Stop()
+	deadline := time.Now().Add(2 * time.Second)
+	for len(m.sem) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("running check was not cancelled by Stop")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, ok := m.GetStatus()["a"]["slow"]; ok {
+		t.Fatal("cancelled check result was stored")
+	}
+}
@@ -0,0 +1,258 @@
+package monitoring
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"inframap/internal/model"
+)
+
+func checkTarget(node model.Node, check model.ServiceCheck) string {
+	if host := strings.TrimSpace(check.Host); host != "" {
+		return host
+	}
+	return pickTarget(node)
+}
+
+func checkTimeout(check model.ServiceCheck) time.Duration {
+	if check.TimeoutSec > 0 {
+		return time.Duration(check.TimeoutSec) * time.Second
+	}
+	return 10 * time.Second
+}
+
+func runServiceCheck(ctx context.Context, node model.Node, check model.ServiceCheck) model.CheckResult {
+	result := model.CheckResult{
+		Check:       check.ID,
+		Type:        check.Type,
+		Status:      model.CheckDown,
+		LastChecked: time.Now().UTC(),
+	}
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout(check))
+	defer cancel()
+
+	start := time.Now()
+	var err error
+	switch check.Type {
+	case model.CheckTCP:
+		err = checkTCP(ctx, node, check, &result)
+	case model.CheckHTTP:
+		err = checkHTTP(ctx, check, &result)
+	case model.CheckDNS:
+		err = checkDNS(ctx, node, check, &result)
+	case model.CheckTLS:
+		err = checkTLS(ctx, node, check, &result)
+	default:
+		err = fmt.Errorf("unknown check type %q", check.Type)
+	}
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("timeout after %s", checkTimeout(check))
+		}
+		result.Status = model.CheckDown
+		result.Error = err.Error()
+		return result
+	}
+	if result.LatencyMs == 0 {
+		result.LatencyMs = durationMs(time.Since(start))
+	}
+	if result.Status == model.CheckDown {
+		result.Status = model.CheckUp
+	}
+	result.Online = true
+	return result
+}
+
+func checkTCP(ctx context.Context, node model.Node, check model.ServiceCheck, result *model.CheckResult) error {
+	host := checkTarget(node, check)
+	if host == "" {
+		return errors.New("no host")
+	}
+	result.Target = net.JoinHostPort(host, strconv.Itoa(check.Port))
+	var dialer net.Dialer
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", result.Target)
+	if err != nil {
+		return err
+	}
+	result.LatencyMs = durationMs(time.Since(start))
+	return conn.Close()
+}
+
+func checkHTTP(ctx context.Context, check model.ServiceCheck, result *model.CheckResult) error {
+	result.Target = check.URL
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, check.URL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "InfraMap")
+	transport := &http.Transport{
+		Proxy:             http.ProxyFromEnvironment,
+		DisableKeepAlives: true,
+		TLSClientConfig:   &tls.Config{InsecureSkipVerify: check.Insecure},
+	}
+	client := &http.Client{Transport: transport}
+	defer transport.CloseIdleConnections()
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	result.LatencyMs = durationMs(time.Since(start))
+	result.Detail = resp.Status
+	if check.ExpectStatus != 0 {
+		if resp.StatusCode != check.ExpectStatus {
+			return fmt.Errorf("status %d, expected %d", resp.StatusCode, check.ExpectStatus)
+		}
+	} else if resp.StatusCode >= 400 {
+		return fmt.Errorf("status %s", resp.Status)
+	}
+	if check.ExpectBody != "" && !bytes.Contains(body, []byte(check.ExpectBody)) {
+		return fmt.Errorf("body does not contain %q", check.ExpectBody)
+	}
+	return nil
+}
+
+func checkDNS(ctx context.Context, node model.Node, check model.ServiceCheck, result *model.CheckResult) error {
+	server := strings.TrimSpace(check.Resolver)
+	if server == "" {
+		server = checkTarget(node, check)
+	}
+	if server == "" {
+		return errors.New("no resolver")
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		port := check.Port
+		if port == 0 {
+			port = 53
+		}
+		server = net.JoinHostPort(server, strconv.Itoa(port))
+	}
+	name := strings.TrimSpace(check.Query)
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+	recordType := strings.ToUpper(check.RecordType)
+	if recordType == "" {
+		recordType = "A"
+	}
+	result.Target = server
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, server)
+		},
+	}
+
+	start := time.Now()
+	var answers []string
+	var err error
+	switch recordType {
+	case "A", "AAAA":
+		var addrs []net.IPAddr
+		addrs, err = resolver.LookupIPAddr(ctx, name)
+		for _, addr := range addrs {
+			if (addr.IP.To4() != nil) == (recordType == "A") {
+				answers = append(answers, addr.IP.String())
+			}
+		}
+	case "CNAME":
+		var cname string
+		cname, err = resolver.LookupCNAME(ctx, name)
+		if cname != "" {
+			answers = append(answers, cname)
+		}
+	case "MX":
+		var records []*net.MX
+		records, err = resolver.LookupMX(ctx, name)
+		for _, record := range records {
+			answers = append(answers, record.Host)
+		}
+	case "NS":
+		var records []*net.NS
+		records, err = resolver.LookupNS(ctx, name)
+		for _, record := range records {
+			answers = append(answers, record.Host)
+		}
+	case "TXT":
+		answers, err = resolver.LookupTXT(ctx, name)
+	default:
+		err = fmt.Errorf("unsupported record type %q", recordType)
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return fmt.Errorf("%s %s: %s", recordType, check.Query, dnsErr.Err)
+	}
+	if err != nil {
+		return err
+	}
+	result.LatencyMs = durationMs(time.Since(start))
+	if len(answers) == 0 {
+		return fmt.Errorf("no %s records for %s", recordType, check.Query)
+	}
+	result.Detail = strings.Join(answers, ", ")
+	if expected := strings.TrimSpace(check.ExpectAnswer); expected != "" {
+		for _, answer := range answers {
+			if strings.EqualFold(strings.TrimSuffix(answer, "."), strings.TrimSuffix(expected, ".")) {
+				return nil
+			}
+		}
+		return fmt.Errorf("answer %s does not include %s", result.Detail, expected)
+	}
+	return nil
+}
+
+func checkTLS(ctx context.Context, node model.Node, check model.ServiceCheck, result *model.CheckResult) error {
+	host := checkTarget(node, check)
+	if host == "" {
+		return errors.New("no host")
+	}
+	port := check.Port
+	if port == 0 {
+		port = 443
+	}
+	result.Target = net.JoinHostPort(host, strconv.Itoa(port))
+	dialer := &tls.Dialer{Config: &tls.Config{ServerName: host, InsecureSkipVerify: check.Insecure}}
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", result.Target)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	result.LatencyMs = durationMs(time.Since(start))
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return errors.New("no certificate presented")
+	}
+	leaf := certs[0]
+	days := int(time.Until(leaf.NotAfter).Hours() / 24)
+	result.DaysLeft = &days
+	result.Detail = fmt.Sprintf("%s expires %s", leaf.Subject.CommonName, leaf.NotAfter.UTC().Format("2006-01-02"))
+	if time.Now().After(leaf.NotAfter) {
+		return fmt.Errorf("certificate expired on %s", leaf.NotAfter.UTC().Format("2006-01-02"))
+	}
+	warnDays := check.WarnDays
+	if warnDays == 0 {
+		warnDays = 14
+	}
+	if days < warnDays {
+		result.Status = model.CheckWarn
+	}
+	return nil
+}
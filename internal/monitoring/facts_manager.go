@@ -0,0 +1,372 @@
+package monitoring
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"inframap/internal/model"
+	"inframap/internal/sshutil"
+)
+
+var ErrFactsUnavailable = errors.New("SSH is not enabled for this device")
+
+type FactsStore interface {
+	Get(node string) (model.NodeFacts, bool, error)
+	Record(node string, facts map[string]model.Fact) (model.NodeFacts, error)
+}
+
+type FactsConfig struct {
+	Interval       time.Duration
+	MinInterval    time.Duration
+	MaxBackoff     time.Duration
+	Concurrency    int
+	CommandTimeout time.Duration
+}
+
+type factsSchedule struct {
+	next     time.Time
+	failures int
+	running  bool
+}
+
+type FactsManager struct {
+	mu       sync.Mutex
+	nodes    map[string]model.Node
+	schedule map[string]*factsSchedule
+	updateCh chan struct{}
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	sem      chan struct{}
+	cfg      FactsConfig
+	provider DeviceSettingsProvider
+	pool     *sshutil.Pool
+	scope    string
+	hostKeys sshutil.HostKeyStore
+	store    FactsStore
+	logger   Logger
+	events   EventPublisher
+}
+
+func NewFactsManager(cfg FactsConfig, provider DeviceSettingsProvider, pool *sshutil.Pool, scope string, hostKeys sshutil.HostKeyStore, store FactsStore, logger Logger, events EventPublisher) *FactsManager {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 15 * time.Minute
+	}
+	if cfg.MinInterval <= 0 {
+		cfg.MinInterval = time.Minute
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 6 * time.Hour
+	}
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 3
+	}
+	if cfg.CommandTimeout <= 0 {
+		cfg.CommandTimeout = 10 * time.Second
+	}
+	m := &FactsManager{
+		nodes:    make(map[string]model.Node),
+		schedule: make(map[string]*factsSchedule),
+		updateCh: make(chan struct{}, 1),
+		stopCh:   make(chan struct{}),
+		sem:      make(chan struct{}, cfg.Concurrency),
+		cfg:      cfg,
+		provider: provider,
+		pool:     pool,
+		scope:    scope,
+		hostKeys: hostKeys,
+		store:    store,
+		logger:   logger,
+		events:   events,
+	}
+	go m.loop()
+	return m
+}
+
+func (m *FactsManager) UpdateNodes(nodes []model.Node) {
+	m.mu.Lock()
+	m.nodes = make(map[string]model.Node, len(nodes))
+	for _, node := range nodes {
+		if node.Type == "network" || !node.ConnectEnabled {
+			continue
+		}
+		m.nodes[node.ID] = node
+		if _, ok := m.schedule[node.ID]; !ok {
+			m.schedule[node.ID] = &factsSchedule{next: m.firstRun(node)}
+		}
+	}
+	for id, entry := range m.schedule {
+		if _, ok := m.nodes[id]; !ok && !entry.running {
+			delete(m.schedule, id)
+		}
+	}
+	m.mu.Unlock()
+	m.signalUpdate()
+}
+
+func (m *FactsManager) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.stopCh)
+	})
+}
+
+func (m *FactsManager) Refresh(nodeID string) {
+	m.mu.Lock()
+	if entry, ok := m.schedule[nodeID]; ok && !entry.running {
+		entry.failures = 0
+		entry.next = time.Now()
+	}
+	m.mu.Unlock()
+	m.signalUpdate()
+}
+
+func (m *FactsManager) NextRun(nodeID string) (time.Time, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.schedule[nodeID]
+	if !ok {
+		return time.Time{}, false
+	}
+	return entry.next, true
+}
+
+func (m *FactsManager) Collect(ctx context.Context, nodeID string, probes []string) (model.NodeFacts, error) {
+	m.mu.Lock()
+	node, ok := m.nodes[nodeID]
+	m.mu.Unlock()
+	if !ok {
+		node = model.Node{ID: nodeID}
+	}
+	facts, err := m.collect(ctx, node, probes)
+	if len(probes) == 0 && err == nil {
+		m.mu.Lock()
+		if entry, ok := m.schedule[nodeID]; ok && !entry.running {
+			entry.failures = 0
+			entry.next = time.Now().Add(m.intervalFor(node))
+		}
+		m.mu.Unlock()
+	}
+	return facts, err
+}
+
+func (m *FactsManager) signalUpdate() {
+	select {
+	case m.updateCh <- struct{}{}:
+	default:
+	}
+}
+
+func (m *FactsManager) loop() {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stopCh:
+			return
+		case <-ticker.C:
+			m.runDue()
+		case <-m.updateCh:
+			m.runDue()
+		}
+	}
+}
+
+func (m *FactsManager) runDue() {
+	now := time.Now()
+	m.mu.Lock()
+	var due []model.Node
+	for id, entry := range m.schedule {
+		node, ok := m.nodes[id]
+		if !ok || entry.running || now.Before(entry.next) {
+			continue
+		}
+		due = append(due, node)
+	}
+	m.mu.Unlock()
+
+	for _, node := range due {
+		select {
+		case m.sem <- struct{}{}:
+		default:
+			return
+		}
+		m.mu.Lock()
+		entry, ok := m.schedule[node.ID]
+		if !ok || entry.running {
+			m.mu.Unlock()
+			<-m.sem
+			continue
+		}
+		entry.running = true
+		m.mu.Unlock()
+		go func(node model.Node) {
+			defer func() { <-m.sem }()
+			_, err := m.collect(context.Background(), node, nil)
+			m.reschedule(node.ID, err)
+		}(node)
+	}
+}
+
+func (m *FactsManager) reschedule(nodeID string, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.schedule[nodeID]
+	if !ok {
+		return
+	}
+	entry.running = false
+	if _, ok := m.nodes[nodeID]; !ok {
+		delete(m.schedule, nodeID)
+		return
+	}
+	interval := m.intervalFor(m.nodes[nodeID])
+	if err == nil || errors.Is(err, ErrFactsUnavailable) {
+		if entry.failures > 0 && err == nil {
+			m.log("info", fmt.Sprintf("fact collection for %s recovered after %d failures", nodeID, entry.failures))
+		}
+		entry.failures = 0
+		entry.next = time.Now().Add(interval)
+		return
+	}
+	entry.failures++
+	delay := backoff(interval, entry.failures, m.cfg.MaxBackoff)
+	entry.next = time.Now().Add(delay)
+	m.log("warn", fmt.Sprintf("fact collection failed for %s (attempt %d, retry in %s): %v", nodeID, entry.failures, delay, err))
+}
+
+func (m *FactsManager) intervalFor(node model.Node) time.Duration {
+	interval := m.cfg.Interval
+	if node.FactsIntervalSec > 0 {
+		interval = time.Duration(node.FactsIntervalSec) * time.Second
+	}
+	if interval < m.cfg.MinInterval {
+		interval = m.cfg.MinInterval
+	}
+	return interval
+}
+
+func (m *FactsManager) firstRun(node model.Node) time.Time {
+	now := time.Now()
+	if m.store == nil {
+		return now
+	}
+	facts, ok, err := m.store.Get(node.ID)
+	if err != nil || !ok {
+		return now
+	}
+	last, err := time.Parse(time.RFC3339, facts.UpdatedAt)
+	if err != nil {
+		return now
+	}
+	next := last.Add(m.intervalFor(node))
+	if next.Before(now) {
+		return now
+	}
+	return next
+}
+
+func backoff(interval time.Duration, failures int, max time.Duration) time.Duration {
+	delay := interval
+	for i := 1; i < failures && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	if delay < interval {
+		delay = interval
+	}
+	return delay
+}
+
+func (m *FactsManager) collect(ctx context.Context, node model.Node, probes []string) (model.NodeFacts, error) {
+	if m.provider == nil || m.store == nil {
+		return model.NodeFacts{}, ErrFactsUnavailable
+	}
+	settings, ok, err := m.provider.Get(node.ID)
+	if err != nil {
+		return model.NodeFacts{}, err
+	}
+	if !ok || !settings.ConnectEnabled {
+		return model.NodeFacts{}, ErrFactsUnavailable
+	}
+	if settings.Host == "" {
+		settings.Host = pickTarget(node)
+	}
+	resolver := sshutil.Resolver{Scope: m.scope, Settings: m.provider, HostKeys: m.hostKeys}
+	target, err := resolver.Target(node.ID, settings)
+	if err != nil {
+		return model.NodeFacts{}, err
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	results, runErr := m.pool.RunProbes(ctx, target, probes, m.cfg.CommandTimeout)
+	if err := ctx.Err(); err != nil {
+		return model.NodeFacts{}, err
+	}
+	facts := make(map[string]model.Fact)
+	if runErr != nil {
+		for _, name := range probeNames(probes, settings.OS) {
+			probe, _ := sshutil.LookupProbe(name)
+			facts[name] = model.Fact{Field: probe.Field, CheckedAt: now, Error: runErr.Error()}
+		}
+	}
+	for _, result := range results {
+		fact := model.Fact{Field: result.Field, CheckedAt: now}
+		if result.Err == nil {
+			fact.Value, result.Err = json.Marshal(result.Value)
+			fact.CollectedAt = now
+		}
+		if result.Err != nil {
+			fact.Value = nil
+			fact.CollectedAt = ""
+			fact.Error = result.Err.Error()
+		}
+		facts[result.Probe] = fact
+	}
+	stored, err := m.store.Record(node.ID, facts)
+	if err != nil {
+		return model.NodeFacts{}, err
+	}
+	if runErr != nil {
+		return stored, runErr
+	}
+	m.publish("facts", map[string]any{
+		"node":  node.ID,
+		"facts": stored,
+	})
+	return stored, nil
+}
+
+func probeNames(requested []string, osType string) []string {
+	if len(requested) > 0 {
+		return requested
+	}
+	if osType == "" {
+		osType = "linux"
+	}
+	var names []string
+	for _, probe := range sshutil.Probes() {
+		if probe.Supports(osType) {
+			names = append(names, probe.Name)
+		}
+	}
+	return names
+}
+
+func (m *FactsManager) log(level, message string) {
+	if m.logger == nil {
+		return
+	}
+	m.logger.Add(level, "facts", message)
+}
+
+func (m *FactsManager) publish(kind string, payload any) {
+	if m.events == nil {
+		return
+	}
+	m.events.Publish(kind, payload)
+}
@@ -0,0 +1,54 @@
+package monitoring
+
+import (
+	"time"
+
+	"inframap/internal/model"
+)
+
+type statusTracker struct {
+	failures  int
+	successes int
+	changes   []time.Time
+	flapping  bool
+}
+
+func (t *statusTracker) observe(prev model.PingResult, seen bool, res model.PingResult, settings model.MonitoringSettings, now time.Time) (model.PingResult, bool) {
+	if res.Online {
+		t.successes++
+		t.failures = 0
+	} else {
+		t.failures++
+		t.successes = 0
+	}
+	held := false
+	if seen && prev.Online != res.Online {
+		if (res.Online && t.successes < settings.RecoverThreshold) || (!res.Online && t.failures < settings.FailThreshold) {
+			res.Online = prev.Online
+			res.State = prev.State
+			held = true
+		} else {
+			t.changes = append(t.changes, now)
+		}
+	}
+
+	cutoff := now.Add(-time.Duration(settings.FlapWindowSec) * time.Second)
+	kept := t.changes[:0]
+	for _, at := range t.changes {
+		if at.After(cutoff) {
+			kept = append(kept, at)
+		}
+	}
+	t.changes = kept
+	if t.flapping {
+		t.flapping = len(t.changes) > settings.FlapThreshold/2
+	} else {
+		t.flapping = len(t.changes) >= settings.FlapThreshold
+	}
+
+	res.Failures = t.failures
+	res.Successes = t.successes
+	res.StateChanges = len(t.changes)
+	res.Flapping = t.flapping
+	return res, held
+}
@@ -0,0 +1,87 @@
+package monitoring
+
+import (
+	"testing"
+	"time"
+
+	"inframap/internal/model"
+)
+
+func TestStatusTrackerObserve(t *testing.T) {
+	type step struct {
+		at       time.Duration
+		online   bool
+		want     bool
+		held     bool
+		changes  int
+		flapping bool
+	}
+	tests := []struct {
+		name     string
+		settings model.MonitoringSettings
+		steps    []step
+	}{
+		{
+			name:     "fail and recover thresholds",
+			settings: model.MonitoringSettings{FailThreshold: 3, RecoverThreshold: 2, FlapThreshold: 5, FlapWindowSec: 900},
+			steps: []step{
+				{at: 0, online: true, want: true},
+				{at: time.Minute, online: false, want: true, held: true},
+				{at: 2 * time.Minute, online: false, want: true, held: true},
+				{at: 3 * time.Minute, online: false, want: false, changes: 1},
+				{at: 4 * time.Minute, online: true, want: false, held: true, changes: 1},
+				{at: 5 * time.Minute, online: false, want: false, changes: 1},
+				{at: 6 * time.Minute, online: true, want: false, held: true, changes: 1},
+				{at: 7 * time.Minute, online: true, want: true, changes: 2},
+			},
+		},
+		{
+			name:     "first result is not held",
+			settings: model.MonitoringSettings{FailThreshold: 3, RecoverThreshold: 2, FlapThreshold: 5, FlapWindowSec: 900},
+			steps: []step{
+				{at: 0, online: false, want: false},
+				{at: time.Minute, online: true, want: false, held: true},
+			},
+		},
+		{
+			name:     "enter and leave flapping",
+			settings: model.MonitoringSettings{FailThreshold: 1, RecoverThreshold: 1, FlapThreshold: 4, FlapWindowSec: 600},
+			steps: []step{
+				{at: 0, online: true, want: true},
+				{at: time.Minute, online: false, want: false, changes: 1},
+				{at: 2 * time.Minute, online: true, want: true, changes: 2},
+				{at: 3 * time.Minute, online: false, want: false, changes: 3},
+				{at: 4 * time.Minute, online: true, want: true, changes: 4, flapping: true},
+				{at: 10*time.Minute + 30*time.Second, online: true, want: true, changes: 4, flapping: true},
+				{at: 11*time.Minute + 30*time.Second, online: true, want: true, changes: 3, flapping: true},
+				{at: 12*time.Minute + 30*time.Second, online: true, want: true, changes: 2},
+				{at: 13 * time.Minute, online: false, want: false, changes: 2},
+				{at: 20 * time.Minute, online: false, want: false, changes: 1},
+			},
+		},
+	}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		tracker := &statusTracker{}
+		var prev model.PingResult
+		for i, s := range tt.steps {
+			res := model.PingResult{State: model.PingDown}
+			res.Online = s.online
+			if s.online {
+				res.State = model.PingUp
+			}
+			got, held := tracker.observe(prev, i > 0, res, tt.settings, start.Add(s.at))
+			if got.Online != s.want || held != s.held || got.StateChanges != s.changes || got.Flapping != s.flapping {
+				t.Fatalf("%s step %d: online %t held %t changes %d flapping %t, want %t %t %d %t",
+					tt.name, i, got.Online, held, got.StateChanges, got.Flapping, s.want, s.held, s.changes, s.flapping)
+			}
+			if held && got.State != prev.State {
+				t.Fatalf("%s step %d: held state %q, want %q", tt.name, i, got.State, prev.State)
+			}
+			if s.online && (got.Successes == 0 || got.Failures != 0) || !s.online && (got.Failures == 0 || got.Successes != 0) {
+				t.Fatalf("%s step %d: failures %d successes %d", tt.name, i, got.Failures, got.Successes)
+			}
+			prev = got
+		}
+	}
+}
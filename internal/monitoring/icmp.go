@@ -0,0 +1,338 @@
+package monitoring
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	icmpv4EchoRequest = 8
+	icmpv4EchoReply   = 0
+	icmpv6EchoRequest = 128
+	icmpv6EchoReply   = 129
+
+	icmpPayloadSize = 32
+)
+
+type PingOptions struct {
+	Count    int
+	Interval time.Duration
+	Timeout  time.Duration
+}
+
+type PingStats struct {
+	Addr     net.IP
+	Sent     int
+	Received int
+	RTTs     []time.Duration
+	Min      time.Duration
+	Avg      time.Duration
+	Max      time.Duration
+	Jitter   time.Duration
+}
+
+func (s PingStats) LossPct() float64 {
+	if s.Sent == 0 {
+		return 100
+	}
+	return float64(s.Sent-s.Received) * 100 / float64(s.Sent)
+}
+
+type icmpReply struct {
+	from net.IP
+	at   time.Time
+}
+
+type icmpConn struct {
+	conn     net.PacketConn
+	datagram bool
+	v6       bool
+}
+
+type pendingProbe struct {
+	addr  net.IP
+	reply chan icmpReply
+}
+
+type Pinger struct {
+	mu      sync.Mutex
+	id      uint16
+	seq     uint16
+	token   [8]byte
+	conns   map[bool]*icmpConn
+	pending map[uint16]*pendingProbe
+}
+
+func NewPinger() *Pinger {
+	p := &Pinger{
+		conns:   make(map[bool]*icmpConn),
+		pending: make(map[uint16]*pendingProbe),
+	}
+	var seed [2]byte
+	_, _ = rand.Read(seed[:])
+	_, _ = rand.Read(p.token[:])
+	p.id = binary.BigEndian.Uint16(seed[:])
+	return p
+}
+
+func (p *Pinger) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for v6, conn := range p.conns {
+		conn.conn.Close()
+		delete(p.conns, v6)
+	}
+}
+
+func (p *Pinger) Ping(ctx context.Context, addr net.IP, opts PingOptions) (PingStats, error) {
+	if opts.Count <= 0 {
+		opts.Count = 1
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = 200 * time.Millisecond
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = time.Second
+	}
+	v6 := addr.To4() == nil
+	if !v6 {
+		addr = addr.To4()
+	}
+	conn, err := p.conn(v6)
+	if err != nil {
+		return PingStats{Addr: addr}, err
+	}
+
+	type sent struct {
+		probe *pendingProbe
+		seq   uint16
+		at    time.Time
+	}
+	probes := make([]sent, 0, opts.Count)
+	defer func() {
+		p.mu.Lock()
+		for _, s := range probes {
+			if p.pending[s.seq] == s.probe {
+				delete(p.pending, s.seq)
+			}
+		}
+		p.mu.Unlock()
+	}()
+
+	stats := PingStats{Addr: addr}
+	for i := 0; i < opts.Count; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return stats, ctx.Err()
+			case <-time.After(opts.Interval):
+			}
+		}
+		probe := &pendingProbe{addr: addr, reply: make(chan icmpReply, 1)}
+		seq := p.register(probe)
+		at := time.Now()
+		if err := p.send(conn, addr, seq); err != nil {
+			p.mu.Lock()
+			delete(p.pending, seq)
+			p.mu.Unlock()
+			if stats.Sent == 0 {
+				return stats, err
+			}
+			break
+		}
+		stats.Sent++
+		probes = append(probes, sent{probe: probe, seq: seq, at: at})
+	}
+
+	for _, s := range probes {
+		wait := time.Until(s.at.Add(opts.Timeout))
+		if wait < 0 {
+			wait = 0
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case reply := <-s.probe.reply:
+			stats.RTTs = append(stats.RTTs, reply.at.Sub(s.at))
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			stats.finish()
+			return stats, ctx.Err()
+		}
+		timer.Stop()
+	}
+	stats.finish()
+	return stats, nil
+}
+
+func (s *PingStats) finish() {
+	s.Received = len(s.RTTs)
+	if s.Received == 0 {
+		return
+	}
+	var total, deviation time.Duration
+	s.Min = time.Duration(math.MaxInt64)
+	for i, rtt := range s.RTTs {
+		total += rtt
+		if rtt < s.Min {
+			s.Min = rtt
+		}
+		if rtt > s.Max {
+			s.Max = rtt
+		}
+		if i > 0 {
+			diff := rtt - s.RTTs[i-1]
+			if diff < 0 {
+				diff = -diff
+			}
+			deviation += diff
+		}
+	}
+	s.Avg = total / time.Duration(s.Received)
+	if s.Received > 1 {
+		s.Jitter = deviation / time.Duration(s.Received-1)
+	}
+}
+
+func (p *Pinger) register(probe *pendingProbe) uint16 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for {
+		p.seq++
+		if _, busy := p.pending[p.seq]; !busy {
+			p.pending[p.seq] = probe
+			return p.seq
+		}
+	}
+}
+
+func (p *Pinger) conn(v6 bool) (*icmpConn, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if conn, ok := p.conns[v6]; ok {
+		return conn, nil
+	}
+	conn, err := listenDatagram(v6)
+	datagram := err == nil
+	if err != nil {
+		network := "ip4:icmp"
+		if v6 {
+			network = "ip6:ipv6-icmp"
+		}
+		var rawErr error
+		conn, rawErr = net.ListenPacket(network, "")
+		if rawErr != nil {
+			return nil, fmt.Errorf("icmp unavailable: datagram socket: %v; raw socket: %v", err, rawErr)
+		}
+	}
+	c := &icmpConn{conn: conn, datagram: datagram, v6: v6}
+	p.conns[v6] = c
+	go p.read(c)
+	return c, nil
+}
+
+func (p *Pinger) send(c *icmpConn, addr net.IP, seq uint16) error {
+	packet := make([]byte, 8+icmpPayloadSize)
+	packet[0] = icmpv4EchoRequest
+	if c.v6 {
+		packet[0] = icmpv6EchoRequest
+	}
+	binary.BigEndian.PutUint16(packet[4:], p.id)
+	binary.BigEndian.PutUint16(packet[6:], seq)
+	copy(packet[8:], p.token[:])
+	if !c.v6 {
+		binary.BigEndian.PutUint16(packet[2:], icmpChecksum(packet))
+	}
+	var dst net.Addr = &net.IPAddr{IP: addr}
+	if c.datagram {
+		dst = &net.UDPAddr{IP: addr}
+	}
+	_, err := c.conn.WriteTo(packet, dst)
+	return err
+}
+
+func (p *Pinger) read(c *icmpConn) {
+	buf := make([]byte, 1500)
+	for {
+		n, from, err := c.conn.ReadFrom(buf)
+		if err != nil {
+			p.mu.Lock()
+			if p.conns[c.v6] == c {
+				delete(p.conns, c.v6)
+			}
+			p.mu.Unlock()
+			c.conn.Close()
+			return
+		}
+		at := time.Now()
+		seq, ok := p.parseReply(c, buf[:n])
+		if !ok {
+			continue
+		}
+		var fromIP net.IP
+		switch addr := from.(type) {
+		case *net.IPAddr:
+			fromIP = addr.IP
+		case *net.UDPAddr:
+			fromIP = addr.IP
+		}
+		p.mu.Lock()
+		probe, found := p.pending[seq]
+		if found && (fromIP == nil || probe.addr.Equal(fromIP)) {
+			delete(p.pending, seq)
+		} else {
+			found = false
+		}
+		p.mu.Unlock()
+		if found {
+			probe.reply <- icmpReply{from: fromIP, at: at}
+		}
+	}
+}
+
+func (p *Pinger) parseReply(c *icmpConn, packet []byte) (uint16, bool) {
+	if !c.v6 && len(packet) >= 20 && packet[0]>>4 == 4 {
+		headerLen := int(packet[0]&0x0f) * 4
+		if headerLen < 20 || len(packet) < headerLen {
+			return 0, false
+		}
+		packet = packet[headerLen:]
+	}
+	if len(packet) < 8+len(p.token) {
+		return 0, false
+	}
+	want := byte(icmpv4EchoReply)
+	if c.v6 {
+		want = icmpv6EchoReply
+	}
+	if packet[0] != want || packet[1] != 0 {
+		return 0, false
+	}
+	if !c.datagram && binary.BigEndian.Uint16(packet[4:]) != p.id {
+		return 0, false
+	}
+	if string(packet[8:8+len(p.token)]) != string(p.token[:]) {
+		return 0, false
+	}
+	return binary.BigEndian.Uint16(packet[6:]), true
+}
+
+func icmpChecksum(packet []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(packet); i += 2 {
+		sum += uint32(packet[i])<<8 | uint32(packet[i+1])
+	}
+	if len(packet)%2 == 1 {
+		sum += uint32(packet[len(packet)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
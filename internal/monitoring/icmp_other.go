@@ -0,0 +1,12 @@
+//go:build !linux && !darwin
+
+package monitoring
+
+import (
+	"errors"
+	"net"
+)
+
+func listenDatagram(v6 bool) (net.PacketConn, error) {
+	return nil, errors.New("not supported on this platform")
+}
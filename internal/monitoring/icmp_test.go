@@ -0,0 +1,100 @@
+package monitoring
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+func TestICMPChecksum(t *testing.T) {
+	// RFC 1071 section 3 example.
+	if got := icmpChecksum([]byte{0x00, 0x01, 0xf2, 0x03, 0xf4, 0xf5, 0xf6, 0xf7}); got != 0x220d {
+		t.Fatalf("checksum = %#04x, want 0x220d", got)
+	}
+	if got := icmpChecksum([]byte{0x00, 0x01, 0xf2}); got != ^uint16(0x0001+0xf200) {
+		t.Fatalf("odd length checksum = %#04x", got)
+	}
+	p := NewPinger()
+	packet := echoPacket(icmpv4EchoRequest, p.id, 7, p.token[:])
+	binary.BigEndian.PutUint16(packet[2:], icmpChecksum(packet))
+	if got := icmpChecksum(packet); got != 0 {
+		t.Fatalf("checksum over a checksummed packet = %#04x, want 0", got)
+	}
+}
+
+func echoPacket(kind byte, id, seq uint16, payload []byte) []byte {
+	packet := make([]byte, 8+icmpPayloadSize)
+	packet[0] = kind
+	binary.BigEndian.PutUint16(packet[4:], id)
+	binary.BigEndian.PutUint16(packet[6:], seq)
+	copy(packet[8:], payload)
+	return packet
+}
+
+func withIPv4Header(packet []byte) []byte {
+	header := make([]byte, 20)
+	header[0] = 0x45
+	header[9] = 1
+	return append(header, packet...)
+}
+
+func TestParseReply(t *testing.T) {
+	p := NewPinger()
+	raw4 := &icmpConn{}
+	dgram4 := &icmpConn{datagram: true}
+	raw6 := &icmpConn{v6: true}
+	otherToken := make([]byte, len(p.token))
+	tests := []struct {
+		name   string
+		conn   *icmpConn
+		packet []byte
+		seq    uint16
+		ok     bool
+	}{
+		{"raw v4 with ip header", raw4, withIPv4Header(echoPacket(icmpv4EchoReply, p.id, 42, p.token[:])), 42, true},
+		{"datagram v4 ignores rewritten id", dgram4, echoPacket(icmpv4EchoReply, p.id+1, 9, p.token[:]), 9, true},
+		{"raw v6", raw6, echoPacket(icmpv6EchoReply, p.id, 300, p.token[:]), 300, true},
+		{"raw v4 other pinger id", raw4, withIPv4Header(echoPacket(icmpv4EchoReply, p.id+1, 42, p.token[:])), 0, false},
+		{"echo request", raw4, withIPv4Header(echoPacket(icmpv4EchoRequest, p.id, 42, p.token[:])), 0, false},
+		{"v4 reply on v6 socket", raw6, echoPacket(icmpv4EchoReply, p.id, 1, p.token[:]), 0, false},
+		{"foreign token", dgram4, echoPacket(icmpv4EchoReply, p.id, 5, otherToken), 0, false},
+		{"truncated", dgram4, echoPacket(icmpv4EchoReply, p.id, 5, p.token[:])[:12], 0, false},
+		{"bad ip header length", raw4, append([]byte{0x4f}, make([]byte, 30)...), 0, false},
+	}
+	for _, tt := range tests {
+		seq, ok := p.parseReply(tt.conn, tt.packet)
+		if ok != tt.ok || seq != tt.seq {
+			t.Errorf("%s: parseReply = %d, %t, want %d, %t", tt.name, seq, ok, tt.seq, tt.ok)
+		}
+	}
+}
+
+func TestPingStatsFinish(t *testing.T) {
+	ms := time.Millisecond
+	stats := PingStats{Sent: 4, RTTs: []time.Duration{10 * ms, 30 * ms, 20 * ms}}
+	stats.finish()
+	if stats.Received != 3 || stats.Min != 10*ms || stats.Max != 30*ms || stats.Avg != 20*ms {
+		t.Fatalf("stats = %+v", stats)
+	}
+	if stats.Jitter != 15*ms {
+		t.Fatalf("jitter = %s, want 15ms", stats.Jitter)
+	}
+	if loss := stats.LossPct(); loss != 25 {
+		t.Fatalf("loss = %v, want 25", loss)
+	}
+
+	single := PingStats{Sent: 1, RTTs: []time.Duration{5 * ms}}
+	single.finish()
+	if single.Jitter != 0 || single.Avg != 5*ms || single.LossPct() != 0 {
+		t.Fatalf("single = %+v", single)
+	}
+
+	lost := PingStats{Sent: 3}
+	lost.finish()
+	if lost.Received != 0 || lost.Min != 0 || lost.LossPct() != 100 {
+		t.Fatalf("lost = %+v", lost)
+	}
+	if (PingStats{}).LossPct() != 100 {
+		t.Fatal("nothing sent should count as full loss")
+	}
+}
@@ -1,72 +1,204 @@
 package monitoring
 
 import (
+	"bytes"
 	"context"
-	"os/exec"
-	"regexp"
-	"runtime"
-	"strconv"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
 	"strings"
 	"time"
 
 	"inframap/internal/model"
 )
 
-var pingRTTRegex = regexp.MustCompile(`time[=<]([0-9.]+)\s*ms`)
-
-func pingTarget(target string) model.PingResult {
-	start := time.Now().UTC()
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+func pingTarget(pinger *Pinger, target string, count int) model.PingProbe {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
-
-	cmd := buildPingCommand(ctx, target)
-	output, err := cmd.CombinedOutput()
-	result := model.PingResult{
-		Online:      false,
-		LastChecked: start,
-		RTTMs:       0,
-		Target:      target,
+	addr, err := resolveTarget(ctx, target)
+	if err != nil {
+		return model.PingProbe{Target: target, LossPct: 100, Error: "resolve failed: " + err.Error()}
 	}
+	return pingIP(pinger, addr, count)
+}
 
-	if ctx.Err() == context.DeadlineExceeded {
-		result.Error = "timeout"
-		return result
+func pingIP(pinger *Pinger, addr net.IP, count int) model.PingProbe {
+	result := model.PingProbe{
+		Target:  addr.String(),
+		LossPct: 100,
 	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(count)*time.Second+2*time.Second)
+	defer cancel()
 
-	if err == nil {
+	stats, err := pinger.Ping(ctx, addr, PingOptions{Count: count})
+	result.Sent = stats.Sent
+	result.Received = stats.Received
+	result.LossPct = stats.LossPct()
+	if stats.Received > 0 {
 		result.Online = true
-	} else {
+		result.RTTMs = int(stats.Avg.Round(time.Millisecond) / time.Millisecond)
+		result.RTTMinMs = durationMs(stats.Min)
+		result.RTTAvgMs = durationMs(stats.Avg)
+		result.RTTMaxMs = durationMs(stats.Max)
+		result.JitterMs = durationMs(stats.Jitter)
+		return result
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		result.Error = "timeout"
+	case err != nil:
+		result.Error = err.Error()
+	default:
 		result.Error = "unreachable"
 	}
+	return result
+}
 
-	rtt := parseRTT(string(output))
-	if rtt > 0 {
-		result.RTTMs = rtt
+func resolveHostname(name string, previous *model.DNSResolution) (*model.DNSResolution, net.IP) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resolution := &model.DNSResolution{Name: name}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, name)
+	if err == nil && len(addrs) == 0 {
+		err = errors.New("no addresses")
+	}
+	if err != nil {
+		resolution.Error = err.Error()
+		if previous != nil && previous.Name == name {
+			resolution.Addresses = previous.Addresses
+		}
+		return resolution, nil
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i].IP.To16(), addrs[j].IP.To16()) < 0
+	})
+	var target net.IP
+	for _, addr := range addrs {
+		resolution.Addresses = append(resolution.Addresses, addr.IP.String())
+		if target == nil && addr.IP.To4() != nil {
+			target = addr.IP
+		}
+	}
+	if target == nil {
+		target = addrs[0].IP
+	}
+	return resolution, target
+}
+
+func resolutionChanged(previous, current *model.DNSResolution) bool {
+	if previous == nil || current == nil || previous.Name != current.Name || current.Error != "" {
+		return false
+	}
+	if len(previous.Addresses) == 0 {
+		return false
+	}
+	return strings.Join(previous.Addresses, ",") != strings.Join(current.Addresses, ",")
+}
+
+type pingAddress struct {
+	kind string
+	host string
+}
+
+func pingAddresses(node model.Node) []pingAddress {
+	var addrs []pingAddress
+	for _, addr := range []pingAddress{
+		{kind: model.AddressHostname, host: node.Hostname},
+		{kind: model.AddressPublic, host: node.IPPublic},
+		{kind: model.AddressPrivate, host: node.IPPrivate},
+		{kind: model.AddressTailscale, host: node.IPTailscale},
+	} {
+		if addr.host = strings.TrimSpace(addr.host); addr.host != "" {
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
+}
+
+func summarizePing(node model.Node, probes map[string]model.PingProbe, dns *model.DNSResolution, checkedAt time.Time) model.PingResult {
+	result := model.PingResult{
+		LastChecked: checkedAt.UTC(),
+		State:       model.PingDown,
+		Addresses:   probes,
+		DNS:         dns,
+	}
+	addrs := pingAddresses(node)
+	up := 0
+	for _, addr := range addrs {
+		probe, ok := probes[addr.kind]
+		if !ok {
+			continue
+		}
+		if probe.Online {
+			up++
+		}
+		if result.Target == "" || (probe.Online && !result.Online) {
+			result.PingProbe = probe
+		}
+	}
+	if len(probes) == 0 && dns != nil && dns.Error != "" {
+		result.Target = dns.Name
+		result.LossPct = 100
+		result.Error = "resolve failed: " + dns.Error
+	}
+	switch {
+	case up == 0:
+		result.State = model.PingDown
+	case up == len(addrs):
+		result.State = model.PingUp
+	default:
+		result.State = model.PingDegraded
 	}
 	return result
 }
 
-func parseRTT(output string) int {
-	match := pingRTTRegex.FindStringSubmatch(output)
-	if len(match) < 2 {
-		return 0
+func resolveTarget(ctx context.Context, target string) (net.IP, error) {
+	if ip := net.ParseIP(target); ip != nil {
+		return ip, nil
 	}
-	value, err := strconv.ParseFloat(strings.TrimSpace(match[1]), 64)
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, target)
 	if err != nil {
-		return 0
+		return nil, err
 	}
-	return int(value + 0.5)
+	for _, addr := range addrs {
+		if addr.IP.To4() != nil {
+			return addr.IP, nil
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, errors.New("no addresses")
+	}
+	return addrs[0].IP, nil
 }
 
-func buildPingCommand(ctx context.Context, target string) *exec.Cmd {
-	switch runtime.GOOS {
-	case "windows":
-		return exec.CommandContext(ctx, "ping", "-n", "1", "-w", "1000", target)
-	case "darwin":
-		return exec.CommandContext(ctx, "ping", "-c", "1", "-W", "1000", target)
-	case "freebsd", "openbsd", "netbsd":
-		return exec.CommandContext(ctx, "ping", "-c", "1", "-W", "1000", target)
-	default:
-		return exec.CommandContext(ctx, "ping", "-c", "1", "-W", "1", target)
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+func describeProbes(node model.Node, probes map[string]model.PingProbe, dns *model.DNSResolution) string {
+	var b strings.Builder
+	for _, addr := range pingAddresses(node) {
+		if addr.kind == model.AddressHostname && dns != nil && dns.Error != "" {
+			fmt.Fprintf(&b, "; hostname %s dns error=%s", dns.Name, dns.Error)
+			continue
+		}
+		probe, ok := probes[addr.kind]
+		if !ok {
+			continue
+		}
+		if addr.kind == model.AddressHostname {
+			fmt.Fprintf(&b, "; hostname %s (%s)", addr.host, probe.Target)
+		} else {
+			fmt.Fprintf(&b, "; %s %s", addr.kind, probe.Target)
+		}
+		if probe.Online {
+			fmt.Fprintf(&b, " rtt=%dms", probe.RTTMs)
+		}
+		fmt.Fprintf(&b, " loss=%.0f%%", probe.LossPct)
+		if probe.Error != "" {
+			b.WriteString(" error=" + probe.Error)
+		}
 	}
+	return b.String()
 }
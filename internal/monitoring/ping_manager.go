@@ -1,32 +1,86 @@
 package monitoring
 
 import (
-	"strconv"
+	"fmt"
+	"strings"
 	"sync"
 	"time"
 
 	"inframap/internal/model"
 )
 
+const pingSnapshotInterval = time.Minute
+
 type Logger interface {
 	Add(level, source, message string)
 }
 
+type EventPublisher interface {
+	Publish(kind string, payload any)
+}
+
+type ResultRecorder interface {
+	RecordPing(results map[string]model.PingResult)
+	RecordSSH(results map[string]model.SSHStatus)
+}
+
+type Recorders []ResultRecorder
+
+func (r Recorders) RecordPing(results map[string]model.PingResult) {
+	for _, recorder := range r {
+		recorder.RecordPing(results)
+	}
+}
+
+func (r Recorders) RecordSSH(results map[string]model.SSHStatus) {
+	for _, recorder := range r {
+		recorder.RecordSSH(results)
+	}
+}
+
+type CycleStats struct {
+	Runs          int64
+	LastDuration  time.Duration
+	TotalDuration time.Duration
+	LastRun       time.Time
+}
+
+func (c *CycleStats) observe(start time.Time) {
+	duration := time.Since(start)
+	c.Runs++
+	c.LastDuration = duration
+	c.TotalDuration += duration
+	c.LastRun = start
+}
+
 type PingManager struct {
 	mu       sync.RWMutex
 	settings model.MonitoringSettings
 	nodes    []model.Node
 	status   map[string]model.PingResult
+	trackers map[string]*statusTracker
+	stats    CycleStats
+	lastFull time.Time
 	updateCh chan struct{}
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	pinger   *Pinger
 	logger   Logger
+	events   EventPublisher
+	recorder ResultRecorder
 }
 
-func NewPingManager(logger Logger) *PingManager {
+func NewPingManager(pinger *Pinger, logger Logger, events EventPublisher, recorder ResultRecorder) *PingManager {
 	manager := &PingManager{
 		settings: defaultMonitoringSettings(),
+		pinger:   pinger,
 		status:   make(map[string]model.PingResult),
+		trackers: make(map[string]*statusTracker),
 		updateCh: make(chan struct{}, 1),
+		stopCh:   make(chan struct{}),
 		logger:   logger,
+		events:   events,
+		recorder: recorder,
 	}
 	go manager.loop()
 	return manager
@@ -34,9 +88,14 @@ func NewPingManager(logger Logger) *PingManager {
 
 func defaultMonitoringSettings() model.MonitoringSettings {
 	return model.MonitoringSettings{
-		Enabled:     false,
-		IntervalSec: 30,
-		ShowStatus:  false,
+		Enabled:          false,
+		IntervalSec:      30,
+		ShowStatus:       false,
+		PingCount:        3,
+		FailThreshold:    1,
+		RecoverThreshold: 1,
+		FlapThreshold:    5,
+		FlapWindowSec:    900,
 	}
 }
 
@@ -50,6 +109,21 @@ func sanitizeMonitoringSettings(settings model.MonitoringSettings) model.Monitor
 	if settings.IntervalSec < 5 || settings.IntervalSec > 3600 {
 		settings.IntervalSec = defaultMonitoringSettings().IntervalSec
 	}
+	if settings.PingCount < 1 || settings.PingCount > 10 {
+		settings.PingCount = defaultMonitoringSettings().PingCount
+	}
+	if settings.FailThreshold < 1 || settings.FailThreshold > 10 {
+		settings.FailThreshold = defaultMonitoringSettings().FailThreshold
+	}
+	if settings.RecoverThreshold < 1 || settings.RecoverThreshold > 10 {
+		settings.RecoverThreshold = defaultMonitoringSettings().RecoverThreshold
+	}
+	if settings.FlapThreshold < 2 || settings.FlapThreshold > 100 {
+		settings.FlapThreshold = defaultMonitoringSettings().FlapThreshold
+	}
+	if settings.FlapWindowSec < 60 || settings.FlapWindowSec > 86400 {
+		settings.FlapWindowSec = defaultMonitoringSettings().FlapWindowSec
+	}
 	return settings
 }
 
@@ -96,6 +170,18 @@ func (m *PingManager) GetStatus() map[string]model.PingResult {
 	return copyMap
 }
 
+func (m *PingManager) Stats() CycleStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.stats
+}
+
+func (m *PingManager) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.stopCh)
+	})
+}
+
 func (m *PingManager) signalUpdate() {
 	select {
 	case m.updateCh <- struct{}{}:
@@ -118,6 +204,8 @@ func (m *PingManager) loop() {
 				currentInterval = 0
 			}
 			select {
+			case <-m.stopCh:
+				return
 			case <-m.updateCh:
 				continue
 			case <-time.After(1 * time.Second):
@@ -137,6 +225,9 @@ func (m *PingManager) loop() {
 		}
 
 		select {
+		case <-m.stopCh:
+			ticker.Stop()
+			return
 		case <-ticker.C:
 			m.runPingCycle()
 		case <-m.updateCh:
@@ -155,10 +246,13 @@ func (m *PingManager) isEnabled() bool {
 }
 
 func (m *PingManager) runPingCycle() {
+	start := time.Now()
 	nodes := m.getNodesSnapshot()
 	statusSnapshot := m.getStatusSnapshot()
 	settings := m.GetSettings()
 	results := make(map[string]model.PingResult, len(nodes))
+	pinged := make(map[string]map[string]model.PingProbe, len(nodes))
+	resolved := make(map[string]*model.DNSResolution)
 	var wg sync.WaitGroup
 	var resultsMu sync.Mutex
 	sem := make(chan struct{}, 8)
@@ -170,44 +264,77 @@ func (m *PingManager) runPingCycle() {
 		interval := intervalForNode(node, settings.IntervalSec)
 		if interval > 0 {
 			if last, ok := statusSnapshot[node.ID]; ok {
-				if time.Since(last.LastChecked) < interval {
+				if time.Since(last.LastChecked)+time.Second < interval {
 					continue
 				}
 			}
 		}
-		target := pickTarget(node)
-		if target == "" {
-			resultsMu.Lock()
+		addrs := pingAddresses(node)
+		if len(addrs) == 0 {
 			results[node.ID] = model.PingResult{
-				Online:      false,
-				LastChecked: time.Now().UTC(),
-				Target:      "",
-				Error:       "no ip",
+				PingProbe:   model.PingProbe{Error: "no ip"},
+				LastChecked: start.UTC(),
+				State:       model.PingDown,
 			}
-			resultsMu.Unlock()
 			m.log("warn", "ping", "ping skipped for "+node.ID+": no ip")
 			continue
 		}
 
-		wg.Add(1)
-		go func(nodeID, target string) {
-			defer wg.Done()
-			sem <- struct{}{}
-			result := pingTarget(target)
-			<-sem
-			resultsMu.Lock()
-			results[nodeID] = result
-			resultsMu.Unlock()
-			level := "info"
-			if !result.Online {
-				level = "warn"
-			}
-			msg := "ping " + nodeID + " -> online=" + strconv.FormatBool(result.Online) + " rtt=" + strconv.Itoa(result.RTTMs) + "ms error=" + result.Error
-			m.log(level, "ping", msg)
-		}(node.ID, target)
+		probes := make(map[string]model.PingProbe, len(addrs))
+		pinged[node.ID] = probes
+		for _, addr := range addrs {
+			wg.Add(1)
+			go func(nodeID string, probes map[string]model.PingProbe, addr pingAddress) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				if addr.kind != model.AddressHostname {
+					probe := pingTarget(m.pinger, addr.host, settings.PingCount)
+					resultsMu.Lock()
+					probes[addr.kind] = probe
+					resultsMu.Unlock()
+					return
+				}
+				dns, ip := resolveHostname(addr.host, statusSnapshot[nodeID].DNS)
+				resultsMu.Lock()
+				resolved[nodeID] = dns
+				resultsMu.Unlock()
+				if ip == nil {
+					return
+				}
+				probe := pingIP(m.pinger, ip, settings.PingCount)
+				resultsMu.Lock()
+				probes[addr.kind] = probe
+				resultsMu.Unlock()
+			}(node.ID, probes, addr)
+		}
 	}
 
 	wg.Wait()
+	for _, node := range nodes {
+		raw, ok := results[node.ID]
+		probes, wasPinged := pinged[node.ID]
+		dns := resolved[node.ID]
+		if wasPinged {
+			raw = summarizePing(node, probes, dns, start)
+		} else if !ok {
+			continue
+		}
+		tracker, ok := m.trackers[node.ID]
+		if !ok {
+			tracker = &statusTracker{}
+			m.trackers[node.ID] = tracker
+		}
+		wasFlapping := tracker.flapping
+		prev, seen := statusSnapshot[node.ID]
+		result, held := tracker.observe(prev, seen, raw, settings, start)
+		results[node.ID] = result
+		if wasPinged {
+			m.logResult(node, result, held, wasFlapping, probes, dns, settings)
+			m.logResolution(node.ID, prev.DNS, dns)
+		}
+	}
+
 	m.mu.Lock()
 	if m.status == nil {
 		m.status = make(map[string]model.PingResult)
@@ -216,15 +343,104 @@ func (m *PingManager) runPingCycle() {
 	for _, node := range nodes {
 		nodeIDs[node.ID] = struct{}{}
 	}
+	changed := make(map[string]model.PingResult)
 	for id, res := range results {
+		prev, ok := m.status[id]
+		if !ok || pingChanged(prev, res) {
+			changed[id] = res
+		}
 		m.status[id] = res
 	}
+	full := time.Since(m.lastFull) >= pingSnapshotInterval
+	if full {
+		m.lastFull = start
+		for id, res := range m.status {
+			if _, ok := nodeIDs[id]; ok {
+				changed[id] = res
+			}
+		}
+	}
+	removed := []string{}
+	for id := range m.trackers {
+		if _, ok := nodeIDs[id]; !ok {
+			delete(m.trackers, id)
+		}
+	}
 	for id := range m.status {
 		if _, ok := nodeIDs[id]; !ok {
 			delete(m.status, id)
+			removed = append(removed, id)
 		}
 	}
+	m.stats.observe(start)
 	m.mu.Unlock()
+	if m.recorder != nil && len(results) > 0 {
+		m.recorder.RecordPing(results)
+	}
+	if len(changed) > 0 || len(removed) > 0 {
+		m.publish("ping", map[string]any{
+			"results": changed,
+			"removed": removed,
+			"full":    full,
+		})
+	}
+}
+
+func pingChanged(prev, res model.PingResult) bool {
+	if prev.Online != res.Online || prev.State != res.State || prev.Error != res.Error || prev.Flapping != res.Flapping || prev.Target != res.Target {
+		return true
+	}
+	if len(prev.Addresses) != len(res.Addresses) {
+		return true
+	}
+	for kind, probe := range res.Addresses {
+		old, ok := prev.Addresses[kind]
+		if !ok || old.Online != probe.Online || old.Target != probe.Target || old.Error != probe.Error {
+			return true
+		}
+	}
+	if (prev.DNS == nil) != (res.DNS == nil) {
+		return true
+	}
+	return prev.DNS != nil && (prev.DNS.Error != res.DNS.Error || strings.Join(prev.DNS.Addresses, ",") != strings.Join(res.DNS.Addresses, ","))
+}
+
+func (m *PingManager) logResult(node model.Node, result model.PingResult, held, wasFlapping bool, probes map[string]model.PingProbe, dns *model.DNSResolution, settings model.MonitoringSettings) {
+	switch {
+	case result.Flapping && !wasFlapping:
+		m.log("warn", "ping", fmt.Sprintf("ping %s is flapping: %d state changes in %s, suppressing status logs", node.ID, result.StateChanges, time.Duration(settings.FlapWindowSec)*time.Second))
+		return
+	case result.Flapping:
+		return
+	case wasFlapping:
+		m.log("info", "ping", fmt.Sprintf("ping %s stopped flapping, now %s", node.ID, result.State))
+	}
+	level := "info"
+	if result.State != model.PingUp || held || (dns != nil && dns.Error != "") {
+		level = "warn"
+	}
+	message := fmt.Sprintf("ping %s -> %s%s", node.ID, result.State, describeProbes(node, probes, dns))
+	if held && result.Online {
+		message += fmt.Sprintf(" (failed %d/%d before down)", result.Failures, settings.FailThreshold)
+	} else if held {
+		message += fmt.Sprintf(" (answered %d/%d before up)", result.Successes, settings.RecoverThreshold)
+	}
+	m.log(level, "ping", message)
+}
+
+func (m *PingManager) logResolution(nodeID string, previous, current *model.DNSResolution) {
+	if current == nil {
+		return
+	}
+	switch {
+	case current.Error != "" && (previous == nil || previous.Error == "" || previous.Name != current.Name):
+		m.log("warn", "dns", fmt.Sprintf("dns resolution of %s for %s failed: %s", current.Name, nodeID, current.Error))
+	case current.Error == "" && previous != nil && previous.Error != "" && previous.Name == current.Name:
+		m.log("info", "dns", fmt.Sprintf("dns resolution of %s for %s recovered: %s", current.Name, nodeID, strings.Join(current.Addresses, ", ")))
+	}
+	if resolutionChanged(previous, current) {
+		m.log("info", "dns", fmt.Sprintf("dns %s for %s changed: %s -> %s", current.Name, nodeID, strings.Join(previous.Addresses, ", "), strings.Join(current.Addresses, ", ")))
+	}
 }
 
 func (m *PingManager) getNodesSnapshot() []model.Node {
@@ -276,6 +492,9 @@ func isPingEnabled(node model.Node) bool {
 }
 
 func pickTarget(node model.Node) string {
+	if host := strings.TrimSpace(node.Hostname); host != "" {
+		return host
+	}
 	if node.IPPublic != "" {
 		return node.IPPublic
 	}
@@ -288,6 +507,13 @@ func pickTarget(node model.Node) string {
 	return ""
 }
 
+func (m *PingManager) publish(kind string, payload any) {
+	if m.events == nil {
+		return
+	}
+	m.events.Publish(kind, payload)
+}
+
 func (m *PingManager) log(level, source, message string) {
 	if m.logger == nil {
 		return
@@ -0,0 +1,55 @@
+package monitoring
+
+import (
+	"testing"
+
+	"inframap/internal/model"
+)
+
+func TestPingChanged(t *testing.T) {
+	base := func() model.PingResult {
+		return model.PingResult{
+			PingProbe: model.PingProbe{Online: true, Target: "10.0.0.1", RTTMs: 1},
+			State:     model.PingDegraded,
+			Addresses: map[string]model.PingProbe{
+				model.AddressPrivate:   {Online: true, Target: "10.0.0.1"},
+				model.AddressTailscale: {Online: false, Target: "100.64.0.1", Error: "timeout"},
+			},
+			DNS: &model.DNSResolution{Addresses: []string{"10.0.0.1"}},
+		}
+	}
+	tests := []struct {
+		name   string
+		mutate func(*model.PingResult)
+		want   bool
+	}{
+		{"identical", func(r *model.PingResult) {}, false},
+		{"rtt only", func(r *model.PingResult) { r.RTTMs = 9; r.LossPct = 50 }, false},
+		{"online", func(r *model.PingResult) { r.Online = false }, true},
+		{"state", func(r *model.PingResult) { r.State = model.PingUp }, true},
+		{"target", func(r *model.PingResult) { r.Target = "100.64.0.1" }, true},
+		{"flapping", func(r *model.PingResult) { r.Flapping = true }, true},
+		{"failing path swapped", func(r *model.PingResult) {
+			r.Addresses = map[string]model.PingProbe{
+				model.AddressPrivate:   {Online: false, Target: "10.0.0.1", Error: "timeout"},
+				model.AddressTailscale: {Online: true, Target: "100.64.0.1"},
+			}
+		}, true},
+		{"address error", func(r *model.PingResult) {
+			r.Addresses[model.AddressTailscale] = model.PingProbe{Target: "100.64.0.1", Error: "no route"}
+		}, true},
+		{"address removed", func(r *model.PingResult) { delete(r.Addresses, model.AddressTailscale) }, true},
+		{"address rtt", func(r *model.PingResult) {
+			r.Addresses[model.AddressPrivate] = model.PingProbe{Online: true, Target: "10.0.0.1", RTTMs: 4}
+		}, false},
+		{"dns addresses", func(r *model.PingResult) { r.DNS = &model.DNSResolution{Addresses: []string{"10.0.0.2"}} }, true},
+		{"dns removed", func(r *model.PingResult) { r.DNS = nil }, true},
+	}
+	for _, tt := range tests {
+		res := base()
+		tt.mutate(&res)
+		if got := pingChanged(base(), res); got != tt.want {
+			t.Errorf("%s: pingChanged = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package monitoring
+
+import (
+	"testing"
+	"time"
+
+	"inframap/internal/model"
+)
+
+func TestSummarizePing(t *testing.T) {
+	up := func(target string) model.PingProbe {
+		return model.PingProbe{Online: true, Target: target, RTTMs: 5}
+	}
+	down := func(target string) model.PingProbe {
+		return model.PingProbe{Target: target, LossPct: 100, Error: "timeout"}
+	}
+	both := model.Node{Hostname: "web.example.com", IPPublic: "203.0.113.7"}
+	resolveFailed := &model.DNSResolution{Name: "web.example.com", Error: "no such host"}
+	tests := []struct {
+		name   string
+		node   model.Node
+		probes map[string]model.PingProbe
+		dns    *model.DNSResolution
+		state  string
+		online bool
+		target string
+		error  string
+	}{
+		{
+			name:   "every address answers",
+			node:   both,
+			probes: map[string]model.PingProbe{model.AddressHostname: up("198.51.100.1"), model.AddressPublic: up("203.0.113.7")},
+			state:  model.PingUp,
+			online: true,
+			target: "198.51.100.1",
+		},
+		{
+			name:   "one address answers",
+			node:   both,
+			probes: map[string]model.PingProbe{model.AddressHostname: down("198.51.100.1"), model.AddressPublic: up("203.0.113.7")},
+			state:  model.PingDegraded,
+			online: true,
+			target: "203.0.113.7",
+		},
+		{
+			name:   "no address answers",
+			node:   both,
+			probes: map[string]model.PingProbe{model.AddressHostname: down("198.51.100.1"), model.AddressPublic: down("203.0.113.7")},
+			state:  model.PingDown,
+			target: "198.51.100.1",
+			error:  "timeout",
+		},
+		{
+			name:   "resolve failed while an address answers",
+			node:   both,
+			probes: map[string]model.PingProbe{model.AddressPublic: up("203.0.113.7")},
+			dns:    resolveFailed,
+			state:  model.PingDegraded,
+			online: true,
+			target: "203.0.113.7",
+		},
+		{
+			name:   "resolve failed without other addresses",
+			node:   model.Node{Hostname: "web.example.com"},
+			probes: map[string]model.PingProbe{},
+			dns:    resolveFailed,
+			state:  model.PingDown,
+			target: "web.example.com",
+			error:  "resolve failed: no such host",
+		},
+		{
+			name:   "no addresses",
+			probes: map[string]model.PingProbe{},
+			state:  model.PingDown,
+		},
+	}
+	for _, tt := range tests {
+		result := summarizePing(tt.node, tt.probes, tt.dns, time.Now())
+		if result.State != tt.state || result.Online != tt.online || result.Target != tt.target || result.Error != tt.error {
+			t.Errorf("%s: state %q online %t target %q error %q, want %q %t %q %q",
+				tt.name, result.State, result.Online, result.Target, result.Error, tt.state, tt.online, tt.target, tt.error)
+		}
+	}
+}
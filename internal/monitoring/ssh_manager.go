@@ -1,6 +1,7 @@
 package monitoring
 
 import (
+	"fmt"
 	"sync"
 	"time"
 
@@ -16,19 +17,33 @@ type SSHStatusManager struct {
 	mu       sync.RWMutex
 	nodes    []model.Node
 	status   map[string]model.SSHStatus
+	stats    CycleStats
 	updateCh chan struct{}
+	stopCh   chan struct{}
+	stopOnce sync.Once
 	interval time.Duration
 	provider DeviceSettingsProvider
+	pool     *sshutil.Pool
+	scope    string
+	hostKeys sshutil.HostKeyStore
 	logger   Logger
+	events   EventPublisher
+	recorder ResultRecorder
 }
 
-func NewSSHStatusManager(provider DeviceSettingsProvider, logger Logger) *SSHStatusManager {
+func NewSSHStatusManager(provider DeviceSettingsProvider, pool *sshutil.Pool, scope string, hostKeys sshutil.HostKeyStore, logger Logger, events EventPublisher, recorder ResultRecorder) *SSHStatusManager {
 	m := &SSHStatusManager{
 		status:   make(map[string]model.SSHStatus),
 		updateCh: make(chan struct{}, 1),
+		stopCh:   make(chan struct{}),
 		interval: 30 * time.Second,
 		provider: provider,
+		pool:     pool,
+		scope:    scope,
+		hostKeys: hostKeys,
 		logger:   logger,
+		events:   events,
+		recorder: recorder,
 	}
 	go m.loop()
 	return m
@@ -51,6 +66,22 @@ func (m *SSHStatusManager) GetStatus() map[string]model.SSHStatus {
 	return copyMap
 }
 
+func (m *SSHStatusManager) Stats() CycleStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.stats
+}
+
+func (m *SSHStatusManager) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.stopCh)
+	})
+}
+
+func (m *SSHStatusManager) Refresh() {
+	m.signalUpdate()
+}
+
 func (m *SSHStatusManager) signalUpdate() {
 	select {
 	case m.updateCh <- struct{}{}:
@@ -63,6 +94,8 @@ func (m *SSHStatusManager) loop() {
 	defer ticker.Stop()
 	for {
 		select {
+		case <-m.stopCh:
+			return
 		case <-ticker.C:
 			m.runCheck()
 		case <-m.updateCh:
@@ -72,6 +105,7 @@ func (m *SSHStatusManager) loop() {
 }
 
 func (m *SSHStatusManager) runCheck() {
+	start := time.Now()
 	nodes := m.getNodesSnapshot()
 	results := make(map[string]model.SSHStatus, len(nodes))
 	var wg sync.WaitGroup
@@ -103,15 +137,42 @@ func (m *SSHStatusManager) runCheck() {
 	for _, node := range nodes {
 		nodeIDs[node.ID] = struct{}{}
 	}
+	changed := make(map[string]model.SSHStatus)
 	for id, res := range results {
+		prev, ok := m.status[id]
+		if !ok || prev.Online != res.Online || prev.Error != res.Error {
+			changed[id] = res
+			if res.HostKeyMismatch && m.logger != nil {
+				m.logger.Add("warn", "ssh", fmt.Sprintf("%s: %s", id, res.Error))
+			}
+		}
 		m.status[id] = res
 	}
+	removed := []string{}
 	for id := range m.status {
 		if _, ok := nodeIDs[id]; !ok {
 			delete(m.status, id)
+			removed = append(removed, id)
 		}
 	}
+	m.stats.observe(start)
 	m.mu.Unlock()
+	if m.recorder != nil && len(results) > 0 {
+		m.recorder.RecordSSH(results)
+	}
+	if len(changed) > 0 || len(removed) > 0 {
+		m.publish("ssh", map[string]any{
+			"results": changed,
+			"removed": removed,
+		})
+	}
+}
+
+func (m *SSHStatusManager) publish(kind string, payload any) {
+	if m.events == nil {
+		return
+	}
+	m.events.Publish(kind, payload)
 }
 
 func (m *SSHStatusManager) checkNode(node model.Node) model.SSHStatus {
@@ -132,9 +193,16 @@ func (m *SSHStatusManager) checkNode(node model.Node) model.SSHStatus {
 	if settings.Host == "" {
 		settings.Host = pickTarget(node)
 	}
-	_, err = sshutil.CheckConnection(settings, 6*time.Second)
+	resolver := sshutil.Resolver{Scope: m.scope, Settings: m.provider, HostKeys: m.hostKeys}
+	target, err := resolver.Target(node.ID, settings)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	_, err = m.pool.CheckConnection(target, 6*time.Second)
 	if err != nil {
 		status.Error = err.Error()
+		status.HostKeyMismatch = sshutil.IsHostKeyMismatch(err)
 		return status
 	}
 	status.Online = true
@@ -0,0 +1,270 @@
+package notify
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"inframap/internal/model"
+)
+
+const (
+	EventFiring   = "firing"
+	EventResolved = "resolved"
+	EventTest     = "test"
+
+	StatusPending   = "pending"
+	StatusRetrying  = "retrying"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+type Logger interface {
+	Add(level, source, message string)
+}
+
+type Message struct {
+	Event string      `json:"event"`
+	Board string      `json:"board"`
+	Alert model.Alert `json:"alert"`
+}
+
+type Delivery struct {
+	ID          string `json:"id"`
+	Board       string `json:"board"`
+	Channel     string `json:"channel"`
+	ChannelName string `json:"channelName"`
+	Type        string `json:"type"`
+	Event       string `json:"event"`
+	Alert       string `json:"alert,omitempty"`
+	Node        string `json:"node,omitempty"`
+	Status      string `json:"status"`
+	Attempts    int    `json:"attempts"`
+	Error       string `json:"error,omitempty"`
+	CreatedAt   string `json:"createdAt"`
+	UpdatedAt   string `json:"updatedAt"`
+	NextAttempt string `json:"nextAttempt,omitempty"`
+}
+
+type Config struct {
+	Concurrency   int
+	Timeout       time.Duration
+	MaxBackoff    time.Duration
+	MaxDeliveries int
+}
+
+type Manager struct {
+	mu         sync.Mutex
+	cfg        Config
+	client     *http.Client
+	sem        chan struct{}
+	deliveries []*Delivery
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e permanentError) Unwrap() error {
+	return e.err
+}
+
+func NewManager(cfg Config) *Manager {
+	if cfg.Concurrency <= 0 {
+		cfg.Concurrency = 4
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 10 * time.Minute
+	}
+	if cfg.MaxDeliveries <= 0 {
+		cfg.MaxDeliveries = 500
+	}
+	return &Manager{
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+		sem:    make(chan struct{}, cfg.Concurrency),
+	}
+}
+
+func Wants(channel model.NotificationChannel, alert model.Alert) bool {
+	if !channel.Enabled || alert.Silenced {
+		return false
+	}
+	if alert.State == model.AlertResolved && !channel.SendResolved {
+		return false
+	}
+	return severityRank(alert.Severity) >= severityRank(channel.MinSeverity)
+}
+
+func (m *Manager) Dispatch(channel model.NotificationChannel, msg Message, logger Logger) {
+	delivery := m.record(channel, msg)
+	go m.deliver(channel, msg, delivery, logger)
+}
+
+func (m *Manager) Test(channel model.NotificationChannel, msg Message, logger Logger) (Delivery, error) {
+	msg.Event = EventTest
+	delivery := m.record(channel, msg)
+	err := m.attempt(context.Background(), channel, msg, delivery, logger)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return *delivery, err
+}
+
+func (m *Manager) Deliveries(board, channel string) []Delivery {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := []Delivery{}
+	for _, delivery := range m.deliveries {
+		if delivery.Board != board || (channel != "" && delivery.Channel != channel) {
+			continue
+		}
+		out = append(out, *delivery)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt > out[j].CreatedAt })
+	return out
+}
+
+func (m *Manager) record(channel model.NotificationChannel, msg Message) *Delivery {
+	id, err := newID()
+	if err != nil {
+		id = fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+	now := stamp(time.Now())
+	delivery := &Delivery{
+		ID:          id,
+		Board:       msg.Board,
+		Channel:     channel.ID,
+		ChannelName: channel.Name,
+		Type:        channel.Type,
+		Event:       msg.Event,
+		Alert:       msg.Alert.ID,
+		Node:        msg.Alert.Node,
+		Status:      StatusPending,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	m.mu.Lock()
+	m.deliveries = append(m.deliveries, delivery)
+	if len(m.deliveries) > m.cfg.MaxDeliveries {
+		m.deliveries = append([]*Delivery(nil), m.deliveries[len(m.deliveries)-m.cfg.MaxDeliveries:]...)
+	}
+	m.mu.Unlock()
+	return delivery
+}
+
+func (m *Manager) deliver(channel model.NotificationChannel, msg Message, delivery *Delivery, logger Logger) {
+	retries := channel.Retries
+	backoff := time.Duration(channel.RetryBackoffSec) * time.Second
+	if backoff <= 0 {
+		backoff = 5 * time.Second
+	}
+	if backoff > m.cfg.MaxBackoff {
+		backoff = m.cfg.MaxBackoff
+	}
+	for attempt := 0; ; attempt++ {
+		err := m.attempt(context.Background(), channel, msg, delivery, logger)
+		if err == nil {
+			return
+		}
+		var permanent permanentError
+		if attempt >= retries || errors.As(err, &permanent) {
+			m.finish(delivery, StatusFailed, time.Time{})
+			log(logger, "warn", fmt.Sprintf("notification to %s (%s) failed after %d attempt(s): %v", channel.Name, channel.Type, attempt+1, err))
+			return
+		}
+		next := time.Now().Add(backoff)
+		m.finish(delivery, StatusRetrying, next)
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > m.cfg.MaxBackoff {
+			backoff = m.cfg.MaxBackoff
+		}
+	}
+}
+
+func (m *Manager) attempt(ctx context.Context, channel model.NotificationChannel, msg Message, delivery *Delivery, logger Logger) error {
+	m.sem <- struct{}{}
+	defer func() { <-m.sem }()
+	ctx, cancel := context.WithTimeout(ctx, m.cfg.Timeout)
+	defer cancel()
+	err := Send(ctx, m.client, channel, msg)
+
+	m.mu.Lock()
+	delivery.Attempts++
+	delivery.UpdatedAt = stamp(time.Now())
+	delivery.NextAttempt = ""
+	if err != nil {
+		delivery.Error = err.Error()
+		delivery.Status = StatusFailed
+	} else {
+		delivery.Error = ""
+		delivery.Status = StatusDelivered
+	}
+	m.mu.Unlock()
+	if err == nil {
+		log(logger, "info", fmt.Sprintf("%s notification for %s sent to %s (%s)", msg.Event, describe(msg), channel.Name, channel.Type))
+	}
+	return err
+}
+
+func (m *Manager) finish(delivery *Delivery, status string, next time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delivery.Status = status
+	if !next.IsZero() {
+		delivery.NextAttempt = stamp(next)
+	}
+}
+
+func log(logger Logger, level, message string) {
+	if logger == nil {
+		return
+	}
+	logger.Add(level, "notify", message)
+}
+
+func describe(msg Message) string {
+	if msg.Event == EventTest {
+		return "board " + msg.Board
+	}
+	if msg.Alert.Node == "" {
+		return msg.Alert.RuleName
+	}
+	return msg.Alert.RuleName + " on " + msg.Alert.Node
+}
+
+func severityRank(severity string) int {
+	switch severity {
+	case "critical":
+		return 2
+	case "warning":
+		return 1
+	default:
+		return 0
+	}
+}
+
+func stamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func newID() (string, error) {
+	raw := make([]byte, 8)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
@@ -0,0 +1,146 @@
+package notify
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"inframap/internal/model"
+)
+
+func waitDelivery(t *testing.T, m *Manager, board string) Delivery {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		deliveries := m.Deliveries(board, "")
+		if len(deliveries) == 1 && (deliveries[0].Status == StatusDelivered || deliveries[0].Status == StatusFailed) {
+			return deliveries[0]
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("delivery did not finish")
+	return Delivery{}
+}
+
+func firingMessage() Message {
+	return Message{Event: EventFiring, Board: "main", Alert: model.Alert{
+		ID:       "offline:node-1",
+		RuleName: "offline",
+		Node:     "node-1",
+		Severity: "critical",
+		State:    model.AlertFiring,
+		Message:  "node-1 is offline",
+	}}
+}
+
+func TestDispatchRetriesWithBackoff(t *testing.T) {
+	var mu sync.Mutex
+	var seen []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, time.Now())
+		attempt := len(seen)
+		mu.Unlock()
+		switch attempt {
+		case 1:
+			w.WriteHeader(http.StatusBadGateway)
+		case 2:
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	m := NewManager(Config{MaxBackoff: 40 * time.Millisecond})
+	channel := model.NotificationChannel{ID: "hook", Name: "hook", Type: model.ChannelWebhook, URL: srv.URL, Retries: 3}
+	m.Dispatch(channel, firingMessage(), nil)
+	delivery := waitDelivery(t, m, "main")
+	if delivery.Status != StatusDelivered || delivery.Attempts != 3 || delivery.Error != "" {
+		t.Fatalf("delivery = %+v", delivery)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for i := 1; i < len(seen); i++ {
+		if gap := seen[i].Sub(seen[i-1]); gap < 40*time.Millisecond {
+			t.Fatalf("attempt %d came %s after the previous one", i+1, gap)
+		}
+	}
+}
+
+func TestDispatchGivesUpAfterRetries(t *testing.T) {
+	var mu sync.Mutex
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts++
+		mu.Unlock()
+		http.Error(w, "down", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	m := NewManager(Config{MaxBackoff: time.Millisecond})
+	channel := model.NotificationChannel{ID: "hook", Type: model.ChannelSlack, URL: srv.URL, Retries: 2}
+	m.Dispatch(channel, firingMessage(), nil)
+	delivery := waitDelivery(t, m, "main")
+	if delivery.Status != StatusFailed || delivery.Attempts != 3 {
+		t.Fatalf("delivery = %+v", delivery)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != 3 {
+		t.Fatalf("attempts = %d, want 3", attempts)
+	}
+}
+
+func TestDispatchDoesNotRetryClientErrors(t *testing.T) {
+	var mu sync.Mutex
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts++
+		mu.Unlock()
+		http.Error(w, "bad token", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	m := NewManager(Config{MaxBackoff: time.Millisecond})
+	channel := model.NotificationChannel{ID: "hook", Type: model.ChannelWebhook, URL: srv.URL, Retries: 3}
+	m.Dispatch(channel, firingMessage(), nil)
+	delivery := waitDelivery(t, m, "main")
+	if delivery.Status != StatusFailed || delivery.Attempts != 1 {
+		t.Fatalf("delivery = %+v", delivery)
+	}
+	if delivery.Error != "unexpected status 401 Unauthorized: bad token" {
+		t.Fatalf("error = %q", delivery.Error)
+	}
+	time.Sleep(10 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != 1 {
+		t.Fatalf("attempts = %d, want 1", attempts)
+	}
+}
+
+func TestWants(t *testing.T) {
+	channel := model.NotificationChannel{Enabled: true, MinSeverity: "warning"}
+	tests := []struct {
+		name    string
+		channel model.NotificationChannel
+		alert   model.Alert
+		want    bool
+	}{
+		{"critical firing", channel, model.Alert{Severity: "critical", State: model.AlertFiring}, true},
+		{"below min severity", channel, model.Alert{Severity: "info", State: model.AlertFiring}, false},
+		{"silenced", channel, model.Alert{Severity: "critical", Silenced: true}, false},
+		{"resolved not wanted", channel, model.Alert{Severity: "critical", State: model.AlertResolved}, false},
+		{"disabled", model.NotificationChannel{}, model.Alert{Severity: "critical"}, false},
+	}
+	for _, tt := range tests {
+		if got := Wants(tt.channel, tt.alert); got != tt.want {
+			t.Errorf("%s: Wants = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"inframap/internal/model"
+)
+
+const DefaultWebhookTemplate = `{"event": {{json .Event}}, "board": {{json .Board}}, "title": {{json .Title}}, "text": {{json .Text}}, "alert": {{json .Alert}}}`
+
+type templateData struct {
+	Event string
+	Board string
+	Title string
+	Text  string
+	Alert model.Alert
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(value any) (string, error) {
+		encoded, err := json.Marshal(value)
+		return string(encoded), err
+	},
+}
+
+func Send(ctx context.Context, client *http.Client, channel model.NotificationChannel, msg Message) error {
+	switch channel.Type {
+	case model.ChannelWebhook:
+		return sendWebhook(ctx, client, channel, msg)
+	case model.ChannelSlack:
+		return postJSON(ctx, client, channel.URL, map[string]any{"text": Title(msg) + "\n" + Text(msg)}, nil)
+	case model.ChannelDiscord:
+		content := truncate(Title(msg)+"\n"+Text(msg), 2000)
+		return postJSON(ctx, client, channel.URL, map[string]any{"content": content}, nil)
+	case model.ChannelTeams:
+		return postJSON(ctx, client, channel.URL, map[string]any{
+			"@type":      "MessageCard",
+			"@context":   "https://schema.org/extensions",
+			"summary":    Title(msg),
+			"title":      Title(msg),
+			"themeColor": themeColor(msg),
+			"text":       strings.ReplaceAll(Text(msg), "\n", "<br>"),
+		}, nil)
+	case model.ChannelEmail:
+		return sendEmail(ctx, channel.SMTP, msg)
+	default:
+		return permanentError{fmt.Errorf("unknown channel type %q", channel.Type)}
+	}
+}
+
+func Title(msg Message) string {
+	if msg.Event == EventTest {
+		return "[TEST] InfraMap test notification"
+	}
+	return fmt.Sprintf("[%s] %s", strings.ToUpper(msg.Event), describe(msg))
+}
+
+func Text(msg Message) string {
+	if msg.Event == EventTest {
+		return fmt.Sprintf("This is a test notification from InfraMap board %s.", msg.Board)
+	}
+	lines := []string{
+		msg.Alert.Message,
+		"Severity: " + msg.Alert.Severity,
+		"Board: " + msg.Board,
+		"Started: " + msg.Alert.StartedAt,
+	}
+	if msg.Alert.ResolvedAt != "" {
+		lines = append(lines, "Resolved: "+msg.Alert.ResolvedAt)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func ValidateTemplate(text string) error {
+	_, err := renderTemplate(text, Message{Event: EventTest, Board: "default", Alert: model.Alert{
+		ID:       "example",
+		RuleName: "example",
+		Node:     "node-1",
+		State:    model.AlertFiring,
+	}})
+	return err
+}
+
+func renderTemplate(text string, msg Message) ([]byte, error) {
+	if strings.TrimSpace(text) == "" {
+		text = DefaultWebhookTemplate
+	}
+	tmpl, err := template.New("webhook").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, templateData{
+		Event: msg.Event,
+		Board: msg.Board,
+		Title: Title(msg),
+		Text:  Text(msg),
+		Alert: msg.Alert,
+	}); err != nil {
+		return nil, err
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, errors.New("template does not produce valid JSON")
+	}
+	return buf.Bytes(), nil
+}
+
+func sendWebhook(ctx context.Context, client *http.Client, channel model.NotificationChannel, msg Message) error {
+	body, err := renderTemplate(channel.Template, msg)
+	if err != nil {
+		return permanentError{err}
+	}
+	headers := map[string]string{"X-InfraMap-Event": msg.Event}
+	for key, value := range channel.Headers {
+		headers[key] = value
+	}
+	if channel.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		headers["X-InfraMap-Timestamp"] = timestamp
+		headers["X-InfraMap-Signature"] = "sha256=" + Sign(channel.Secret, timestamp, body)
+	}
+	return post(ctx, client, channel.URL, body, headers)
+}
+
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func postJSON(ctx context.Context, client *http.Client, url string, payload any, headers map[string]string) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return permanentError{err}
+	}
+	return post(ctx, client, url, body, headers)
+}
+
+func post(ctx context.Context, client *http.Client, endpoint string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return permanentError{err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "InfraMap")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return fmt.Errorf("post to %s: %w", req.URL.Host, urlErr.Err)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	err = fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(snippet)))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return permanentError{err}
+	}
+	return err
+}
+
+func truncate(text string, limit int) string {
+	count := 0
+	for i := range text {
+		if count == limit {
+			return text[:i]
+		}
+		count++
+	}
+	return text
+}
+
+func themeColor(msg Message) string {
+	if msg.Event == EventResolved {
+		return "2EB886"
+	}
+	switch msg.Alert.Severity {
+	case "critical":
+		return "D93025"
+	case "warning":
+		return "F2A516"
+	default:
+		return "4A90D9"
+	}
+}
+
+func sendEmail(ctx context.Context, settings *model.SMTPSettings, msg Message) error {
+	if settings == nil {
+		return permanentError{errors.New("smtp settings missing")}
+	}
+	port := settings.Port
+	if port == 0 {
+		port = 587
+		if settings.TLS == "tls" {
+			port = 465
+		}
+	}
+	addr := net.JoinHostPort(settings.Host, strconv.Itoa(port))
+	tlsConfig := &tls.Config{ServerName: settings.Host}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	if settings.TLS == "tls" {
+		conn = tls.Client(conn, tlsConfig)
+	}
+	client, err := smtp.NewClient(conn, settings.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if settings.TLS != "tls" && settings.TLS != "none" {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return err
+			}
+		} else if settings.TLS == "starttls" {
+			return permanentError{errors.New("smtp server does not support STARTTLS")}
+		}
+	}
+	if settings.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", settings.Username, settings.Password, settings.Host)); err != nil {
+			return smtpError(err)
+		}
+	}
+	from, err := mail.ParseAddress(settings.From)
+	if err != nil {
+		return permanentError{err}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return smtpError(err)
+	}
+	for _, to := range settings.To {
+		addr, err := mail.ParseAddress(to)
+		if err != nil {
+			return permanentError{err}
+		}
+		if err := client.Rcpt(addr.Address); err != nil {
+			return smtpError(err)
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return smtpError(err)
+	}
+	if _, err := writer.Write(buildEmail(settings, msg)); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return smtpError(err)
+	}
+	return client.Quit()
+}
+
+func buildEmail(settings *model.SMTPSettings, msg Message) []byte {
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		value = strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+		buf.WriteString(key + ": " + value + "\r\n")
+	}
+	header("From", settings.From)
+	header("To", strings.Join(settings.To, ", "))
+	header("Subject", mimeHeader(Title(msg)))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "8bit")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(Text(msg), "\n", "\r\n"))
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
+
+func mimeHeader(value string) string {
+	for _, r := range value {
+		if r > 127 {
+			return mime.QEncoding.Encode("utf-8", value)
+		}
+	}
+	return value
+}
+
+func smtpError(err error) error {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) && protoErr.Code >= 500 {
+		return permanentError{err}
+	}
+	return err
+}
@@ -0,0 +1,220 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"inframap/internal/model"
+)
+
+func TestWebhookSignature(t *testing.T) {
+	var header http.Header
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	channel := model.NotificationChannel{
+		Type:    model.ChannelWebhook,
+		URL:     srv.URL,
+		Secret:  "s3cret",
+		Headers: map[string]string{"X-Team": "ops"},
+	}
+	if err := Send(context.Background(), srv.Client(), channel, firingMessage()); err != nil {
+		t.Fatal(err)
+	}
+	timestamp := header.Get("X-InfraMap-Timestamp")
+	if ts, err := strconv.ParseInt(timestamp, 10, 64); err != nil || time.Since(time.Unix(ts, 0)) > time.Minute {
+		t.Fatalf("timestamp = %q", timestamp)
+	}
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); header.Get("X-InfraMap-Signature") != want {
+		t.Fatalf("signature = %q, want %q", header.Get("X-InfraMap-Signature"), want)
+	}
+	if header.Get("X-InfraMap-Event") != EventFiring || header.Get("X-Team") != "ops" {
+		t.Fatalf("headers = %v", header)
+	}
+	var payload struct {
+		Event string      `json:"event"`
+		Title string      `json:"title"`
+		Alert model.Alert `json:"alert"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatal(err)
+	}
+	if payload.Event != EventFiring || payload.Title != "[FIRING] offline on node-1" || payload.Alert.Node != "node-1" {
+		t.Fatalf("payload = %+v", payload)
+	}
+}
+
+func TestWebhookWithoutSecretIsUnsigned(t *testing.T) {
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+	}))
+	defer srv.Close()
+
+	channel := model.NotificationChannel{Type: model.ChannelWebhook, URL: srv.URL, Template: `{"text": {{json .Text}}}`}
+	if err := Send(context.Background(), srv.Client(), channel, firingMessage()); err != nil {
+		t.Fatal(err)
+	}
+	if header.Get("X-InfraMap-Signature") != "" || header.Get("X-InfraMap-Timestamp") != "" {
+		t.Fatalf("headers = %v", header)
+	}
+}
+
+func TestDiscordTruncatesOnRuneBoundary(t *testing.T) {
+	var content string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Content string `json:"content"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		content = payload.Content
+	}))
+	defer srv.Close()
+
+	msg := firingMessage()
+	msg.Alert.Message = strings.Repeat("é", 3000)
+	channel := model.NotificationChannel{Type: model.ChannelDiscord, URL: srv.URL}
+	if err := Send(context.Background(), srv.Client(), channel, msg); err != nil {
+		t.Fatal(err)
+	}
+	if !utf8.ValidString(content) || utf8.RuneCountInString(content) != 2000 {
+		t.Fatalf("content has %d runes (valid %t)", utf8.RuneCountInString(content), utf8.ValidString(content))
+	}
+	if got := truncate("héllo", 2); got != "hé" {
+		t.Fatalf("truncate = %q", got)
+	}
+	if got := truncate("short", 10); got != "short" {
+		t.Fatalf("truncate = %q", got)
+	}
+}
+
+type smtpServer struct {
+	addr string
+	data chan string
+}
+
+func startSMTP(t *testing.T, rcptReply string) *smtpServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	srv := &smtpServer{addr: ln.Addr().String(), data: make(chan string, 1)}
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+		reply("220 stub ESMTP")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			command := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+				reply("250 stub")
+			case strings.HasPrefix(command, "MAIL FROM:"):
+				reply("250 ok")
+			case strings.HasPrefix(command, "RCPT TO:"):
+				reply(rcptReply)
+			case command == "DATA":
+				reply("354 go ahead")
+				var data strings.Builder
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if line == ".\r\n" {
+						break
+					}
+					data.WriteString(line)
+				}
+				srv.data <- data.String()
+				reply("250 queued")
+			case command == "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+	return srv
+}
+
+func smtpSettings(t *testing.T, addr string) *model.SMTPSettings {
+	t.Helper()
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	portNum, _ := strconv.Atoi(port)
+	return &model.SMTPSettings{
+		Host: host,
+		Port: portNum,
+		TLS:  "none",
+		From: "InfraMap <inframap@example.com>",
+		To:   []string{"ops@example.com"},
+	}
+}
+
+func TestEmailSend(t *testing.T) {
+	srv := startSMTP(t, "250 ok")
+	channel := model.NotificationChannel{Type: model.ChannelEmail, SMTP: smtpSettings(t, srv.addr)}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := Send(ctx, nil, channel, firingMessage()); err != nil {
+		t.Fatal(err)
+	}
+	data := <-srv.data
+	for _, want := range []string{
+		"From: InfraMap <inframap@example.com>\r\n",
+		"To: ops@example.com\r\n",
+		"Subject: [FIRING] offline on node-1\r\n",
+		"\r\n\r\nnode-1 is offline\r\nSeverity: critical\r\n",
+	} {
+		if !strings.Contains(data, want) {
+			t.Fatalf("message missing %q:\n%s", want, data)
+		}
+	}
+}
+
+func TestEmailRejectedRecipientIsPermanent(t *testing.T) {
+	srv := startSMTP(t, "550 no such user")
+	channel := model.NotificationChannel{Type: model.ChannelEmail, SMTP: smtpSettings(t, srv.addr)}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := Send(ctx, nil, channel, firingMessage())
+	var permanent permanentError
+	if !errors.As(err, &permanent) {
+		t.Fatalf("err = %v, want permanent", err)
+	}
+}
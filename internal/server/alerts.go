@@ -0,0 +1,333 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"inframap/internal/model"
+	"inframap/internal/storage"
+)
+
+type silenceRequest struct {
+	Rule        string `json:"rule"`
+	Node        string `json:"node"`
+	Until       string `json:"until"`
+	DurationSec int    `json:"durationSec"`
+	Comment     string `json:"comment"`
+}
+
+func (s *Server) handleAlerts(w http.ResponseWriter, r *http.Request) {
+	ws, ok := s.workspaceFor(w, r)
+	if !ok {
+		return
+	}
+	if ws.alerts == nil || ws.alertStore == nil {
+		http.Error(w, "alert store not available", http.StatusInternalServerError)
+		return
+	}
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/alerts"), "/")
+	head, tail, _ := strings.Cut(rest, "/")
+	switch head {
+	case "":
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.listAlerts(w, r, ws)
+	case "rules":
+		s.handleAlertRules(w, r, ws, tail)
+	case "silences":
+		s.handleSilences(w, r, ws, tail)
+	default:
+		s.handleAlert(w, r, ws, head, tail)
+	}
+}
+
+func (s *Server) listAlerts(w http.ResponseWriter, r *http.Request, ws *workspace) {
+	alerts, err := ws.alertStore.Alerts()
+	if err != nil {
+		http.Error(w, "failed to read alerts", http.StatusInternalServerError)
+		return
+	}
+	silences, err := ws.alertStore.Silences()
+	if err != nil {
+		http.Error(w, "failed to read silences", http.StatusInternalServerError)
+		return
+	}
+	state := r.URL.Query().Get("state")
+	node := r.URL.Query().Get("node")
+	now := time.Now()
+	items := []model.Alert{}
+	for _, alert := range alerts {
+		if state != "" && alert.State != state {
+			continue
+		}
+		if node != "" && alert.Node != node {
+			continue
+		}
+		if alert.State == model.AlertFiring {
+			alert.Silenced = silencedBy(silences, alert, now)
+		}
+		items = append(items, alert)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"items": items,
+	})
+}
+
+func (s *Server) handleAlert(w http.ResponseWriter, r *http.Request, ws *workspace, id, action string) {
+	alert, ok, err := ws.alertStore.GetAlert(id)
+	if err != nil {
+		http.Error(w, "failed to read alerts", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "alert not found", http.StatusNotFound)
+		return
+	}
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		if alert.State == model.AlertFiring {
+			if silences, err := ws.alertStore.Silences(); err == nil {
+				alert.Silenced = silencedBy(silences, alert, time.Now())
+			}
+		}
+		writeJSON(w, http.StatusOK, alert)
+	case action == "ack" && r.Method == http.MethodPost:
+		if !s.requireRole(w, r, storage.RoleOperator) {
+			return
+		}
+		alert, err := ws.alerts.Acknowledge(id, requestAuthor(r))
+		if err != nil {
+			http.Error(w, "failed to acknowledge alert", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, alert)
+	case action == "" || action == "ack":
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+func (s *Server) handleAlertRules(w http.ResponseWriter, r *http.Request, ws *workspace, id string) {
+	if id == "" {
+		switch r.Method {
+		case http.MethodGet:
+			rules, err := ws.alertStore.Rules()
+			if err != nil {
+				http.Error(w, "failed to read alert rules", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]any{
+				"items":      rules,
+				"conditions": []string{model.AlertOffline, model.AlertRTTHigh, model.AlertSSHDownPingUp},
+			})
+		case http.MethodPost:
+			if !s.requireRole(w, r, storage.RoleEditor) {
+				return
+			}
+			rule := model.AlertRule{Severity: "warning", Enabled: true}
+			if !decodeAlertRule(w, r, &rule) {
+				return
+			}
+			rule.ID = ""
+			s.saveAlertRule(w, r, ws, rule, http.StatusCreated)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+		return
+	}
+
+	rule, ok, err := ws.alertStore.GetRule(id)
+	if err != nil {
+		http.Error(w, "failed to read alert rules", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "alert rule not found", http.StatusNotFound)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, rule)
+	case http.MethodPut:
+		if !s.requireRole(w, r, storage.RoleEditor) {
+			return
+		}
+		if !decodeAlertRule(w, r, &rule) {
+			return
+		}
+		rule.ID = id
+		s.saveAlertRule(w, r, ws, rule, http.StatusOK)
+	case http.MethodDelete:
+		if !s.requireRole(w, r, storage.RoleEditor) {
+			return
+		}
+		if err := ws.alertStore.DeleteRule(id); err != nil {
+			http.Error(w, "failed to delete alert rule", http.StatusInternalServerError)
+			return
+		}
+		s.reloadAlertRules(ws)
+		s.log("info", "alerts", fmt.Sprintf("alert rule %s deleted by %s", rule.Name, requestAuthor(r)))
+		writeJSON(w, http.StatusOK, map[string]string{
+			"status": "deleted",
+		})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func decodeAlertRule(w http.ResponseWriter, r *http.Request, rule *model.AlertRule) bool {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return false
+	}
+	if err := json.Unmarshal(body, rule); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return false
+	}
+	rule.Name = strings.TrimSpace(rule.Name)
+	rule.Network = strings.TrimSpace(rule.Network)
+	if errs := model.ValidateAlertRule(rule); len(errs) > 0 {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{
+			"error":  "invalid alert rule",
+			"errors": errs,
+		})
+		return false
+	}
+	return true
+}
+
+func (s *Server) saveAlertRule(w http.ResponseWriter, r *http.Request, ws *workspace, rule model.AlertRule, status int) {
+	saved, err := ws.alertStore.PutRule(rule)
+	if err != nil {
+		http.Error(w, "failed to save alert rule", http.StatusInternalServerError)
+		return
+	}
+	s.reloadAlertRules(ws)
+	s.log("info", "alerts", fmt.Sprintf("alert rule %s saved by %s", saved.Name, requestAuthor(r)))
+	writeJSON(w, status, saved)
+}
+
+func (s *Server) reloadAlertRules(ws *workspace) {
+	if err := ws.alerts.ReloadRules(); err != nil {
+		s.log("warn", "alerts", fmt.Sprintf("failed to reload alert rules: %v", err))
+	}
+}
+
+func (s *Server) handleSilences(w http.ResponseWriter, r *http.Request, ws *workspace, id string) {
+	if id != "" {
+		if r.Method != http.MethodDelete {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !s.requireRole(w, r, storage.RoleOperator) {
+			return
+		}
+		err := ws.alertStore.DeleteSilence(id)
+		if errors.Is(err, storage.ErrSilenceNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "failed to delete silence", http.StatusInternalServerError)
+			return
+		}
+		s.log("info", "alerts", fmt.Sprintf("silence %s removed by %s", id, requestAuthor(r)))
+		writeJSON(w, http.StatusOK, map[string]string{
+			"status": "deleted",
+		})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		silences, err := ws.alertStore.Silences()
+		if err != nil {
+			http.Error(w, "failed to read silences", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"items": silences,
+		})
+	case http.MethodPost:
+		if !s.requireRole(w, r, storage.RoleOperator) {
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err != nil {
+			http.Error(w, "failed to read request body", http.StatusBadRequest)
+			return
+		}
+		var req silenceRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+		silence, err := buildSilence(ws, req, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		silence.CreatedBy = requestAuthor(r)
+		saved, err := ws.alertStore.AddSilence(silence)
+		if err != nil {
+			http.Error(w, "failed to save silence", http.StatusInternalServerError)
+			return
+		}
+		s.log("info", "alerts", fmt.Sprintf("silence %s added by %s until %s", saved.ID, saved.CreatedBy, saved.Until))
+		writeJSON(w, http.StatusCreated, saved)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func buildSilence(ws *workspace, req silenceRequest, now time.Time) (model.Silence, error) {
+	silence := model.Silence{
+		Rule:    strings.TrimSpace(req.Rule),
+		Node:    strings.TrimSpace(req.Node),
+		Comment: strings.TrimSpace(req.Comment),
+	}
+	if silence.Rule == "" && silence.Node == "" {
+		return model.Silence{}, errors.New("rule or node is required")
+	}
+	if silence.Rule != "" {
+		if _, ok, err := ws.alertStore.GetRule(silence.Rule); err != nil || !ok {
+			return model.Silence{}, fmt.Errorf("unknown alert rule %q", silence.Rule)
+		}
+	}
+	var until time.Time
+	switch {
+	case req.Until != "":
+		parsed, err := time.Parse(time.RFC3339, req.Until)
+		if err != nil {
+			return model.Silence{}, fmt.Errorf("invalid until: %v", err)
+		}
+		until = parsed
+	case req.DurationSec > 0:
+		until = now.Add(time.Duration(req.DurationSec) * time.Second)
+	default:
+		return model.Silence{}, errors.New("until or durationSec is required")
+	}
+	if !until.After(now) {
+		return model.Silence{}, errors.New("until must be in the future")
+	}
+	silence.Until = until.UTC().Format(time.RFC3339)
+	return silence, nil
+}
+
+func silencedBy(silences []model.Silence, alert model.Alert, now time.Time) bool {
+	for _, silence := range silences {
+		if silence.Matches(alert, now) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,587 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"inframap/internal/storage"
+)
+
+const (
+	sessionCookieName = "inframap_session"
+	csrfHeaderName    = "X-CSRF-Token"
+	sessionTTL        = 24 * time.Hour
+	loginMaxFailures  = 5
+	loginLockout      = 5 * time.Minute
+)
+
+var publicAPIPaths = map[string]struct{}{
+	"/api/health":      {},
+	"/api/auth/status": {},
+	"/api/auth/setup":  {},
+	"/api/auth/login":  {},
+}
+
+type session struct {
+	token    string
+	username string
+	csrf     string
+	expires  time.Time
+}
+
+type loginAttempt struct {
+	failures int
+	until    time.Time
+}
+
+type sessionStore struct {
+	mu       sync.Mutex
+	items    map[string]*session
+	attempts map[string]*loginAttempt
+}
+
+func newSessionStore() *sessionStore {
+	return &sessionStore{
+		items:    make(map[string]*session),
+		attempts: make(map[string]*loginAttempt),
+	}
+}
+
+func (st *sessionStore) create(username string) (*session, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	csrf, err := randomToken(24)
+	if err != nil {
+		return nil, err
+	}
+	sess := &session{
+		token:    token,
+		username: username,
+		csrf:     csrf,
+		expires:  time.Now().Add(sessionTTL),
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	now := time.Now()
+	for key, item := range st.items {
+		if now.After(item.expires) {
+			delete(st.items, key)
+		}
+	}
+	st.items[token] = sess
+	return sess, nil
+}
+
+func (st *sessionStore) get(token string) (*session, bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	sess, ok := st.items[token]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(sess.expires) {
+		delete(st.items, token)
+		return nil, false
+	}
+	sess.expires = time.Now().Add(sessionTTL)
+	return sess, true
+}
+
+func (st *sessionStore) delete(token string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	delete(st.items, token)
+}
+
+func (st *sessionStore) deleteUser(username string) {
+	st.deleteUserExcept(username, "")
+}
+
+func (st *sessionStore) deleteUserExcept(username, keep string) int {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	removed := 0
+	for key, item := range st.items {
+		if key != keep && strings.EqualFold(item.username, username) {
+			delete(st.items, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+func (st *sessionStore) loginBlocked(key string) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	attempt, ok := st.attempts[key]
+	if !ok {
+		return false
+	}
+	if time.Now().After(attempt.until) {
+		delete(st.attempts, key)
+		return false
+	}
+	return attempt.failures >= loginMaxFailures
+}
+
+func (st *sessionStore) loginResult(key string, success bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if success {
+		delete(st.attempts, key)
+		return
+	}
+	attempt, ok := st.attempts[key]
+	if !ok || time.Now().After(attempt.until) {
+		attempt = &loginAttempt{}
+		st.attempts[key] = attempt
+	}
+	attempt.failures++
+	attempt.until = time.Now().Add(loginLockout)
+}
+
+type authInfo struct {
+	Username string
+	Role     string
+	Session  *session
+	Method   string
+}
+
+var roleLevels = map[string]int{
+	storage.RoleViewer:   1,
+	storage.RoleEditor:   2,
+	storage.RoleOperator: 3,
+	storage.RoleAdmin:    4,
+}
+
+func roleAtLeast(have, need string) bool {
+	return roleLevels[have] >= roleLevels[need]
+}
+
+func (s *Server) requireRole(w http.ResponseWriter, r *http.Request, role string) bool {
+	if s.users == nil {
+		return true
+	}
+	info := currentAuth(r)
+	if info != nil && roleAtLeast(info.Role, role) {
+		return true
+	}
+	writeJSON(w, http.StatusForbidden, map[string]string{
+		"error": "this action requires the " + role + " role",
+	})
+	return false
+}
+
+type authContextKey struct{}
+
+func currentAuth(r *http.Request) *authInfo {
+	info, _ := r.Context().Value(authContextKey{}).(*authInfo)
+	return info
+}
+
+func (s *Server) withAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.users == nil || (!strings.HasPrefix(r.URL.Path, "/api/") && r.URL.Path != "/metrics") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		info, err := s.identify(r)
+		if info != nil {
+			r = r.WithContext(context.WithValue(r.Context(), authContextKey{}, info))
+		}
+		if _, public := publicAPIPaths[r.URL.Path]; public {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if info == nil {
+			message := "authentication required"
+			if err != nil {
+				message = err.Error()
+			}
+			writeJSON(w, http.StatusUnauthorized, map[string]string{
+				"error": message,
+			})
+			return
+		}
+		if info.Session != nil && !isSafeMethod(r.Method) {
+			token := r.Header.Get(csrfHeaderName)
+			if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(info.Session.csrf)) != 1 {
+				writeJSON(w, http.StatusForbidden, map[string]string{
+					"error": "missing or invalid CSRF token",
+				})
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) identify(r *http.Request) (*authInfo, error) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		scheme, secret, _ := strings.Cut(header, " ")
+		if !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(secret) == "" {
+			return nil, errors.New("unsupported authorization header")
+		}
+		user, err := s.users.AuthenticateToken(strings.TrimSpace(secret))
+		if err != nil {
+			return nil, errors.New("invalid api token")
+		}
+		return &authInfo{Username: user.Username, Role: user.Role, Method: "token"}, nil
+	}
+	cookie, err := r.Cookie(sessionCookieName)
+	if err != nil || cookie.Value == "" {
+		return nil, nil
+	}
+	sess, ok := s.sessions.get(cookie.Value)
+	if !ok {
+		return nil, errors.New("session expired")
+	}
+	user, exists, err := s.users.Get(sess.username)
+	if err != nil || !exists {
+		s.sessions.delete(sess.token)
+		return nil, errors.New("session expired")
+	}
+	return &authInfo{Username: user.Username, Role: user.Role, Session: sess, Method: "session"}, nil
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+type credentialsRequest struct {
+	Username        string `json:"username"`
+	Password        string `json:"password"`
+	CurrentPassword string `json:"currentPassword"`
+	NewPassword     string `json:"newPassword"`
+	Name            string `json:"name"`
+	Role            string `json:"role"`
+}
+
+func readCredentials(w http.ResponseWriter, r *http.Request) (credentialsRequest, bool) {
+	var req credentialsRequest
+	body, err := io.ReadAll(io.LimitReader(r.Body, 64<<10))
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return req, false
+	}
+	if len(strings.TrimSpace(string(body))) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return req, false
+		}
+	}
+	req.Username = strings.TrimSpace(req.Username)
+	return req, true
+}
+
+func (s *Server) handleAuthStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	count, err := s.users.Count()
+	if err != nil {
+		http.Error(w, "failed to read users", http.StatusInternalServerError)
+		return
+	}
+	payload := map[string]any{
+		"setupRequired": count == 0,
+		"authenticated": false,
+	}
+	if info := currentAuth(r); info != nil {
+		payload["authenticated"] = true
+		payload["username"] = info.Username
+	}
+	writeJSON(w, http.StatusOK, payload)
+}
+
+func (s *Server) handleAuthSetup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	req, ok := readCredentials(w, r)
+	if !ok {
+		return
+	}
+	user, err := s.users.CreateFirstAdmin(req.Username, req.Password)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	s.log("info", "auth", fmt.Sprintf("initial admin %s created", user.Username))
+	s.startSession(w, r, user)
+}
+
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	req, ok := readCredentials(w, r)
+	if !ok {
+		return
+	}
+	key := clientIP(r) + "|" + strings.ToLower(req.Username)
+	if s.sessions.loginBlocked(key) {
+		http.Error(w, "too many failed login attempts, try again later", http.StatusTooManyRequests)
+		return
+	}
+	user, err := s.users.Authenticate(req.Username, req.Password)
+	if err != nil {
+		s.sessions.loginResult(key, false)
+		s.log("warn", "auth", fmt.Sprintf("failed login for %q from %s", req.Username, clientIP(r)))
+		writeJSON(w, http.StatusUnauthorized, map[string]string{
+			"error": storage.ErrInvalidCredentials.Error(),
+		})
+		return
+	}
+	s.sessions.loginResult(key, true)
+	s.log("info", "auth", fmt.Sprintf("%s logged in from %s", user.Username, clientIP(r)))
+	s.startSession(w, r, user)
+}
+
+func (s *Server) startSession(w http.ResponseWriter, r *http.Request, user storage.User) {
+	sess, err := s.sessions.create(user.Username)
+	if err != nil {
+		http.Error(w, "failed to create session", http.StatusInternalServerError)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    sess.token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+		MaxAge:   int(sessionTTL.Seconds()),
+	})
+	writeJSON(w, http.StatusOK, map[string]any{
+		"username":  sess.username,
+		"role":      user.Role,
+		"csrfToken": sess.csrf,
+	})
+}
+
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if info := currentAuth(r); info != nil && info.Session != nil {
+		s.sessions.delete(info.Session.token)
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    "",
+		Path:     "/",
+		HttpOnly: true,
+		MaxAge:   -1,
+	})
+	writeJSON(w, http.StatusOK, map[string]string{
+		"status": "logged out",
+	})
+}
+
+func (s *Server) handleMe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	info := currentAuth(r)
+	payload := map[string]any{
+		"username": info.Username,
+		"role":     info.Role,
+		"method":   info.Method,
+	}
+	if info.Session != nil {
+		payload["csrfToken"] = info.Session.csrf
+	}
+	writeJSON(w, http.StatusOK, payload)
+}
+
+func (s *Server) handleChangePassword(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	req, ok := readCredentials(w, r)
+	if !ok {
+		return
+	}
+	info := currentAuth(r)
+	if _, err := s.users.Authenticate(info.Username, req.CurrentPassword); err != nil {
+		http.Error(w, "current password is incorrect", http.StatusForbidden)
+		return
+	}
+	if err := s.users.SetPassword(info.Username, req.NewPassword); err != nil {
+		writeUserError(w, err)
+		return
+	}
+	keep := ""
+	if info.Session != nil {
+		keep = info.Session.token
+	}
+	revoked := s.sessions.deleteUserExcept(info.Username, keep)
+	s.log("info", "auth", fmt.Sprintf("%s changed their password, %d other sessions revoked", info.Username, revoked))
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status":          "updated",
+		"revokedSessions": revoked,
+	})
+}
+
+func (s *Server) handleTokens(w http.ResponseWriter, r *http.Request) {
+	info := currentAuth(r)
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/auth/tokens"), "/")
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		items, err := s.users.ListTokens(info.Username)
+		if err != nil {
+			http.Error(w, "failed to read tokens", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"items": items,
+		})
+	case id == "" && r.Method == http.MethodPost:
+		req, ok := readCredentials(w, r)
+		if !ok {
+			return
+		}
+		if strings.TrimSpace(req.Name) == "" {
+			http.Error(w, "token name is required", http.StatusBadRequest)
+			return
+		}
+		token, secret, err := s.users.CreateToken(info.Username, req.Name)
+		if err != nil {
+			writeUserError(w, err)
+			return
+		}
+		token.Hash = ""
+		s.log("info", "auth", fmt.Sprintf("%s created api token %q", info.Username, token.Name))
+		writeJSON(w, http.StatusCreated, map[string]any{
+			"token":  token,
+			"secret": secret,
+		})
+	case id != "" && r.Method == http.MethodDelete:
+		if err := s.users.DeleteToken(info.Username, id); err != nil {
+			writeUserError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{
+			"status": "deleted",
+		})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleUsers(w http.ResponseWriter, r *http.Request) {
+	if !s.requireRole(w, r, storage.RoleAdmin) {
+		return
+	}
+	name := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/users"), "/")
+	switch {
+	case name == "" && r.Method == http.MethodGet:
+		items, err := s.users.List()
+		if err != nil {
+			http.Error(w, "failed to read users", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"items": items,
+		})
+	case name == "" && r.Method == http.MethodPost:
+		req, ok := readCredentials(w, r)
+		if !ok {
+			return
+		}
+		if req.Role == "" {
+			req.Role = storage.RoleViewer
+		}
+		user, err := s.users.Create(req.Username, req.Password, req.Role)
+		if err != nil {
+			writeUserError(w, err)
+			return
+		}
+		user.PasswordHash = ""
+		s.log("info", "auth", fmt.Sprintf("user %s (%s) created by %s", user.Username, user.Role, currentAuth(r).Username))
+		writeJSON(w, http.StatusCreated, user)
+	case name != "" && (r.Method == http.MethodPatch || r.Method == http.MethodPut):
+		req, ok := readCredentials(w, r)
+		if !ok {
+			return
+		}
+		user, err := s.users.SetRole(name, strings.ToLower(strings.TrimSpace(req.Role)))
+		if err != nil {
+			writeUserError(w, err)
+			return
+		}
+		s.log("info", "auth", fmt.Sprintf("user %s is now %s (changed by %s)", user.Username, user.Role, currentAuth(r).Username))
+		writeJSON(w, http.StatusOK, user)
+	case name != "" && r.Method == http.MethodDelete:
+		if strings.EqualFold(name, currentAuth(r).Username) {
+			http.Error(w, "you cannot delete your own account", http.StatusBadRequest)
+			return
+		}
+		if err := s.users.Delete(name); err != nil {
+			writeUserError(w, err)
+			return
+		}
+		s.sessions.deleteUser(name)
+		s.log("info", "auth", fmt.Sprintf("user %s deleted by %s", name, currentAuth(r).Username))
+		writeJSON(w, http.StatusOK, map[string]string{
+			"status": "deleted",
+		})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func writeUserError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, storage.ErrInvalidUsername), errors.Is(err, storage.ErrWeakPassword),
+		errors.Is(err, storage.ErrInvalidRole), errors.Is(err, storage.ErrLastAdmin):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, storage.ErrUserExists), errors.Is(err, storage.ErrSetupDone):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, storage.ErrUserNotFound), errors.Is(err, storage.ErrTokenNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func randomToken(size int) (string, error) {
+	raw := make([]byte, size)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,233 @@
+package server
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"inframap/internal/storage"
+)
+
+func newUserStore(t *testing.T) *storage.UserStore {
+	t.Helper()
+	users, err := storage.NewUserStore(filepath.Join(t.TempDir(), "users.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return users
+}
+
+type testSession struct {
+	cookie *http.Cookie
+	csrf   string
+}
+
+func login(t *testing.T, h http.Handler, username, password string) testSession {
+	t.Helper()
+	rec := serve(h, testRequest{
+		method: http.MethodPost,
+		path:   "/api/auth/login",
+		body:   `{"username": "` + username + `", "password": "` + password + `"}`,
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login %s = %d %s", username, rec.Code, rec.Body.String())
+	}
+	var cookie *http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == sessionCookieName {
+			cookie = c
+		}
+	}
+	if cookie == nil || !cookie.HttpOnly {
+		t.Fatalf("session cookie = %+v", cookie)
+	}
+	return testSession{cookie: cookie, csrf: decode(t, rec)["csrfToken"].(string)}
+}
+
+func (ts testSession) request(method, path, body string) testRequest {
+	return testRequest{
+		method:  method,
+		path:    path,
+		body:    body,
+		headers: map[string]string{csrfHeaderName: ts.csrf},
+		cookies: []*http.Cookie{ts.cookie},
+	}
+}
+
+func TestAuthSetupCreatesOnlyOneAdmin(t *testing.T) {
+	users := newUserStore(t)
+	_, h := newTestServer(t, users)
+
+	status := decode(t, serve(h, testRequest{method: http.MethodGet, path: "/api/auth/status"}))
+	if status["setupRequired"] != true {
+		t.Fatalf("status = %v", status)
+	}
+
+	codes := make(chan int, 8)
+	var wg sync.WaitGroup
+	for i := 0; i < cap(codes); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rec := serve(h, testRequest{
+				method: http.MethodPost,
+				path:   "/api/auth/setup",
+				body:   `{"username": "admin` + string(rune('a'+i)) + `", "password": "correct horse"}`,
+			})
+			codes <- rec.Code
+		}(i)
+	}
+	wg.Wait()
+	close(codes)
+	created := 0
+	for code := range codes {
+		switch code {
+		case http.StatusOK:
+			created++
+		case http.StatusConflict:
+		default:
+			t.Fatalf("setup = %d", code)
+		}
+	}
+	if count, _ := users.Count(); created != 1 || count != 1 {
+		t.Fatalf("created %d admins, store has %d", created, count)
+	}
+}
+
+func TestAuthRequiresSessionOrToken(t *testing.T) {
+	users := newUserStore(t)
+	if _, err := users.Create("alice", "correct horse", storage.RoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+	_, h := newTestServer(t, users)
+
+	if rec := serve(h, testRequest{method: http.MethodGet, path: "/api/board"}); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("anonymous GET = %d", rec.Code)
+	}
+	if rec := serve(h, testRequest{method: http.MethodGet, path: "/api/health"}); rec.Code != http.StatusOK {
+		t.Fatalf("health = %d", rec.Code)
+	}
+
+	sess := login(t, h, "alice", "correct horse")
+	if rec := serve(h, sess.request(http.MethodGet, "/api/board", "")); rec.Code != http.StatusOK {
+		t.Fatalf("session GET = %d", rec.Code)
+	}
+	me := decode(t, serve(h, sess.request(http.MethodGet, "/api/auth/me", "")))
+	if me["username"] != "alice" || me["method"] != "session" || me["csrfToken"] != sess.csrf {
+		t.Fatalf("me = %v", me)
+	}
+
+	noCSRF := sess.request(http.MethodPost, "/api/auth/tokens", `{"name": "ci"}`)
+	noCSRF.headers = nil
+	if rec := serve(h, noCSRF); rec.Code != http.StatusForbidden {
+		t.Fatalf("POST without CSRF = %d", rec.Code)
+	}
+	badCSRF := sess.request(http.MethodPost, "/api/auth/tokens", `{"name": "ci"}`)
+	badCSRF.headers[csrfHeaderName] = "wrong"
+	if rec := serve(h, badCSRF); rec.Code != http.StatusForbidden {
+		t.Fatalf("POST with wrong CSRF = %d", rec.Code)
+	}
+	rec := serve(h, sess.request(http.MethodPost, "/api/auth/tokens", `{"name": "ci"}`))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create token = %d %s", rec.Code, rec.Body.String())
+	}
+	secret := decode(t, rec)["secret"].(string)
+
+	bearer := func(method, path, token string) testRequest {
+		return testRequest{method: method, path: path, headers: map[string]string{"Authorization": "Bearer " + token}}
+	}
+	me = decode(t, serve(h, bearer(http.MethodGet, "/api/auth/me", secret)))
+	if me["username"] != "alice" || me["method"] != "token" || me["csrfToken"] != nil {
+		t.Fatalf("token me = %v", me)
+	}
+	if rec := serve(h, bearer(http.MethodPost, "/api/auth/logout", secret)); rec.Code != http.StatusOK {
+		t.Fatalf("token POST without CSRF = %d", rec.Code)
+	}
+	if rec := serve(h, bearer(http.MethodGet, "/api/board", "nope")); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("bad token = %d", rec.Code)
+	}
+
+	if rec := serve(h, sess.request(http.MethodPost, "/api/auth/logout", "")); rec.Code != http.StatusOK {
+		t.Fatalf("logout = %d", rec.Code)
+	}
+	if rec := serve(h, sess.request(http.MethodGet, "/api/board", "")); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("GET after logout = %d", rec.Code)
+	}
+}
+
+func TestLoginLockout(t *testing.T) {
+	users := newUserStore(t)
+	if _, err := users.Create("alice", "correct horse", storage.RoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+	_, h := newTestServer(t, users)
+	attempt := func(password string) int {
+		return serve(h, testRequest{
+			method: http.MethodPost,
+			path:   "/api/auth/login",
+			body:   `{"username": "alice", "password": "` + password + `"}`,
+		}).Code
+	}
+	for i := 0; i < loginMaxFailures; i++ {
+		if code := attempt("wrong"); code != http.StatusUnauthorized {
+			t.Fatalf("failure %d = %d", i+1, code)
+		}
+	}
+	if code := attempt("correct horse"); code != http.StatusTooManyRequests {
+		t.Fatalf("login while locked out = %d", code)
+	}
+}
+
+func TestLastAdminGuard(t *testing.T) {
+	users := newUserStore(t)
+	if _, err := users.Create("alice", "correct horse", storage.RoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.Create("bob", "correct horse", storage.RoleViewer); err != nil {
+		t.Fatal(err)
+	}
+	_, h := newTestServer(t, users)
+	sess := login(t, h, "alice", "correct horse")
+
+	if rec := serve(h, sess.request(http.MethodPatch, "/api/users/alice", `{"role": "viewer"}`)); rec.Code != http.StatusBadRequest {
+		t.Fatalf("demote last admin = %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := serve(h, sess.request(http.MethodDelete, "/api/users/alice", "")); rec.Code != http.StatusBadRequest {
+		t.Fatalf("delete self = %d", rec.Code)
+	}
+	if rec := serve(h, sess.request(http.MethodPatch, "/api/users/bob", `{"role": "admin"}`)); rec.Code != http.StatusOK {
+		t.Fatalf("promote bob = %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := serve(h, sess.request(http.MethodPatch, "/api/users/alice", `{"role": "viewer"}`)); rec.Code != http.StatusOK {
+		t.Fatalf("demote alice with another admin = %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestPasswordChangeRevokesOtherSessions(t *testing.T) {
+	users := newUserStore(t)
+	if _, err := users.Create("alice", "correct horse", storage.RoleViewer); err != nil {
+		t.Fatal(err)
+	}
+	_, h := newTestServer(t, users)
+	first := login(t, h, "alice", "correct horse")
+	second := login(t, h, "alice", "correct horse")
+
+	rec := serve(h, first.request(http.MethodPost, "/api/auth/password", `{"currentPassword": "wrong", "newPassword": "battery staple"}`))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("wrong current password = %d", rec.Code)
+	}
+	rec = serve(h, first.request(http.MethodPost, "/api/auth/password", `{"currentPassword": "correct horse", "newPassword": "battery staple"}`))
+	if rec.Code != http.StatusOK || decode(t, rec)["revokedSessions"] != float64(1) {
+		t.Fatalf("change password = %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := serve(h, first.request(http.MethodGet, "/api/auth/me", "")); rec.Code != http.StatusOK {
+		t.Fatalf("current session = %d", rec.Code)
+	}
+	rec = serve(h, second.request(http.MethodGet, "/api/auth/me", ""))
+	if rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), "session expired") {
+		t.Fatalf("other session = %d %s", rec.Code, rec.Body.String())
+	}
+	login(t, h, "alice", "battery staple")
+}
@@ -0,0 +1,303 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"inframap/internal/model"
+	"inframap/internal/storage"
+)
+
+type boardRequest struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func (s *Server) handleBoards(w http.ResponseWriter, r *http.Request) {
+	if s.boards == nil {
+		http.Error(w, "board registry not available", http.StatusInternalServerError)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		items, err := s.boards.List()
+		if err != nil {
+			http.Error(w, "failed to read boards", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"items": items,
+		})
+	case http.MethodPost:
+		if !s.requireRole(w, r, storage.RoleEditor) {
+			return
+		}
+		req, ok := readBoardRequest(w, r)
+		if !ok {
+			return
+		}
+		info, err := s.createBoard(req, nil)
+		if err != nil {
+			writeBoardError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, info)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleBoardScoped(w http.ResponseWriter, r *http.Request) {
+	if s.boards == nil {
+		http.Error(w, "board registry not available", http.StatusInternalServerError)
+		return
+	}
+	rest := strings.TrimPrefix(r.URL.Path, "/api/boards/")
+	id, sub, _ := strings.Cut(rest, "/")
+	info, ok, err := s.boards.Get(id)
+	if err != nil {
+		http.Error(w, "failed to read boards", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "board not found", http.StatusNotFound)
+		return
+	}
+
+	switch sub {
+	case "":
+		s.handleBoardInfo(w, r, info)
+	case "clone":
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !s.requireRole(w, r, storage.RoleEditor) {
+			return
+		}
+		req, ok := readBoardRequest(w, r)
+		if !ok {
+			return
+		}
+		if req.Name == "" {
+			req.Name = info.Name + " (copy)"
+		}
+		clone, err := s.createBoard(req, &info)
+		if err != nil {
+			writeBoardError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, clone)
+	default:
+		if strings.HasPrefix(sub, "boards") || s.mux == nil {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		scoped := withBoard(r, info.ID)
+		scoped.URL.Path = "/api/" + sub
+		s.mux.ServeHTTP(w, scoped)
+	}
+}
+
+func (s *Server) handleBoardInfo(w http.ResponseWriter, r *http.Request, info storage.BoardInfo) {
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, info)
+	case http.MethodPatch, http.MethodPut:
+		if !s.requireRole(w, r, storage.RoleEditor) {
+			return
+		}
+		req, ok := readBoardRequest(w, r)
+		if !ok {
+			return
+		}
+		if req.Name == "" {
+			http.Error(w, "name is required", http.StatusBadRequest)
+			return
+		}
+		renamed, err := s.boards.Rename(info.ID, req.Name)
+		if err != nil {
+			writeBoardError(w, err)
+			return
+		}
+		s.log("info", "board", fmt.Sprintf("board %s renamed to %q", info.ID, renamed.Name))
+		s.publish("boards", map[string]any{"action": "renamed", "board": renamed})
+		writeJSON(w, http.StatusOK, renamed)
+	case http.MethodDelete:
+		if !s.requireRole(w, r, storage.RoleAdmin) {
+			return
+		}
+		if info.ID == storage.DefaultBoardID {
+			http.Error(w, "the default board cannot be deleted", http.StatusBadRequest)
+			return
+		}
+		s.boardsMu.Lock()
+		err := s.boards.Delete(info.ID)
+		if err == nil {
+			s.closeWorkspace(info.ID)
+		}
+		s.boardsMu.Unlock()
+		if err != nil {
+			writeBoardError(w, err)
+			return
+		}
+		if s.secrets != nil {
+			if err := s.secrets.DeleteScope(info.ID); err != nil {
+				s.log("warn", "settings", fmt.Sprintf("failed to delete device settings for board %s: %v", info.ID, err))
+			}
+		}
+		if s.knownHosts != nil {
+			if err := s.knownHosts.DeleteScope(info.ID); err != nil {
+				s.log("warn", "ssh", fmt.Sprintf("failed to delete host keys for board %s: %v", info.ID, err))
+			}
+		}
+		if s.facts != nil {
+			if err := s.facts.DeleteScope(info.ID); err != nil {
+				s.log("warn", "facts", fmt.Sprintf("failed to delete facts for board %s: %v", info.ID, err))
+			}
+		}
+		if s.alerts != nil {
+			if err := s.alerts.DeleteScope(info.ID); err != nil {
+				s.log("warn", "alerts", fmt.Sprintf("failed to delete alerts for board %s: %v", info.ID, err))
+			}
+		}
+		if s.channels != nil {
+			if err := s.channels.DeleteScope(info.ID); err != nil {
+				s.log("warn", "notify", fmt.Sprintf("failed to delete notification channels for board %s: %v", info.ID, err))
+			}
+		}
+		if s.metrics != nil {
+			if err := s.metrics.DeleteScope(info.ID); err != nil {
+				s.log("warn", "metrics", fmt.Sprintf("failed to delete metrics for board %s: %v", info.ID, err))
+			}
+		}
+		s.log("info", "board", fmt.Sprintf("board %s deleted", info.ID))
+		s.publish("boards", map[string]any{"action": "deleted", "board": info})
+		writeJSON(w, http.StatusOK, map[string]string{
+			"status": "deleted",
+		})
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) createBoard(req boardRequest, source *storage.BoardInfo) (storage.BoardInfo, error) {
+	if req.ID == "" {
+		req.ID = storage.SlugifyBoardName(req.Name)
+	}
+	if req.Name == "" {
+		req.Name = req.ID
+	}
+	info, err := s.boards.Create(req.ID, req.Name)
+	if err != nil {
+		return storage.BoardInfo{}, err
+	}
+
+	ws := s.workspace(info.ID)
+	action := "created"
+	if source != nil {
+		action = "cloned"
+		if err := s.cloneBoardFile(s.workspace(source.ID), ws, info.Name); err != nil {
+			s.closeWorkspace(info.ID)
+			_ = s.boards.Delete(info.ID)
+			return storage.BoardInfo{}, err
+		}
+		if s.secrets != nil {
+			if err := s.secrets.CopyScope(source.ID, info.ID); err != nil {
+				s.log("warn", "settings", fmt.Sprintf("failed to copy device settings from %s to %s: %v", source.ID, info.ID, err))
+			}
+		}
+		if s.knownHosts != nil {
+			if err := s.knownHosts.CopyScope(source.ID, info.ID); err != nil {
+				s.log("warn", "ssh", fmt.Sprintf("failed to copy host keys from %s to %s: %v", source.ID, info.ID, err))
+			}
+		}
+		if s.facts != nil {
+			if err := s.facts.CopyScope(source.ID, info.ID); err != nil {
+				s.log("warn", "facts", fmt.Sprintf("failed to copy facts from %s to %s: %v", source.ID, info.ID, err))
+			}
+		}
+		if s.alerts != nil {
+			if err := s.alerts.CopyScope(source.ID, info.ID); err != nil {
+				s.log("warn", "alerts", fmt.Sprintf("failed to copy alert rules from %s to %s: %v", source.ID, info.ID, err))
+			}
+		}
+		if s.channels != nil {
+			if err := s.channels.CopyScope(source.ID, info.ID); err != nil {
+				s.log("warn", "notify", fmt.Sprintf("failed to copy notification channels from %s to %s: %v", source.ID, info.ID, err))
+			}
+		}
+	}
+	if err := s.bootstrapWorkspace(ws); err != nil {
+		s.log("warn", "board", fmt.Sprintf("failed to prepare board %s: %v", info.ID, err))
+	}
+	s.log("info", "board", fmt.Sprintf("board %s %s", info.ID, action))
+	s.publish("boards", map[string]any{"action": action, "board": info})
+	return info, nil
+}
+
+func (s *Server) cloneBoardFile(src, dst *workspace, name string) error {
+	src.mu.Lock()
+	data, err := os.ReadFile(src.boardFile)
+	src.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to read source board: %w", err)
+	}
+	var board model.Board
+	if err := json.Unmarshal(data, &board); err != nil {
+		return fmt.Errorf("failed to parse source board: %w", err)
+	}
+	board.Meta.Name = name
+	board.Meta.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	payload, err := json.MarshalIndent(board, "", "  ")
+	if err != nil {
+		return err
+	}
+	dst.mu.Lock()
+	defer dst.mu.Unlock()
+	return os.WriteFile(dst.boardFile, payload, 0o644)
+}
+
+func readBoardRequest(w http.ResponseWriter, r *http.Request) (boardRequest, bool) {
+	var req boardRequest
+	body, err := io.ReadAll(io.LimitReader(r.Body, 64<<10))
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return req, false
+	}
+	if len(strings.TrimSpace(string(body))) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return req, false
+		}
+	}
+	req.ID = strings.ToLower(strings.TrimSpace(req.ID))
+	req.Name = strings.TrimSpace(req.Name)
+	return req, true
+}
+
+func writeBoardError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, storage.ErrInvalidBoard):
+		http.Error(w, "invalid board id (use a-z, 0-9, '-' or '_', max 48 chars)", http.StatusBadRequest)
+	case errors.Is(err, storage.ErrBoardExists):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, storage.ErrBoardNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (s *Server) log(level, source, message string) {
+	if s.logs == nil {
+		return
+	}
+	s.logs.Add(level, source, message)
+}
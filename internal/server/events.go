@@ -0,0 +1,108 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"inframap/internal/events"
+)
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.events == nil {
+		http.Error(w, "event stream not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	lastID := parseLastEventID(r)
+	ch, backlog, complete := s.events.Subscribe(lastID)
+	defer s.events.Unsubscribe(ch)
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := fmt.Fprint(w, "retry: 3000\n\n"); err != nil {
+		return
+	}
+	if !complete {
+		if err := writeEvent(w, events.Event{Type: "reset", Data: []byte(`{}`)}); err != nil {
+			return
+		}
+	}
+	for _, event := range backlog {
+		if err := writeEvent(w, event); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	keepalive := time.NewTicker(25 * time.Second)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-ch:
+			if !ok {
+				return
+			}
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+		case <-keepalive.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event events.Event) error {
+	var b strings.Builder
+	if event.ID > 0 {
+		b.WriteString("id: " + strconv.FormatUint(event.ID, 10) + "\n")
+	}
+	b.WriteString("event: " + event.Type + "\n")
+	b.WriteString("data: ")
+	b.Write(event.Data)
+	b.WriteString("\n\n")
+	_, err := fmt.Fprint(w, b.String())
+	return err
+}
+
+func parseLastEventID(r *http.Request) uint64 {
+	raw := strings.TrimSpace(r.Header.Get("Last-Event-ID"))
+	if raw == "" {
+		raw = strings.TrimSpace(r.URL.Query().Get("lastEventId"))
+	}
+	if raw == "" {
+		return 0
+	}
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return id
+}
+
+func (s *Server) publish(kind string, payload any) {
+	if s.events == nil {
+		return
+	}
+	s.events.Publish(kind, payload)
+}
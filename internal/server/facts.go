@@ -0,0 +1,115 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"inframap/internal/model"
+	"inframap/internal/monitoring"
+	"inframap/internal/sshutil"
+	"inframap/internal/storage"
+)
+
+func (s *Server) handleProbes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	items := []map[string]any{}
+	for _, probe := range sshutil.Probes() {
+		items = append(items, map[string]any{
+			"name":  probe.Name,
+			"field": probe.Field,
+			"os":    probe.OS,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"items": items,
+	})
+}
+
+func (s *Server) handleNode(w http.ResponseWriter, r *http.Request) {
+	id, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/nodes/"), "/")
+	if id == "" {
+		http.Error(w, "missing node id", http.StatusBadRequest)
+		return
+	}
+	if action != "facts" {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	ws, ok := s.workspaceFor(w, r)
+	if !ok {
+		return
+	}
+	if ws.facts == nil || ws.factStore == nil {
+		http.Error(w, "facts store not available", http.StatusInternalServerError)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		facts, _, err := ws.factStore.Get(id)
+		if err != nil {
+			http.Error(w, "failed to read facts", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, factsView(ws, id, facts))
+	case http.MethodPost:
+		if !s.requireRole(w, r, storage.RoleOperator) {
+			return
+		}
+		var names []string
+		for _, name := range strings.Split(r.URL.Query().Get("probes"), ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if _, ok := sshutil.LookupProbe(name); !ok {
+				http.Error(w, fmt.Sprintf("unknown probe %q", name), http.StatusBadRequest)
+				return
+			}
+			names = append(names, name)
+		}
+		facts, err := ws.facts.Collect(r.Context(), id, names)
+		if errors.Is(err, monitoring.ErrFactsUnavailable) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			s.log("warn", "facts", fmt.Sprintf("fact collection failed for %s: %v", id, err))
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		writeJSON(w, http.StatusOK, factsView(ws, id, facts))
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func factsView(ws *workspace, node string, facts model.NodeFacts) map[string]any {
+	values := make(map[string]json.RawMessage)
+	for _, fact := range facts.Probes {
+		if len(fact.Value) > 0 {
+			values[fact.Field] = fact.Value
+		}
+	}
+	probes := facts.Probes
+	if probes == nil {
+		probes = map[string]model.Fact{}
+	}
+	view := map[string]any{
+		"node":      node,
+		"values":    values,
+		"probes":    probes,
+		"updatedAt": facts.UpdatedAt,
+	}
+	if next, ok := ws.facts.NextRun(node); ok {
+		view["nextRun"] = next.UTC().Format(time.RFC3339)
+	}
+	return view
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,6 +12,7 @@ import (
 
 	"inframap/internal/model"
 	"inframap/internal/sshutil"
+	"inframap/internal/storage"
 )
 
 func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
@@ -21,11 +23,18 @@ func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
 }
 
 func (s *Server) handleBoard(w http.ResponseWriter, r *http.Request) {
+	ws, ok := s.workspaceFor(w, r)
+	if !ok {
+		return
+	}
 	switch r.Method {
 	case http.MethodGet:
-		s.serveBoard(w)
+		s.serveBoard(w, ws)
 	case http.MethodPost:
-		s.saveBoard(w, r)
+		if !s.requireRole(w, r, storage.RoleEditor) {
+			return
+		}
+		s.saveBoard(w, r, ws)
 	default:
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 	}
@@ -36,9 +45,13 @@ func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	ws, ok := s.workspaceFor(w, r)
+	if !ok {
+		return
+	}
 	results := map[string]model.PingResult{}
-	if s.ping != nil {
-		results = s.ping.GetStatus()
+	if ws.ping != nil {
+		results = ws.ping.GetStatus()
 	}
 	writeJSON(w, http.StatusOK, map[string]any{
 		"updatedAt": time.Now().UTC().Format(time.RFC3339),
@@ -51,9 +64,32 @@ func (s *Server) handleSSHStatus(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	ws, ok := s.workspaceFor(w, r)
+	if !ok {
+		return
+	}
 	results := map[string]model.SSHStatus{}
-	if s.ssh != nil {
-		results = s.ssh.GetStatus()
+	if ws.ssh != nil {
+		results = ws.ssh.GetStatus()
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"updatedAt": time.Now().UTC().Format(time.RFC3339),
+		"results":   results,
+	})
+}
+
+func (s *Server) handleChecks(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ws, ok := s.workspaceFor(w, r)
+	if !ok {
+		return
+	}
+	results := map[string]map[string]model.CheckResult{}
+	if ws.checks != nil {
+		results = ws.checks.GetStatus()
 	}
 	writeJSON(w, http.StatusOK, map[string]any{
 		"updatedAt": time.Now().UTC().Format(time.RFC3339),
@@ -84,14 +120,21 @@ func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleMonitoring(w http.ResponseWriter, r *http.Request) {
+	ws, ok := s.workspaceFor(w, r)
+	if !ok {
+		return
+	}
 	switch r.Method {
 	case http.MethodGet:
 		settings := model.MonitoringSettings{}
-		if s.ping != nil {
-			settings = s.ping.GetSettings()
+		if ws.ping != nil {
+			settings = ws.ping.GetSettings()
 		}
 		writeJSON(w, http.StatusOK, settings)
 	case http.MethodPost:
+		if !s.requireRole(w, r, storage.RoleEditor) {
+			return
+		}
 		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
 		if err != nil {
 			http.Error(w, "failed to read request body", http.StatusBadRequest)
@@ -102,8 +145,8 @@ func (s *Server) handleMonitoring(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "invalid json", http.StatusBadRequest)
 			return
 		}
-		if s.ping != nil {
-			s.ping.SetSettings(settings)
+		if ws.ping != nil {
+			ws.ping.SetSettings(settings)
 		}
 		writeJSON(w, http.StatusOK, settings)
 	default:
@@ -116,6 +159,13 @@ func (s *Server) handleMonitoringNodes(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	if !s.requireRole(w, r, storage.RoleEditor) {
+		return
+	}
+	ws, ok := s.workspaceFor(w, r)
+	if !ok {
+		return
+	}
 	body, err := io.ReadAll(io.LimitReader(r.Body, 2<<20))
 	if err != nil {
 		http.Error(w, "failed to read request body", http.StatusBadRequest)
@@ -128,11 +178,20 @@ func (s *Server) handleMonitoringNodes(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid json", http.StatusBadRequest)
 		return
 	}
-	if s.ping != nil {
-		s.ping.UpdateNodes(payload.Nodes)
+	if ws.ping != nil {
+		ws.ping.UpdateNodes(payload.Nodes)
+	}
+	if ws.ssh != nil {
+		ws.ssh.UpdateNodes(payload.Nodes)
+	}
+	if ws.checks != nil {
+		ws.checks.UpdateNodes(payload.Nodes)
+	}
+	if ws.facts != nil {
+		ws.facts.UpdateNodes(payload.Nodes)
 	}
-	if s.ssh != nil {
-		s.ssh.UpdateNodes(payload.Nodes)
+	if ws.alerts != nil {
+		ws.alerts.UpdateNodes(payload.Nodes)
 	}
 	writeJSON(w, http.StatusOK, map[string]string{
 		"status": "ok",
@@ -140,73 +199,42 @@ func (s *Server) handleMonitoringNodes(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleDeviceSettings(w http.ResponseWriter, r *http.Request) {
-	id := strings.TrimPrefix(r.URL.Path, "/api/device-settings/")
-	if id == "" || id == "/" {
+	id, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/device-settings/"), "/")
+	if id == "" {
 		http.Error(w, "missing device id", http.StatusBadRequest)
 		return
 	}
-	if s.secrets == nil {
+	ws, ok := s.workspaceFor(w, r)
+	if !ok {
+		return
+	}
+	if ws.secrets == nil {
 		http.Error(w, "secrets store not available", http.StatusInternalServerError)
 		return
 	}
-	detect := r.URL.Query().Get("detect") == "1"
-	forceDetect := r.URL.Query().Get("force") == "1"
-
+	if action == "credentials" {
+		s.clearDeviceCredentials(w, r, ws, id)
+		return
+	} else if action != "" {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
 	switch r.Method {
 	case http.MethodGet:
-		var tailscaleIP string
-		settings, ok, err := s.secrets.Get(id)
+		settings, ok, err := ws.secrets.Get(id)
 		if err != nil {
 			http.Error(w, "failed to read device settings", http.StatusInternalServerError)
 			return
 		}
 		if !ok {
-			writeJSON(w, http.StatusOK, map[string]any{
-				"exists":   false,
-				"settings": model.DeviceSettings{},
-			})
+			writeJSON(w, http.StatusOK, deviceSettingsView(model.DeviceSettings{}, false, nil))
 			return
 		}
-		if detect && settings.ConnectEnabled {
-			if settings.OS == "linux" || settings.OS == "windows" {
-				if settings.LinkSpeedMbps == 0 || forceDetect {
-					if s.logs != nil {
-						s.logs.Add("info", "ssh", fmt.Sprintf("auto-detect link speed for %s", id))
-					}
-					speed, iface, detErr := sshutil.DetectLinkSpeed(settings, 8*time.Second)
-					if detErr != nil {
-						if s.logs != nil {
-							s.logs.Add("warn", "ssh", fmt.Sprintf("link speed detect failed for %s: %v", id, detErr))
-						}
-					} else if speed > 0 {
-						settings.LinkSpeedMbps = speed
-						if s.logs != nil {
-							s.logs.Add("info", "ssh", fmt.Sprintf("link speed %d Mbps detected for %s (%s)", speed, id, iface))
-						}
-						_ = s.secrets.Set(id, settings)
-					}
-				}
-				tsIP, tsErr := sshutil.DetectTailscaleIP(settings, 6*time.Second)
-				if tsErr != nil {
-					if s.logs != nil {
-						s.logs.Add("warn", "ssh", fmt.Sprintf("tailscale ip detect failed for %s: %v", id, tsErr))
-					}
-				} else if tsIP != "" {
-					tailscaleIP = tsIP
-					if s.logs != nil {
-						s.logs.Add("info", "ssh", fmt.Sprintf("tailscale ip %s detected for %s", tsIP, id))
-					}
-				}
-			} else if s.logs != nil {
-				s.logs.Add("info", "ssh", fmt.Sprintf("link speed detection skipped for %s (os=%s)", id, settings.OS))
-			}
-		}
-		writeJSON(w, http.StatusOK, map[string]any{
-			"exists":      true,
-			"settings":    settings,
-			"tailscaleIp": tailscaleIP,
-		})
+		writeJSON(w, http.StatusOK, deviceSettingsView(settings, true, nil))
 	case http.MethodPost:
+		if !s.requireRole(w, r, storage.RoleAdmin) {
+			return
+		}
 		body, err := io.ReadAll(io.LimitReader(r.Body, 2<<20))
 		if err != nil {
 			http.Error(w, "failed to read request body", http.StatusBadRequest)
@@ -217,33 +245,52 @@ func (s *Server) handleDeviceSettings(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, "invalid json", http.StatusBadRequest)
 			return
 		}
-		prevSettings, prevExists, prevErr := s.secrets.Get(id)
+		prevSettings, prevExists, prevErr := ws.secrets.Get(id)
 		if prevErr != nil && s.logs != nil {
 			s.logs.Add("warn", "settings", fmt.Sprintf("failed to read previous settings for %s: %v", id, prevErr))
 		}
-		settings = sanitizeDeviceSettings(settings)
+		if prevExists {
+			settings = keepStoredCredentials(settings, prevSettings)
+			s.dropSSH(ws, id)
+		}
+		settings, err = sanitizeDeviceSettings(settings)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(settings.JumpHosts) > 5 {
+			http.Error(w, "at most 5 jump hosts are supported", http.StatusBadRequest)
+			return
+		}
+		for _, jump := range settings.JumpHosts {
+			if jump.Device == id {
+				http.Error(w, "a device cannot be its own jump host", http.StatusBadRequest)
+				return
+			}
+		}
 		if s.logs != nil {
 			s.logs.Add("info", "settings", fmt.Sprintf("settings received for %s (connect=%t os=%s host=%s)", id, settings.ConnectEnabled, settings.OS, settings.Host))
 		}
+		if err := ws.secrets.Set(id, settings); err != nil {
+			http.Error(w, "failed to save device settings", http.StatusInternalServerError)
+			return
+		}
+		if ws.facts != nil && settings.ConnectEnabled {
+			ws.facts.Refresh(id)
+		}
+		extra := map[string]any{
+			"status": "saved",
+		}
 		if settings.ConnectEnabled && (settings.OS == "linux" || settings.OS == "windows") {
-			speed, iface, err := sshutil.DetectLinkSpeed(settings, 8*time.Second)
+			job, err := s.startJob(ws, jobDetect, []string{id}, requestAuthor(r), true)
 			if err != nil {
-				if s.logs != nil {
-					s.logs.Add("warn", "ssh", fmt.Sprintf("link speed detect failed for %s: %v", id, err))
-				}
-			} else if speed > 0 {
-				settings.LinkSpeedMbps = speed
-				if s.logs != nil {
-					s.logs.Add("info", "ssh", fmt.Sprintf("link speed %d Mbps detected for %s (%s)", speed, id, iface))
-				}
+				s.log("warn", "ssh", fmt.Sprintf("failed to start detection for %s: %v", id, err))
+			} else {
+				extra["job"] = job.ID
 			}
 		} else if settings.ConnectEnabled && s.logs != nil {
 			s.logs.Add("info", "ssh", fmt.Sprintf("link speed detection skipped for %s (os=%s)", id, settings.OS))
 		}
-		if err := s.secrets.Set(id, settings); err != nil {
-			http.Error(w, "failed to save device settings", http.StatusInternalServerError)
-			return
-		}
 		if s.logs != nil {
 			if prevExists {
 				if prevSettings.ConnectEnabled != settings.ConnectEnabled {
@@ -272,15 +319,21 @@ func (s *Server) handleDeviceSettings(w http.ResponseWriter, r *http.Request) {
 				s.logs.Add("info", "ssh", fmt.Sprintf("SSH settings saved for %s (host=%s port=%d user=%s)", id, host, port, user))
 			}
 		}
-		writeJSON(w, http.StatusOK, map[string]any{
-			"status":   "saved",
-			"settings": settings,
-		})
+		writeJSON(w, http.StatusOK, deviceSettingsView(settings, true, extra))
 	case http.MethodDelete:
-		if err := s.secrets.Delete(id); err != nil {
+		if !s.requireRole(w, r, storage.RoleAdmin) {
+			return
+		}
+		if err := ws.secrets.Delete(id); err != nil {
 			http.Error(w, "failed to delete device settings", http.StatusInternalServerError)
 			return
 		}
+		s.dropSSH(ws, id)
+		if ws.knownHosts != nil {
+			if err := ws.knownHosts.Reset(id); err != nil {
+				s.log("warn", "ssh", fmt.Sprintf("failed to remove host key for %s: %v", id, err))
+			}
+		}
 		writeJSON(w, http.StatusOK, map[string]string{
 			"status": "deleted",
 		})
@@ -289,7 +342,97 @@ func (s *Server) handleDeviceSettings(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func sanitizeDeviceSettings(settings model.DeviceSettings) model.DeviceSettings {
+func (s *Server) clearDeviceCredentials(w http.ResponseWriter, r *http.Request, ws *workspace, id string) {
+	if r.Method != http.MethodDelete {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if !s.requireRole(w, r, storage.RoleAdmin) {
+		return
+	}
+	settings, ok, err := ws.secrets.Get(id)
+	if err != nil {
+		http.Error(w, "failed to read device settings", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "device settings not found", http.StatusNotFound)
+		return
+	}
+	field := r.URL.Query().Get("field")
+	switch field {
+	case "":
+		settings.Password = ""
+		settings.PrivateKey = ""
+		settings.PrivateKeyPassphrase = ""
+		field = "all credentials"
+	case "password":
+		settings.Password = ""
+	case "privateKey":
+		settings.PrivateKey = ""
+		settings.PrivateKeyPassphrase = ""
+	case "privateKeyPassphrase":
+		settings.PrivateKeyPassphrase = ""
+	default:
+		http.Error(w, "unknown credential field (use password, privateKey or privateKeyPassphrase)", http.StatusBadRequest)
+		return
+	}
+	if err := ws.secrets.Set(id, settings); err != nil {
+		http.Error(w, "failed to save device settings", http.StatusInternalServerError)
+		return
+	}
+	s.dropSSH(ws, id)
+	s.log("info", "settings", fmt.Sprintf("%s cleared for %s by %s", field, id, requestAuthor(r)))
+	writeJSON(w, http.StatusOK, deviceSettingsView(settings, true, map[string]any{
+		"status": "cleared",
+	}))
+}
+
+func deviceSettingsView(settings model.DeviceSettings, exists bool, extra map[string]any) map[string]any {
+	view := map[string]any{
+		"exists":        exists,
+		"hasPassword":   settings.Password != "",
+		"hasPrivateKey": settings.PrivateKey != "",
+		"hasPassphrase": settings.PrivateKeyPassphrase != "",
+	}
+	if settings.PrivateKey != "" {
+		if fingerprint, err := sshutil.KeyFingerprint(settings); err == nil {
+			view["keyFingerprint"] = fingerprint
+		} else {
+			view["keyError"] = err.Error()
+		}
+	}
+	if settings.Certificate != "" {
+		if info, err := sshutil.DescribeCertificate(settings.Certificate); err == nil {
+			view["certificateInfo"] = info
+		} else {
+			view["certificateError"] = err.Error()
+		}
+	}
+	settings.Password = ""
+	settings.PrivateKey = ""
+	settings.PrivateKeyPassphrase = ""
+	view["settings"] = settings
+	for key, value := range extra {
+		view[key] = value
+	}
+	return view
+}
+
+func keepStoredCredentials(settings, stored model.DeviceSettings) model.DeviceSettings {
+	if settings.Password == "" {
+		settings.Password = stored.Password
+	}
+	if strings.TrimSpace(settings.PrivateKey) == "" {
+		settings.PrivateKey = stored.PrivateKey
+		if settings.PrivateKeyPassphrase == "" {
+			settings.PrivateKeyPassphrase = stored.PrivateKeyPassphrase
+		}
+	}
+	return settings
+}
+
+func sanitizeDeviceSettings(settings model.DeviceSettings) (model.DeviceSettings, error) {
 	settings.OS = strings.ToLower(strings.TrimSpace(settings.OS))
 	if settings.OS == "" {
 		settings.OS = "linux"
@@ -300,18 +443,68 @@ func sanitizeDeviceSettings(settings model.DeviceSettings) model.DeviceSettings
 	}
 	settings.AuthMethod = strings.ToLower(strings.TrimSpace(settings.AuthMethod))
 	if settings.AuthMethod == "" {
-		settings.AuthMethod = "password"
+		settings.AuthMethod = sshutil.AuthPassword
+	}
+	if !sshutil.ValidAuthMethod(settings.AuthMethod) {
+		return settings, fmt.Errorf("unsupported auth method %q (use password, ssh_key, agent, certificate or keyboard-interactive)", settings.AuthMethod)
 	}
 	if settings.LinkSpeedMbps < 0 {
 		settings.LinkSpeedMbps = 0
 	}
-	if settings.AuthMethod == "password" {
+	settings.Certificate = strings.TrimSpace(settings.Certificate)
+	switch settings.AuthMethod {
+	case sshutil.AuthPassword, sshutil.AuthKeyboardInteractive:
 		settings.PrivateKey = ""
 		settings.PrivateKeyPassphrase = ""
-	}
-	if settings.AuthMethod == "ssh_key" {
+		settings.Certificate = ""
+	case sshutil.AuthKey:
 		settings.Password = ""
+		settings.Certificate = ""
+	case sshutil.AuthCertificate:
+		settings.Password = ""
+		if strings.TrimSpace(settings.PrivateKey) == "" {
+			return settings, errors.New("certificate auth needs the private key the certificate was issued for")
+		}
+		if err := sshutil.CheckCertificate(settings); err != nil {
+			return settings, err
+		}
+	case sshutil.AuthAgent:
+		settings.Password = ""
+		settings.PrivateKey = ""
+		settings.PrivateKeyPassphrase = ""
+		settings.Certificate = ""
+		if settings.ConnectEnabled {
+			if err := sshutil.AgentAvailable(); err != nil {
+				return settings, err
+			}
+		}
 	}
 	settings.Username = strings.TrimSpace(settings.Username)
-	return settings
+	jumps := settings.JumpHosts[:0]
+	for _, jump := range settings.JumpHosts {
+		jump.Device = strings.TrimSpace(jump.Device)
+		jump.Host = strings.TrimSpace(jump.Host)
+		jump.Username = strings.TrimSpace(jump.Username)
+		if jump.Device != "" {
+			jump.Host, jump.Port, jump.Username = "", 0, ""
+		} else if jump.Host == "" {
+			continue
+		} else if jump.Port <= 0 || jump.Port > 65535 {
+			jump.Port = 22
+		}
+		jumps = append(jumps, jump)
+	}
+	settings.JumpHosts = jumps
+	if len(settings.JumpHosts) == 0 {
+		settings.JumpHosts = nil
+	}
+	for _, jump := range settings.JumpHosts {
+		if jump.Device == "" && settings.ConnectEnabled {
+			if err := sshutil.AgentAvailable(); err != nil {
+				return settings, fmt.Errorf("inline jump host %s authenticates with the SSH agent: %w; reference a device to use a password or key", jump.Host, err)
+			}
+			break
+		}
+	}
+	return settings, nil
 }
@@ -0,0 +1,226 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"inframap/internal/jobs"
+	"inframap/internal/model"
+	"inframap/internal/monitoring"
+	"inframap/internal/storage"
+)
+
+const (
+	jobDetect = "detect"
+	jobFacts  = "facts"
+
+	maxJobNodes = 500
+)
+
+type jobRequest struct {
+	Type  string   `json:"type"`
+	Nodes []string `json:"nodes"`
+	Force bool     `json:"force"`
+}
+
+type detectResult struct {
+	LinkSpeedMbps  int    `json:"linkSpeedMbps,omitempty"`
+	Interface      string `json:"interface,omitempty"`
+	LinkSpeedError string `json:"linkSpeedError,omitempty"`
+	TailscaleIP    string `json:"tailscaleIp,omitempty"`
+	TailscaleError string `json:"tailscaleError,omitempty"`
+}
+
+func (s *Server) handleJobs(w http.ResponseWriter, r *http.Request) {
+	ws, ok := s.workspaceFor(w, r)
+	if !ok {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]any{
+			"items": s.jobs.List(ws.id),
+		})
+	case http.MethodPost:
+		if !s.requireRole(w, r, storage.RoleOperator) {
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err != nil {
+			http.Error(w, "failed to read request body", http.StatusBadRequest)
+			return
+		}
+		var req jobRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, "invalid json", http.StatusBadRequest)
+			return
+		}
+		nodes, err := normalizeJobNodes(req.Nodes)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		job, err := s.startJob(ws, req.Type, nodes, requestAuthor(r), req.Force)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Location", "/api/jobs/"+job.ID)
+		writeJSON(w, http.StatusAccepted, job)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleJob(w http.ResponseWriter, r *http.Request) {
+	id, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/jobs/"), "/")
+	if id == "" {
+		http.Error(w, "missing job id", http.StatusBadRequest)
+		return
+	}
+	ws, ok := s.workspaceFor(w, r)
+	if !ok {
+		return
+	}
+	job, ok := s.jobs.Get(id)
+	if !ok || job.Board != ws.id {
+		http.Error(w, "job not found", http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, job)
+	case action == "cancel" && r.Method == http.MethodPost:
+		if !s.requireRole(w, r, storage.RoleOperator) {
+			return
+		}
+		job, err := s.jobs.Cancel(id)
+		if errors.Is(err, jobs.ErrJobFinished) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		s.log("info", "jobs", fmt.Sprintf("%s job %s cancelled by %s", job.Type, job.ID, requestAuthor(r)))
+		writeJSON(w, http.StatusOK, job)
+	case action == "" || action == "cancel":
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+func normalizeJobNodes(nodes []string) ([]string, error) {
+	seen := make(map[string]bool, len(nodes))
+	out := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		node = strings.TrimSpace(node)
+		if node == "" || seen[node] {
+			continue
+		}
+		seen[node] = true
+		out = append(out, node)
+	}
+	if len(out) == 0 {
+		return nil, errors.New("at least one node is required")
+	}
+	if len(out) > maxJobNodes {
+		return nil, fmt.Errorf("at most %d nodes per job", maxJobNodes)
+	}
+	return out, nil
+}
+
+func (s *Server) startJob(ws *workspace, kind string, nodes []string, author string, force bool) (jobs.Job, error) {
+	var run jobs.Runner
+	switch kind {
+	case jobDetect:
+		if ws.secrets == nil {
+			return jobs.Job{}, errors.New("secrets store not available")
+		}
+		run = func(ctx context.Context, node string) (any, error) {
+			settings, ok, err := ws.secrets.Get(node)
+			if err != nil {
+				return nil, err
+			}
+			if !ok || !settings.ConnectEnabled {
+				return nil, monitoring.ErrFactsUnavailable
+			}
+			if settings.OS != "linux" && settings.OS != "windows" {
+				return nil, fmt.Errorf("detection is not supported for os %q", settings.OS)
+			}
+			_, result := s.detectDevice(ctx, ws, node, settings, force)
+			if result.LinkSpeedError != "" && result.TailscaleError != "" {
+				return result, errors.New(result.LinkSpeedError)
+			}
+			return result, nil
+		}
+	case jobFacts:
+		if ws.facts == nil {
+			return jobs.Job{}, errors.New("facts collection not available")
+		}
+		run = func(ctx context.Context, node string) (any, error) {
+			facts, err := ws.facts.Collect(ctx, node, nil)
+			if err != nil {
+				return nil, err
+			}
+			return factsView(ws, node, facts), nil
+		}
+	default:
+		return jobs.Job{}, fmt.Errorf("unknown job type %q (use %s or %s)", kind, jobDetect, jobFacts)
+	}
+	job, err := s.jobs.Start(kind, ws.id, author, nodes, run)
+	if err != nil {
+		return jobs.Job{}, err
+	}
+	s.log("info", "jobs", fmt.Sprintf("%s job %s started for %d node(s) by %s", kind, job.ID, len(nodes), author))
+	return job, nil
+}
+
+func (s *Server) detectDevice(ctx context.Context, ws *workspace, id string, settings model.DeviceSettings, force bool) (model.DeviceSettings, detectResult) {
+	var result detectResult
+	if settings.LinkSpeedMbps == 0 || force {
+		s.log("info", "ssh", fmt.Sprintf("auto-detect link speed for %s", id))
+		speed, iface, err := s.detectLinkSpeed(ctx, ws, id, settings)
+		result.Interface = iface
+		if ctx.Err() != nil {
+			result.LinkSpeedError = ctx.Err().Error()
+			result.TailscaleError = ctx.Err().Error()
+			return settings, result
+		}
+		if err != nil {
+			result.LinkSpeedError = err.Error()
+			s.log("warn", "ssh", fmt.Sprintf("link speed detect failed for %s: %v", id, err))
+		} else if speed > 0 {
+			result.LinkSpeedMbps = speed
+			s.log("info", "ssh", fmt.Sprintf("link speed %d Mbps detected for %s (%s)", speed, id, iface))
+			if current, ok, err := ws.secrets.Get(id); err == nil && ok {
+				current.LinkSpeedMbps = speed
+				_ = ws.secrets.Set(id, current)
+			}
+			settings.LinkSpeedMbps = speed
+		}
+	} else {
+		result.LinkSpeedMbps = settings.LinkSpeedMbps
+	}
+	if ctx.Err() != nil {
+		result.TailscaleError = ctx.Err().Error()
+		return settings, result
+	}
+	ip, err := s.detectTailscaleIP(ctx, ws, id, settings)
+	if err != nil {
+		result.TailscaleError = err.Error()
+		s.log("warn", "ssh", fmt.Sprintf("tailscale ip detect failed for %s: %v", id, err))
+	} else if ip != "" {
+		result.TailscaleIP = ip
+		s.log("info", "ssh", fmt.Sprintf("tailscale ip %s detected for %s", ip, id))
+	}
+	return settings, result
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"inframap/internal/storage"
+)
+
+func (s *Server) handleKnownHosts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ws, ok := s.workspaceFor(w, r)
+	if !ok {
+		return
+	}
+	if ws.knownHosts == nil {
+		http.Error(w, "known hosts store not available", http.StatusInternalServerError)
+		return
+	}
+	items, err := ws.knownHosts.List()
+	if err != nil {
+		http.Error(w, "failed to read known hosts", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"items": items,
+	})
+}
+
+func (s *Server) handleKnownHost(w http.ResponseWriter, r *http.Request) {
+	id, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/known-hosts/"), "/")
+	if id == "" {
+		http.Error(w, "missing device id", http.StatusBadRequest)
+		return
+	}
+	ws, ok := s.workspaceFor(w, r)
+	if !ok {
+		return
+	}
+	if ws.knownHosts == nil {
+		http.Error(w, "known hosts store not available", http.StatusInternalServerError)
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		entry, _, err := ws.knownHosts.Get(id)
+		if err != nil {
+			http.Error(w, "failed to read known hosts", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, entry)
+	case action == "" && r.Method == http.MethodDelete:
+		if !s.requireRole(w, r, storage.RoleAdmin) {
+			return
+		}
+		if err := ws.knownHosts.Reset(id); err != nil {
+			http.Error(w, "failed to reset host key", http.StatusInternalServerError)
+			return
+		}
+		s.dropSSH(ws, id)
+		s.log("warn", "ssh", fmt.Sprintf("host key for %s reset by %s; the next connection will pin a new key", id, requestAuthor(r)))
+		if ws.ssh != nil {
+			ws.ssh.Refresh()
+		}
+		writeJSON(w, http.StatusOK, map[string]string{
+			"status": "reset",
+		})
+	case action == "accept" && r.Method == http.MethodPost:
+		if !s.requireRole(w, r, storage.RoleAdmin) {
+			return
+		}
+		entry, err := ws.knownHosts.Accept(id)
+		if errors.Is(err, storage.ErrNoOfferedKey) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, "failed to accept host key", http.StatusInternalServerError)
+			return
+		}
+		s.dropSSH(ws, id)
+		s.log("warn", "ssh", fmt.Sprintf("new host key %s accepted for %s by %s", entry.Pinned.Fingerprint, id, requestAuthor(r)))
+		if ws.ssh != nil {
+			ws.ssh.Refresh()
+		}
+		writeJSON(w, http.StatusOK, entry)
+	case action == "" || action == "accept":
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"inframap/internal/tsdb"
+)
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	kind := strings.TrimPrefix(r.URL.Path, "/api/metrics/")
+	if kind != tsdb.KindPing && kind != tsdb.KindSSH && kind != tsdb.KindCheck {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ws, ok := s.workspaceFor(w, r)
+	if !ok {
+		return
+	}
+	if ws.metrics == nil {
+		http.Error(w, "metrics store not available", http.StatusInternalServerError)
+		return
+	}
+	query := r.URL.Query()
+	node := strings.TrimSpace(query.Get("node"))
+	if node == "" {
+		http.Error(w, "node is required", http.StatusBadRequest)
+		return
+	}
+	if kind == tsdb.KindCheck {
+		check := strings.TrimSpace(query.Get("check"))
+		if check == "" {
+			http.Error(w, "check is required", http.StatusBadRequest)
+			return
+		}
+		node += "/" + check
+	}
+	now := time.Now()
+	to, err := parseMetricsTime(query.Get("to"), now, now)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid to: %v", err), http.StatusBadRequest)
+		return
+	}
+	from, err := parseMetricsTime(query.Get("from"), to.Add(-time.Hour), now)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid from: %v", err), http.StatusBadRequest)
+		return
+	}
+	step, err := parseMetricsStep(query.Get("step"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid step: %v", err), http.StatusBadRequest)
+		return
+	}
+	result, err := ws.metrics.Query(kind, node, from, to, step)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func parseMetricsTime(value string, fallback, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return fallback, nil
+	}
+	if unix, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(unix, 0), nil
+	}
+	if ago, err := time.ParseDuration(strings.TrimPrefix(value, "-")); err == nil {
+		return now.Add(-ago), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func parseMetricsStep(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, fmt.Errorf("must not be negative")
+		}
+		return time.Duration(seconds) * time.Second, nil
+	}
+	step, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if step < 0 {
+		return 0, fmt.Errorf("must not be negative")
+	}
+	return step, nil
+}
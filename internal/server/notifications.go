@@ -0,0 +1,266 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"inframap/internal/model"
+	"inframap/internal/notify"
+	"inframap/internal/storage"
+)
+
+type channelView struct {
+	model.NotificationChannel
+	HasURL      bool     `json:"hasUrl"`
+	URLHost     string   `json:"urlHost,omitempty"`
+	HeaderNames []string `json:"headerNames,omitempty"`
+	HasSecret   bool     `json:"hasSecret"`
+	HasPassword bool     `json:"hasPassword,omitempty"`
+}
+
+type alertNotifier struct {
+	board    string
+	channels *storage.ScopedChannels
+	notify   *notify.Manager
+	logger   notify.Logger
+}
+
+func (n alertNotifier) Notify(alert model.Alert) {
+	channels, err := n.channels.List()
+	if err != nil {
+		n.logger.Add("warn", "notify", fmt.Sprintf("failed to read notification channels: %v", err))
+		return
+	}
+	event := notify.EventFiring
+	if alert.State == model.AlertResolved {
+		event = notify.EventResolved
+	}
+	for _, channel := range channels {
+		if notify.Wants(channel, alert) {
+			n.notify.Dispatch(channel, notify.Message{Event: event, Board: n.board, Alert: alert}, n.logger)
+		}
+	}
+}
+
+func (s *Server) handleNotifications(w http.ResponseWriter, r *http.Request) {
+	ws, ok := s.workspaceFor(w, r)
+	if !ok {
+		return
+	}
+	if ws.channels == nil {
+		http.Error(w, "notification channels not available", http.StatusInternalServerError)
+		return
+	}
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/notifications/"), "/")
+	head, tail, _ := strings.Cut(rest, "/")
+	switch head {
+	case "channels":
+		id, action, _ := strings.Cut(tail, "/")
+		if id == "" {
+			s.handleChannels(w, r, ws)
+			return
+		}
+		s.handleChannel(w, r, ws, id, action)
+	case "deliveries":
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"items": s.notify.Deliveries(ws.id, r.URL.Query().Get("channel")),
+		})
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+func (s *Server) handleChannels(w http.ResponseWriter, r *http.Request, ws *workspace) {
+	switch r.Method {
+	case http.MethodGet:
+		channels, err := ws.channels.List()
+		if err != nil {
+			http.Error(w, "failed to read notification channels", http.StatusInternalServerError)
+			return
+		}
+		items := make([]channelView, 0, len(channels))
+		for _, channel := range channels {
+			items = append(items, redactChannel(channel))
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"items": items,
+		})
+	case http.MethodPost:
+		if !s.requireRole(w, r, storage.RoleAdmin) {
+			return
+		}
+		channel := model.NotificationChannel{Enabled: true, SendResolved: true, Retries: 3, RetryBackoffSec: 5}
+		if !decodeChannel(w, r, &channel, nil) {
+			return
+		}
+		channel.ID = ""
+		saved, err := ws.channels.Put(channel)
+		if err != nil {
+			http.Error(w, "failed to save notification channel", http.StatusInternalServerError)
+			return
+		}
+		s.log("info", "notify", fmt.Sprintf("notification channel %s (%s) created by %s", saved.Name, saved.Type, requestAuthor(r)))
+		writeJSON(w, http.StatusCreated, redactChannel(saved))
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleChannel(w http.ResponseWriter, r *http.Request, ws *workspace, id, action string) {
+	channel, ok, err := ws.channels.Get(id)
+	if err != nil {
+		http.Error(w, "failed to read notification channels", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "notification channel not found", http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, redactChannel(channel))
+	case action == "" && r.Method == http.MethodPut:
+		if !s.requireRole(w, r, storage.RoleAdmin) {
+			return
+		}
+		prev := channel
+		if prev.SMTP != nil {
+			smtp := *prev.SMTP
+			prev.SMTP = &smtp
+		}
+		channel.Headers = nil
+		if !decodeChannel(w, r, &channel, &prev) {
+			return
+		}
+		channel.ID = id
+		saved, err := ws.channels.Put(channel)
+		if err != nil {
+			http.Error(w, "failed to save notification channel", http.StatusInternalServerError)
+			return
+		}
+		s.log("info", "notify", fmt.Sprintf("notification channel %s updated by %s", saved.Name, requestAuthor(r)))
+		writeJSON(w, http.StatusOK, redactChannel(saved))
+	case action == "" && r.Method == http.MethodDelete:
+		if !s.requireRole(w, r, storage.RoleAdmin) {
+			return
+		}
+		err := ws.channels.Delete(id)
+		if errors.Is(err, storage.ErrChannelNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "failed to delete notification channel", http.StatusInternalServerError)
+			return
+		}
+		s.log("info", "notify", fmt.Sprintf("notification channel %s deleted by %s", channel.Name, requestAuthor(r)))
+		writeJSON(w, http.StatusOK, map[string]string{
+			"status": "deleted",
+		})
+	case action == "test" && r.Method == http.MethodPost:
+		if !s.requireRole(w, r, storage.RoleOperator) {
+			return
+		}
+		logger := scopedLogger{board: ws.id, logger: s.logs}
+		delivery, err := s.notify.Test(channel, notify.Message{Board: ws.id}, logger)
+		if err != nil {
+			logger.Add("warn", "notify", fmt.Sprintf("test notification to %s failed: %v", channel.Name, err))
+			writeJSON(w, http.StatusBadGateway, delivery)
+			return
+		}
+		writeJSON(w, http.StatusOK, delivery)
+	case action == "" || action == "test":
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+func decodeChannel(w http.ResponseWriter, r *http.Request, channel *model.NotificationChannel, prev *model.NotificationChannel) bool {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		http.Error(w, "failed to read request body", http.StatusBadRequest)
+		return false
+	}
+	if err := json.Unmarshal(body, channel); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return false
+	}
+	channel.Name = strings.TrimSpace(channel.Name)
+	channel.URL = strings.TrimSpace(channel.URL)
+	if prev != nil {
+		keepStoredChannelSecrets(channel, *prev)
+	}
+	if channel.Type != model.ChannelEmail {
+		channel.SMTP = nil
+	}
+	errs := model.ValidateNotificationChannel(channel)
+	if channel.Type == model.ChannelWebhook {
+		if err := notify.ValidateTemplate(channel.Template); err != nil {
+			errs = append(errs, model.ValidationError{Field: "template", Message: err.Error()})
+		}
+	}
+	if len(errs) > 0 {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{
+			"error":  "invalid notification channel",
+			"errors": errs,
+		})
+		return false
+	}
+	return true
+}
+
+func keepStoredChannelSecrets(channel *model.NotificationChannel, prev model.NotificationChannel) {
+	if channel.URL == "" && channel.Type == prev.Type {
+		channel.URL = prev.URL
+	}
+	if channel.Secret == "" {
+		channel.Secret = prev.Secret
+	}
+	if channel.Headers == nil {
+		channel.Headers = prev.Headers
+	} else {
+		for name, value := range channel.Headers {
+			if value == "" {
+				if stored, ok := prev.Headers[name]; ok {
+					channel.Headers[name] = stored
+				}
+			}
+		}
+	}
+	if channel.SMTP != nil && channel.SMTP.Password == "" && prev.SMTP != nil {
+		channel.SMTP.Password = prev.SMTP.Password
+	}
+}
+
+func redactChannel(channel model.NotificationChannel) channelView {
+	view := channelView{NotificationChannel: channel, HasURL: channel.URL != "", HasSecret: channel.Secret != ""}
+	if parsed, err := url.Parse(channel.URL); err == nil {
+		view.URLHost = parsed.Host
+	}
+	for name := range channel.Headers {
+		view.HeaderNames = append(view.HeaderNames, name)
+	}
+	sort.Strings(view.HeaderNames)
+	view.URL = ""
+	view.Headers = nil
+	view.Secret = ""
+	if channel.SMTP != nil {
+		smtp := *channel.SMTP
+		view.HasPassword = smtp.Password != ""
+		smtp.Password = ""
+		view.SMTP = &smtp
+	}
+	return view
+}
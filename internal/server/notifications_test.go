@@ -0,0 +1,78 @@
+package server
+
+import (
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"inframap/internal/storage"
+)
+
+func TestNotificationChannelsHideSecrets(t *testing.T) {
+	dir := t.TempDir()
+	channels, err := storage.NewChannelStore(filepath.Join(dir, "secrets.key"), filepath.Join(dir, "channels.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	alerts, err := storage.NewAlertStore(filepath.Join(dir, "alerts.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv, h := newTestServerConfig(t, Config{Alerts: alerts, Channels: channels})
+	const hook = "https://hooks.slack.com/services/T000/B000/XXXXSECRET"
+
+	rec := serve(h, testRequest{
+		method: http.MethodPost,
+		path:   "/api/notifications/channels",
+		body:   `{"name": "ops", "type": "webhook", "url": "` + hook + `", "secret": "hmac-key", "headers": {"Authorization": "Bearer tok3n", "X-Team": "ops"}}`,
+	})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create = %d %s", rec.Code, rec.Body.String())
+	}
+	id := decode(t, rec)["id"].(string)
+
+	for _, path := range []string{"/api/notifications/channels", "/api/notifications/channels/" + id} {
+		body := serve(h, testRequest{method: http.MethodGet, path: path}).Body.String()
+		for _, secret := range []string{"XXXXSECRET", "tok3n", "hmac-key", `"ops"}`} {
+			if strings.Contains(body, secret) {
+				t.Fatalf("GET %s leaks %q: %s", path, secret, body)
+			}
+		}
+	}
+	view := decode(t, serve(h, testRequest{method: http.MethodGet, path: "/api/notifications/channels/" + id}))
+	if view["hasUrl"] != true || view["urlHost"] != "hooks.slack.com" || view["hasSecret"] != true {
+		t.Fatalf("view = %v", view)
+	}
+	if names, _ := view["headerNames"].([]any); len(names) != 2 || names[0] != "Authorization" || names[1] != "X-Team" {
+		t.Fatalf("headerNames = %v", view["headerNames"])
+	}
+
+	rec = serve(h, testRequest{
+		method: http.MethodPut,
+		path:   "/api/notifications/channels/" + id,
+		body:   `{"name": "ops-renamed", "type": "webhook", "url": "", "headers": {"Authorization": "", "X-Extra": "1"}}`,
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("update = %d %s", rec.Code, rec.Body.String())
+	}
+	stored, _, err := srv.workspace(storage.DefaultBoardID).channels.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Name != "ops-renamed" || stored.URL != hook || stored.Secret != "hmac-key" {
+		t.Fatalf("stored after update = %+v", stored)
+	}
+	if len(stored.Headers) != 2 || stored.Headers["Authorization"] != "Bearer tok3n" || stored.Headers["X-Extra"] != "1" {
+		t.Fatalf("stored headers = %v", stored.Headers)
+	}
+
+	rec = serve(h, testRequest{
+		method: http.MethodPut,
+		path:   "/api/notifications/channels/" + id,
+		body:   `{"name": "ops-renamed", "type": "webhook"}`,
+	})
+	if stored, _, _ = srv.workspace(storage.DefaultBoardID).channels.Get(id); rec.Code != http.StatusOK || len(stored.Headers) != 2 {
+		t.Fatalf("update without headers = %d, headers %v", rec.Code, stored.Headers)
+	}
+}
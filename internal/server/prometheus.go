@@ -0,0 +1,258 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"inframap/internal/model"
+	"inframap/internal/monitoring"
+)
+
+type requestKey struct {
+	method string
+	code   int
+}
+
+type requestCounter struct {
+	mu     sync.Mutex
+	counts map[requestKey]int64
+}
+
+func newRequestCounter() *requestCounter {
+	return &requestCounter{counts: make(map[requestKey]int64)}
+}
+
+func (c *requestCounter) observe(method string, code int) {
+	if c == nil {
+		return
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions:
+	default:
+		method = "OTHER"
+	}
+	c.mu.Lock()
+	c.counts[requestKey{method: method, code: code}]++
+	c.mu.Unlock()
+}
+
+func (c *requestCounter) snapshot() map[requestKey]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[requestKey]int64, len(c.counts))
+	for key, count := range c.counts {
+		out[key] = count
+	}
+	return out
+}
+
+type promSample struct {
+	labels []string
+	value  float64
+}
+
+type promFamily struct {
+	name    string
+	kind    string
+	help    string
+	samples []promSample
+}
+
+func (f *promFamily) add(value float64, labels ...string) {
+	f.samples = append(f.samples, promSample{labels: labels, value: value})
+}
+
+func (s *Server) handlePrometheus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	boards, err := s.boards.List()
+	if err != nil {
+		http.Error(w, "failed to read boards", http.StatusInternalServerError)
+		return
+	}
+
+	pingUp := &promFamily{name: "inframap_ping_up", kind: "gauge", help: "Whether the last ping of the node succeeded."}
+	pingRTT := &promFamily{name: "inframap_ping_rtt_ms", kind: "gauge", help: "Round-trip time of the last successful ping in milliseconds."}
+	addrUp := &promFamily{name: "inframap_ping_address_up", kind: "gauge", help: "Whether the last ping of one of the node's addresses succeeded."}
+	addrRTT := &promFamily{name: "inframap_ping_address_rtt_ms", kind: "gauge", help: "Round-trip time of the last successful ping of one of the node's addresses in milliseconds."}
+	dnsResolved := &promFamily{name: "inframap_dns_resolved", kind: "gauge", help: "Whether the node's hostname resolved on the last ping cycle."}
+	sshUp := &promFamily{name: "inframap_ssh_up", kind: "gauge", help: "Whether the last SSH check of the node succeeded."}
+	linkSpeed := &promFamily{name: "inframap_link_speed_mbps", kind: "gauge", help: "Detected link speed of the node in Mbit/s."}
+	checkUp := &promFamily{name: "inframap_check_up", kind: "gauge", help: "Whether the last run of the service check succeeded."}
+	checkLatency := &promFamily{name: "inframap_check_latency_ms", kind: "gauge", help: "Latency of the last successful service check in milliseconds."}
+	certDays := &promFamily{name: "inframap_tls_cert_days_left", kind: "gauge", help: "Days until the certificate seen by a TLS check expires."}
+	pingDuration := &promFamily{name: "inframap_ping_cycle_duration_seconds", kind: "gauge", help: "Duration of the last ping cycle."}
+	pingCycles := &promFamily{name: "inframap_ping_cycles_total", kind: "counter", help: "Ping cycles run since start."}
+	pingSeconds := &promFamily{name: "inframap_ping_cycle_seconds_total", kind: "counter", help: "Time spent in ping cycles since start."}
+	sshDuration := &promFamily{name: "inframap_ssh_check_duration_seconds", kind: "gauge", help: "Duration of the last SSH status check."}
+	sshChecks := &promFamily{name: "inframap_ssh_checks_total", kind: "counter", help: "SSH status checks run since start."}
+	sshSeconds := &promFamily{name: "inframap_ssh_check_seconds_total", kind: "counter", help: "Time spent in SSH status checks since start."}
+
+	for _, info := range boards {
+		ws, ok, err := s.existingWorkspace(info.ID)
+		if err != nil || !ok {
+			continue
+		}
+		board, err := s.readBoard(ws)
+		if err != nil {
+			continue
+		}
+		var pings map[string]model.PingResult
+		if ws.ping != nil {
+			pings = ws.ping.GetStatus()
+			addCycleStats(ws.ping.Stats(), info.ID, pingDuration, pingCycles, pingSeconds)
+		}
+		var sshStatus map[string]model.SSHStatus
+		if ws.ssh != nil {
+			sshStatus = ws.ssh.GetStatus()
+			addCycleStats(ws.ssh.Stats(), info.ID, sshDuration, sshChecks, sshSeconds)
+		}
+		var checkStatus map[string]map[string]model.CheckResult
+		if ws.checks != nil {
+			checkStatus = ws.checks.GetStatus()
+		}
+		for _, node := range board.Nodes {
+			if node.Type == "network" {
+				continue
+			}
+			labels := nodeLabels(info.ID, node)
+			if res, ok := pings[node.ID]; ok {
+				pingUp.add(boolValue(res.Online), labels...)
+				if res.Online {
+					pingRTT.add(float64(res.RTTMs), labels...)
+				}
+				if res.DNS != nil {
+					dnsResolved.add(boolValue(res.DNS.Error == ""), append(labels[:len(labels):len(labels)], "hostname", res.DNS.Name)...)
+				}
+				kinds := make([]string, 0, len(res.Addresses))
+				for kind := range res.Addresses {
+					kinds = append(kinds, kind)
+				}
+				sort.Strings(kinds)
+				for _, kind := range kinds {
+					probe := res.Addresses[kind]
+					addrLabels := append(labels[:len(labels):len(labels)], "address", kind, "ip", probe.Target)
+					addrUp.add(boolValue(probe.Online), addrLabels...)
+					if probe.Online {
+						addrRTT.add(float64(probe.RTTMs), addrLabels...)
+					}
+				}
+			}
+			if res, ok := sshStatus[node.ID]; ok {
+				sshUp.add(boolValue(res.Online), labels...)
+			}
+			if node.LinkSpeedMbps > 0 {
+				linkSpeed.add(float64(node.LinkSpeedMbps), labels...)
+			}
+			for _, check := range node.Checks {
+				res, ok := checkStatus[node.ID][check.ID]
+				if !ok {
+					continue
+				}
+				checkLabels := append(labels[:len(labels):len(labels)], "check", check.ID, "check_type", check.Type)
+				checkUp.add(boolValue(res.Online), checkLabels...)
+				if res.Online {
+					checkLatency.add(res.LatencyMs, checkLabels...)
+				}
+				if res.DaysLeft != nil {
+					certDays.add(float64(*res.DaysLeft), checkLabels...)
+				}
+			}
+		}
+	}
+
+	requests := &promFamily{name: "inframap_http_requests_total", kind: "counter", help: "HTTP requests served, by method and status code."}
+	counts := s.requests.snapshot()
+	keys := make([]requestKey, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].method != keys[j].method {
+			return keys[i].method < keys[j].method
+		}
+		return keys[i].code < keys[j].code
+	})
+	for _, key := range keys {
+		requests.add(float64(counts[key]), "method", key.method, "code", strconv.Itoa(key.code))
+	}
+	goroutines := &promFamily{name: "go_goroutines", kind: "gauge", help: "Number of goroutines that currently exist."}
+	goroutines.add(float64(runtime.NumGoroutine()))
+
+	var buf bytes.Buffer
+	for _, family := range []*promFamily{
+		pingUp, pingRTT, addrUp, addrRTT, dnsResolved, sshUp, linkSpeed,
+		checkUp, checkLatency, certDays,
+		pingDuration, pingCycles, pingSeconds,
+		sshDuration, sshChecks, sshSeconds,
+		requests, goroutines,
+	} {
+		writeFamily(&buf, family)
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
+
+func addCycleStats(stats monitoring.CycleStats, board string, last, runs, total *promFamily) {
+	if stats.Runs == 0 {
+		return
+	}
+	last.add(stats.LastDuration.Seconds(), "board", board)
+	runs.add(float64(stats.Runs), "board", board)
+	total.add(stats.TotalDuration.Seconds(), "board", board)
+}
+
+func nodeLabels(board string, node model.Node) []string {
+	tags := append([]string(nil), node.Tags...)
+	sort.Strings(tags)
+	return []string{
+		"board", board,
+		"node", node.ID,
+		"label", node.Label,
+		"type", node.Type,
+		"network", node.Network,
+		"tags", strings.Join(tags, ","),
+	}
+}
+
+func boolValue(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+func writeFamily(buf *bytes.Buffer, family *promFamily) {
+	fmt.Fprintf(buf, "# HELP %s %s\n", family.name, family.help)
+	fmt.Fprintf(buf, "# TYPE %s %s\n", family.name, family.kind)
+	for _, sample := range family.samples {
+		buf.WriteString(family.name)
+		if len(sample.labels) > 0 {
+			buf.WriteByte('{')
+			for i := 0; i+1 < len(sample.labels); i += 2 {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				buf.WriteString(sample.labels[i])
+				buf.WriteString(`="`)
+				buf.WriteString(labelEscaper.Replace(sample.labels[i+1]))
+				buf.WriteByte('"')
+			}
+			buf.WriteByte('}')
+		}
+		buf.WriteByte(' ')
+		buf.WriteString(strconv.FormatFloat(sample.value, 'g', -1, 64))
+		buf.WriteByte('\n')
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
@@ -0,0 +1,220 @@
+package server
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"inframap/internal/model"
+	"inframap/internal/tsdb"
+)
+
+type nodeAvailability struct {
+	Node    string `json:"node"`
+	Label   string `json:"label,omitempty"`
+	Network string `json:"network,omitempty"`
+	tsdb.Availability
+}
+
+type networkAvailability struct {
+	Network string `json:"network"`
+	Nodes   int    `json:"nodes"`
+	tsdb.Availability
+}
+
+func (s *Server) handleReports(w http.ResponseWriter, r *http.Request) {
+	if strings.TrimPrefix(r.URL.Path, "/api/reports/") != "availability" {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ws, ok := s.workspaceFor(w, r)
+	if !ok {
+		return
+	}
+	if ws.metrics == nil {
+		http.Error(w, "metrics store not available", http.StatusInternalServerError)
+		return
+	}
+	query := r.URL.Query()
+	kind := query.Get("kind")
+	if kind == "" {
+		kind = tsdb.KindPing
+	}
+	from, to, err := reportWindow(query.Get("month"), query.Get("from"), query.Get("to"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	board, err := s.readBoard(ws)
+	if err != nil {
+		http.Error(w, "failed to read board file", http.StatusInternalServerError)
+		return
+	}
+	nodes := reportNodes(board, kind, splitList(query.Get("node")), query.Get("network"))
+
+	rows := make([]nodeAvailability, 0, len(nodes))
+	networks := make(map[string]*networkAvailability)
+	total := tsdb.Availability{}
+	for _, node := range nodes {
+		report, err := ws.metrics.Availability(kind, node.ID, from, to)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		rows = append(rows, nodeAvailability{Node: node.ID, Label: node.Label, Network: node.Network, Availability: report})
+		group, ok := networks[node.Network]
+		if !ok {
+			group = &networkAvailability{Network: node.Network}
+			networks[node.Network] = group
+		}
+		group.Nodes++
+		group.Merge(report)
+		total.Merge(report)
+	}
+	groups := make([]networkAvailability, 0, len(networks))
+	for _, group := range networks {
+		groups = append(groups, *group)
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Network < groups[j].Network })
+
+	if query.Get("format") == "csv" {
+		writeAvailabilityCSV(w, kind, from, to, rows, groups, total)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"kind":     kind,
+		"from":     from.UTC().Format(time.RFC3339),
+		"to":       to.UTC().Format(time.RFC3339),
+		"nodes":    rows,
+		"networks": groups,
+		"total":    total,
+	})
+}
+
+func (s *Server) readBoard(ws *workspace) (model.Board, error) {
+	ws.mu.Lock()
+	data, err := os.ReadFile(ws.boardFile)
+	ws.mu.Unlock()
+	if err != nil {
+		return model.Board{}, err
+	}
+	var board model.Board
+	if err := json.Unmarshal(data, &board); err != nil {
+		return model.Board{}, err
+	}
+	return board, nil
+}
+
+func reportWindow(month, fromValue, toValue string) (time.Time, time.Time, error) {
+	now := time.Now()
+	if month = strings.TrimSpace(month); month != "" {
+		start, err := time.Parse("2006-01", month)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid month %q (use YYYY-MM)", month)
+		}
+		end := start.AddDate(0, 1, 0)
+		if end.After(now) {
+			end = now
+		}
+		if !end.After(start) {
+			return time.Time{}, time.Time{}, fmt.Errorf("month %s has not started yet", month)
+		}
+		return start, end, nil
+	}
+	to, err := parseMetricsTime(toValue, now, now)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid to: %v", err)
+	}
+	from, err := parseMetricsTime(fromValue, to.AddDate(0, 0, -30), now)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid from: %v", err)
+	}
+	return from, to, nil
+}
+
+func reportNodes(board model.Board, kind string, ids []string, network string) []model.Node {
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	var nodes []model.Node
+	for _, node := range board.Nodes {
+		if node.Type == "network" {
+			continue
+		}
+		if network != "" && node.Network != network {
+			continue
+		}
+		if len(wanted) > 0 {
+			if !wanted[node.ID] {
+				continue
+			}
+		} else if kind == tsdb.KindPing && (node.PingEnabled == nil || !*node.PingEnabled) {
+			continue
+		} else if kind == tsdb.KindSSH && !node.ConnectEnabled {
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return nodes
+}
+
+func splitList(value string) []string {
+	var out []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func writeAvailabilityCSV(w http.ResponseWriter, kind string, from, to time.Time, rows []nodeAvailability, groups []networkAvailability, total tsdb.Availability) {
+	name := fmt.Sprintf("availability-%s-%s-%s.csv", kind, from.UTC().Format("20060102"), to.UTC().Format("20060102"))
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	w.WriteHeader(http.StatusOK)
+	out := csv.NewWriter(w)
+	_ = out.Write([]string{"scope", "network", "node", "label", "samples", "uptime_pct", "outages", "downtime_sec", "mttr_sec", "longest_outage_sec"})
+	record := func(scope, network, node, label string, a tsdb.Availability) {
+		uptime := ""
+		if a.UptimePct != nil {
+			uptime = strconv.FormatFloat(*a.UptimePct, 'f', 3, 64)
+		}
+		_ = out.Write([]string{
+			scope, csvCell(network), csvCell(node), csvCell(label),
+			strconv.Itoa(a.Samples),
+			uptime,
+			strconv.Itoa(a.Outages),
+			strconv.FormatInt(a.DowntimeSec, 10),
+			strconv.FormatInt(a.MTTRSec, 10),
+			strconv.FormatInt(a.LongestOutageSec, 10),
+		})
+	}
+	for _, row := range rows {
+		record("node", row.Network, row.Node, row.Label, row.Availability)
+	}
+	for _, group := range groups {
+		record("network", group.Network, "", "", group.Availability)
+	}
+	record("total", "", "", "", total)
+	out.Flush()
+}
+
+func csvCell(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
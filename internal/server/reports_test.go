@@ -0,0 +1,45 @@
+package server
+
+import (
+	"encoding/csv"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"inframap/internal/tsdb"
+)
+
+func TestAvailabilityCSVEscapesFormulas(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rows := []nodeAvailability{
+		{Node: "node-1", Label: `=HYPERLINK("http://evil","x")`, Network: "+lan"},
+		{Node: "-node-2", Label: "@SUM(A1)", Network: "lab"},
+		{Node: "node-3", Label: "db 1", Network: ""},
+	}
+	groups := []networkAvailability{{Network: "\t=cmd", Nodes: 1}}
+	writeAvailabilityCSV(rec, "ping", time.Now().Add(-time.Hour), time.Now(), rows, groups, tsdb.Availability{})
+
+	records, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"node", "'+lan", "node-1", `'=HYPERLINK("http://evil","x")`},
+		{"node", "lab", "'-node-2", "'@SUM(A1)"},
+		{"node", "", "node-3", "db 1"},
+		{"network", "'\t=cmd", "", ""},
+		{"total", "", "", ""},
+	}
+	if len(records) != len(want)+1 {
+		t.Fatalf("records = %q", records)
+	}
+	for i, row := range want {
+		got := records[i+1][:4]
+		for j := range row {
+			if got[j] != row[j] {
+				t.Errorf("row %d = %q, want %q", i, got, row)
+				break
+			}
+		}
+	}
+}
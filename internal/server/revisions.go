@@ -0,0 +1,216 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"inframap/internal/model"
+	"inframap/internal/storage"
+)
+
+func (s *Server) bootstrapRevisions(ws *workspace, data []byte) {
+	if ws.revisions == nil {
+		return
+	}
+	if _, ok, err := ws.revisions.Latest(); err != nil || ok {
+		return
+	}
+	var board model.Board
+	if err := json.Unmarshal(data, &board); err != nil {
+		return
+	}
+	if _, err := ws.revisions.Add(board, "system", "initial board"); err != nil && s.logs != nil {
+		s.logs.Add("warn", "board", fmt.Sprintf("failed to record initial revision: %v", err))
+	}
+}
+
+func (s *Server) recordRevision(ws *workspace, board *model.Board, author, summary string) *storage.RevisionMeta {
+	if ws.revisions == nil {
+		return nil
+	}
+	latest, ok, err := ws.revisions.Latest()
+	if err != nil && s.logs != nil {
+		s.logs.Add("warn", "board", fmt.Sprintf("failed to read latest revision: %v", err))
+	}
+	if ok {
+		diff := model.DiffBoards(&latest.Board, board)
+		if diff.Empty() {
+			return &latest.RevisionMeta
+		}
+		if summary == "" {
+			summary = diff.Summary()
+		}
+	} else if summary == "" {
+		summary = model.DiffBoards(nil, board).Summary()
+	}
+	meta, err := ws.revisions.Add(*board, author, summary)
+	if err != nil {
+		if s.logs != nil {
+			s.logs.Add("warn", "board", fmt.Sprintf("failed to record revision: %v", err))
+		}
+		return nil
+	}
+	return &meta
+}
+
+func (s *Server) handleRevisions(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ws, ok := s.workspaceFor(w, r)
+	if !ok {
+		return
+	}
+	if ws.revisions == nil {
+		http.Error(w, "revision store not available", http.StatusInternalServerError)
+		return
+	}
+	items, err := ws.revisions.List()
+	if err != nil {
+		http.Error(w, "failed to read revisions", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"items": items,
+	})
+}
+
+func (s *Server) handleRevision(w http.ResponseWriter, r *http.Request) {
+	ws, ok := s.workspaceFor(w, r)
+	if !ok {
+		return
+	}
+	if ws.revisions == nil {
+		http.Error(w, "revision store not available", http.StatusInternalServerError)
+		return
+	}
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/board/revisions/"), "/")
+	if rest == "diff" {
+		s.handleRevisionDiff(w, r, ws)
+		return
+	}
+	parts := strings.Split(rest, "/")
+	number, err := strconv.Atoi(parts[0])
+	if err != nil || number <= 0 {
+		http.Error(w, "invalid revision number", http.StatusBadRequest)
+		return
+	}
+
+	switch {
+	case len(parts) == 1:
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		rev, ok, err := ws.revisions.Get(number)
+		if err != nil {
+			http.Error(w, "failed to read revision", http.StatusInternalServerError)
+			return
+		}
+		if !ok {
+			http.Error(w, "revision not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, rev)
+	case len(parts) == 2 && parts[1] == "restore":
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !s.requireRole(w, r, storage.RoleEditor) {
+			return
+		}
+		s.restoreRevision(w, r, ws, number)
+	default:
+		http.Error(w, "not found", http.StatusNotFound)
+	}
+}
+
+func (s *Server) handleRevisionDiff(w http.ResponseWriter, r *http.Request, ws *workspace) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	from, errFrom := strconv.Atoi(r.URL.Query().Get("from"))
+	to, errTo := strconv.Atoi(r.URL.Query().Get("to"))
+	if errFrom != nil || errTo != nil {
+		http.Error(w, "from and to must be revision numbers", http.StatusBadRequest)
+		return
+	}
+	before, ok, err := ws.revisions.Get(from)
+	if err != nil {
+		http.Error(w, "failed to read revision", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, fmt.Sprintf("revision %d not found", from), http.StatusNotFound)
+		return
+	}
+	after, ok, err := ws.revisions.Get(to)
+	if err != nil {
+		http.Error(w, "failed to read revision", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, fmt.Sprintf("revision %d not found", to), http.StatusNotFound)
+		return
+	}
+	diff := model.DiffBoards(&before.Board, &after.Board)
+	writeJSON(w, http.StatusOK, map[string]any{
+		"from":    before.RevisionMeta,
+		"to":      after.RevisionMeta,
+		"summary": diff.Summary(),
+		"diff":    diff,
+	})
+}
+
+func (s *Server) restoreRevision(w http.ResponseWriter, r *http.Request, ws *workspace, number int) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if err := s.ensureDataDir(); err != nil {
+		http.Error(w, "failed to prepare data directory", http.StatusInternalServerError)
+		return
+	}
+	if !s.checkBoardPrecondition(w, r, ws) {
+		return
+	}
+	rev, ok, err := ws.revisions.Get(number)
+	if err != nil {
+		http.Error(w, "failed to read revision", http.StatusInternalServerError)
+		return
+	}
+	if !ok {
+		http.Error(w, "revision not found", http.StatusNotFound)
+		return
+	}
+	author := requestAuthor(r)
+	meta, etag, err := s.storeBoard(ws, &rev.Board, author, fmt.Sprintf("restored revision #%d", number))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	if s.logs != nil {
+		s.logs.Add("info", "board", fmt.Sprintf("board %s restored to revision #%d by %s", ws.id, number, author))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"status":   "restored",
+		"revision": meta,
+		"etag":     etag,
+		"board":    rev.Board,
+	})
+}
+
+func requestAuthor(r *http.Request) string {
+	if info := currentAuth(r); info != nil {
+		return info.Username
+	}
+	if author := strings.TrimSpace(r.Header.Get("X-InfraMap-Author")); author != "" {
+		return author
+	}
+	return clientIP(r)
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"inframap/internal/storage"
+)
+
+func TestRoleEnforcement(t *testing.T) {
+	users := newUserStore(t)
+	roles := []string{storage.RoleViewer, storage.RoleEditor, storage.RoleOperator, storage.RoleAdmin}
+	for _, role := range roles {
+		if _, err := users.Create(role+"-user", "correct horse", role); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, h := newTestServer(t, users)
+	sessions := make(map[string]testSession)
+	for _, role := range roles {
+		sessions[role] = login(t, h, role+"-user", "correct horse")
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		body   string
+		need   string
+	}{
+		{http.MethodGet, "/api/board", "", storage.RoleViewer},
+		{http.MethodGet, "/api/alerts", "", storage.RoleViewer},
+		{http.MethodPost, "/api/board", "not json", storage.RoleEditor},
+		{http.MethodPost, "/api/boards", "not json", storage.RoleEditor},
+		{http.MethodPost, "/api/jobs", "not json", storage.RoleOperator},
+		{http.MethodGet, "/api/users", "", storage.RoleAdmin},
+		{http.MethodDelete, "/api/boards/" + storage.DefaultBoardID, "", storage.RoleAdmin},
+	}
+	for _, tt := range tests {
+		for _, role := range roles {
+			rec := serve(h, sessions[role].request(tt.method, tt.path, tt.body))
+			allowed := roleAtLeast(role, tt.need)
+			if denied := rec.Code == http.StatusForbidden; denied == allowed {
+				t.Errorf("%s %s as %s = %d %s, want allowed %t", tt.method, tt.path, role, rec.Code, rec.Body.String(), allowed)
+			}
+			if !allowed && !strings.Contains(rec.Body.String(), "requires the "+tt.need+" role") {
+				t.Errorf("%s %s as %s: body %s", tt.method, tt.path, role, rec.Body.String())
+			}
+		}
+	}
+}
+
+func TestRoleChangeAppliesToExistingSessions(t *testing.T) {
+	users := newUserStore(t)
+	if _, err := users.Create("alice", "correct horse", storage.RoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := users.Create("bob", "correct horse", storage.RoleEditor); err != nil {
+		t.Fatal(err)
+	}
+	_, h := newTestServer(t, users)
+	bob := login(t, h, "bob", "correct horse")
+	if rec := serve(h, bob.request(http.MethodPost, "/api/board", "not json")); rec.Code == http.StatusForbidden {
+		t.Fatalf("editor save = %d", rec.Code)
+	}
+	if _, err := users.SetRole("bob", storage.RoleViewer); err != nil {
+		t.Fatal(err)
+	}
+	if rec := serve(h, bob.request(http.MethodPost, "/api/board", "not json")); rec.Code != http.StatusForbidden {
+		t.Fatalf("demoted save = %d", rec.Code)
+	}
+	if me := decode(t, serve(h, bob.request(http.MethodGet, "/api/auth/me", ""))); me["role"] != storage.RoleViewer {
+		t.Fatalf("me = %v", me)
+	}
+}
+
+func TestRoleAtLeast(t *testing.T) {
+	tests := []struct {
+		have, need string
+		want       bool
+	}{
+		{storage.RoleAdmin, storage.RoleViewer, true},
+		{storage.RoleOperator, storage.RoleEditor, true},
+		{storage.RoleEditor, storage.RoleOperator, false},
+		{storage.RoleViewer, storage.RoleViewer, true},
+		{"", storage.RoleViewer, false},
+		{"root", storage.RoleViewer, false},
+	}
+	for _, tt := range tests {
+		if got := roleAtLeast(tt.have, tt.need); got != tt.want {
+			t.Errorf("roleAtLeast(%q, %q) = %t, want %t", tt.have, tt.need, got, tt.want)
+		}
+	}
+}
@@ -1,47 +1,91 @@
 package server
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"inframap/internal/events"
+	"inframap/internal/jobs"
 	"inframap/internal/model"
 	"inframap/internal/monitoring"
+	"inframap/internal/notify"
+	"inframap/internal/sshutil"
 	"inframap/internal/storage"
+	"inframap/internal/tsdb"
 )
 
 type Config struct {
-	DataDir   string
-	BoardFile string
-	StaticDir string
-	Ping      *monitoring.PingManager
-	SSH       *monitoring.SSHStatusManager
-	Secrets   *storage.SecretStore
-	Logs      *storage.LogStore
+	DataDir    string
+	StaticDir  string
+	Boards     *storage.BoardRegistry
+	Secrets    *storage.SecretStore
+	KnownHosts *storage.KnownHostsStore
+	Facts      *storage.FactsStore
+	Metrics    *tsdb.Store
+	Alerts     *storage.AlertStore
+	Channels   *storage.ChannelStore
+	Users      *storage.UserStore
+	Logs       *storage.LogStore
+	Events     *events.Broker
 }
 
 type Server struct {
-	dataDir   string
-	boardFile string
-	staticDir string
-	ping      *monitoring.PingManager
-	ssh       *monitoring.SSHStatusManager
-	secrets   *storage.SecretStore
-	logs      *storage.LogStore
+	dataDir    string
+	staticDir  string
+	boards     *storage.BoardRegistry
+	secrets    *storage.SecretStore
+	knownHosts *storage.KnownHostsStore
+	facts      *storage.FactsStore
+	metrics    *tsdb.Store
+	alerts     *storage.AlertStore
+	channels   *storage.ChannelStore
+	notify     *notify.Manager
+	sshPool    *sshutil.Pool
+	pinger     *monitoring.Pinger
+	jobs       *jobs.Manager
+	users      *storage.UserStore
+	sessions   *sessionStore
+	logs       *storage.LogStore
+	events     *events.Broker
+	mux        *http.ServeMux
+	requests   *requestCounter
+	boardsMu   sync.RWMutex
+	wsMu       sync.Mutex
+	workspaces map[string]*workspace
 }
 
 func New(cfg Config) *Server {
 	return &Server{
-		dataDir:   cfg.DataDir,
-		boardFile: cfg.BoardFile,
-		staticDir: cfg.StaticDir,
-		ping:      cfg.Ping,
-		ssh:       cfg.SSH,
-		secrets:   cfg.Secrets,
-		logs:      cfg.Logs,
+		dataDir:    cfg.DataDir,
+		staticDir:  cfg.StaticDir,
+		boards:     cfg.Boards,
+		secrets:    cfg.Secrets,
+		knownHosts: cfg.KnownHosts,
+		facts:      cfg.Facts,
+		metrics:    cfg.Metrics,
+		alerts:     cfg.Alerts,
+		channels:   cfg.Channels,
+		notify:     notify.NewManager(notify.Config{}),
+		sshPool:    sshutil.NewPool(sshutil.PoolConfig{}),
+		pinger:     monitoring.NewPinger(),
+		jobs:       jobs.NewManager(jobs.Config{}, cfg.Events),
+		users:      cfg.Users,
+		sessions:   newSessionStore(),
+		logs:       cfg.Logs,
+		events:     cfg.Events,
+		requests:   newRequestCounter(),
+		workspaces: make(map[string]*workspace),
 	}
 }
 
@@ -49,34 +93,94 @@ func (s *Server) Routes() http.Handler {
 	mux := http.NewServeMux()
 	mux.Handle("/", http.FileServer(http.Dir(s.staticDir)))
 	mux.HandleFunc("/api/health", s.handleHealth)
+	mux.HandleFunc("/metrics", s.handlePrometheus)
+	if s.users != nil {
+		mux.HandleFunc("/api/auth/status", s.handleAuthStatus)
+		mux.HandleFunc("/api/auth/setup", s.handleAuthSetup)
+		mux.HandleFunc("/api/auth/login", s.handleLogin)
+		mux.HandleFunc("/api/auth/logout", s.handleLogout)
+		mux.HandleFunc("/api/auth/me", s.handleMe)
+		mux.HandleFunc("/api/auth/password", s.handleChangePassword)
+		mux.HandleFunc("/api/auth/tokens", s.handleTokens)
+		mux.HandleFunc("/api/auth/tokens/", s.handleTokens)
+		mux.HandleFunc("/api/users", s.handleUsers)
+		mux.HandleFunc("/api/users/", s.handleUsers)
+	}
+	mux.HandleFunc("/api/boards", s.handleBoards)
+	mux.HandleFunc("/api/boards/", s.handleBoardScoped)
 	mux.HandleFunc("/api/board", s.handleBoard)
+	mux.HandleFunc("/api/board/revisions", s.handleRevisions)
+	mux.HandleFunc("/api/board/revisions/", s.handleRevision)
 	mux.HandleFunc("/api/status", s.handleStatus)
 	mux.HandleFunc("/api/ssh-status", s.handleSSHStatus)
+	mux.HandleFunc("/api/checks", s.handleChecks)
 	mux.HandleFunc("/api/logs", s.handleLogs)
+	mux.HandleFunc("/api/events", s.handleEvents)
 	mux.HandleFunc("/api/monitoring", s.handleMonitoring)
 	mux.HandleFunc("/api/monitoring/nodes", s.handleMonitoringNodes)
 	mux.HandleFunc("/api/device-settings/", s.handleDeviceSettings)
-	return withLogging(mux)
+	mux.HandleFunc("/api/known-hosts", s.handleKnownHosts)
+	mux.HandleFunc("/api/known-hosts/", s.handleKnownHost)
+	mux.HandleFunc("/api/probes", s.handleProbes)
+	mux.HandleFunc("/api/nodes/", s.handleNode)
+	mux.HandleFunc("/api/jobs", s.handleJobs)
+	mux.HandleFunc("/api/jobs/", s.handleJob)
+	mux.HandleFunc("/api/metrics/", s.handleMetrics)
+	mux.HandleFunc("/api/reports/", s.handleReports)
+	mux.HandleFunc("/api/alerts", s.handleAlerts)
+	mux.HandleFunc("/api/alerts/", s.handleAlerts)
+	mux.HandleFunc("/api/notifications/", s.handleNotifications)
+	s.mux = mux
+	return withLogging(s.withAuth(mux), s.requests)
 }
 
 func (s *Server) Bootstrap() error {
 	if err := s.ensureDataDir(); err != nil {
 		return fmt.Errorf("failed to prepare data directory: %w", err)
 	}
-	if _, err := os.Stat(s.boardFile); err != nil {
+	if s.boards == nil {
+		return fmt.Errorf("board registry not configured")
+	}
+	if s.secrets != nil {
+		moved, err := s.secrets.MigrateUnscoped(storage.DefaultBoardID)
+		if err != nil {
+			return fmt.Errorf("failed to migrate device settings: %w", err)
+		}
+		if moved > 0 && s.logs != nil {
+			s.logs.Add("info", "settings", fmt.Sprintf("moved %d device settings into board %q", moved, storage.DefaultBoardID))
+		}
+	}
+	boards, err := s.boards.List()
+	if err != nil {
+		return fmt.Errorf("failed to list boards: %w", err)
+	}
+	var errs []error
+	for _, info := range boards {
+		if err := s.bootstrapWorkspace(s.workspace(info.ID)); err != nil {
+			errs = append(errs, fmt.Errorf("board %s: %w", info.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Server) bootstrapWorkspace(ws *workspace) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if _, err := os.Stat(ws.boardFile); err != nil {
 		if os.IsNotExist(err) {
-			if err := s.writeDefaultBoard(); err != nil {
+			if err := s.writeDefaultBoard(ws); err != nil {
 				return fmt.Errorf("failed to create default board: %w", err)
 			}
 		} else {
 			return fmt.Errorf("failed to stat board file: %w", err)
 		}
 	}
-	data, err := os.ReadFile(s.boardFile)
+	data, err := os.ReadFile(ws.boardFile)
 	if err != nil {
 		return fmt.Errorf("failed to read board file: %w", err)
 	}
-	s.updateManagerFromBytes(data)
+	s.updateManagerFromBytes(ws, data)
+	s.bootstrapRevisions(ws, data)
 	return nil
 }
 
@@ -87,7 +191,14 @@ func (s *Server) ensureDataDir() error {
 	return os.MkdirAll(s.dataDir, 0o755)
 }
 
-func (s *Server) writeDefaultBoard() error {
+func (s *Server) writeDefaultBoard(ws *workspace) error {
+	if ws.id != storage.DefaultBoardID {
+		name := ws.id
+		if info, ok, err := s.boards.Get(ws.id); err == nil && ok {
+			name = info.Name
+		}
+		return writeEmptyBoard(ws.boardFile, name)
+	}
 	defaultBoard := map[string]any{
 		"version": 1,
 		"meta": map[string]any{
@@ -148,53 +259,98 @@ func (s *Server) writeDefaultBoard() error {
 		return err
 	}
 
-	return os.WriteFile(s.boardFile, payload, 0o644)
+	return os.WriteFile(ws.boardFile, payload, 0o644)
 }
 
-func (s *Server) updateManagerFromBytes(data []byte) {
-	if s.ping == nil {
-		return
+func writeEmptyBoard(path, name string) error {
+	board := model.Board{
+		Version: 1,
+		Meta: model.BoardMeta{
+			Name:      name,
+			UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+			Monitoring: model.MonitoringSettings{
+				IntervalSec: 30,
+			},
+		},
+		Viewport: model.Viewport{Zoom: 1},
+		Nodes:    []model.Node{},
+		Links:    []model.Link{},
+	}
+	payload, err := json.MarshalIndent(board, "", "  ")
+	if err != nil {
+		return err
 	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, payload, 0o644)
+}
+
+func (s *Server) updateManagerFromBytes(ws *workspace, data []byte) {
 	var board model.Board
 	if err := json.Unmarshal(data, &board); err != nil {
 		return
 	}
-	s.ping.UpdateFromBoard(&board)
-	if s.ssh != nil {
-		s.ssh.UpdateNodes(board.Nodes)
+	s.updateManagers(ws, &board)
+}
+
+func (s *Server) updateManagers(ws *workspace, board *model.Board) {
+	if ws.ping != nil {
+		ws.ping.UpdateFromBoard(board)
+	}
+	if ws.ssh != nil {
+		ws.ssh.UpdateNodes(board.Nodes)
+	}
+	if ws.checks != nil {
+		ws.checks.UpdateNodes(board.Nodes)
+	}
+	if ws.facts != nil {
+		ws.facts.UpdateNodes(board.Nodes)
+	}
+	if ws.alerts != nil {
+		ws.alerts.UpdateNodes(board.Nodes)
 	}
 }
 
-func (s *Server) serveBoard(w http.ResponseWriter) {
+func (s *Server) serveBoard(w http.ResponseWriter, ws *workspace) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
 	if err := s.ensureDataDir(); err != nil {
 		http.Error(w, "failed to prepare data directory", http.StatusInternalServerError)
 		return
 	}
 
-	if _, err := os.Stat(s.boardFile); err != nil {
+	if _, err := os.Stat(ws.boardFile); err != nil {
 		if !os.IsNotExist(err) {
 			http.Error(w, "failed to read board file", http.StatusInternalServerError)
 			return
 		}
-		if err := s.writeDefaultBoard(); err != nil {
+		if err := s.writeDefaultBoard(ws); err != nil {
 			http.Error(w, "failed to create default board", http.StatusInternalServerError)
 			return
 		}
 	}
 
-	data, err := os.ReadFile(s.boardFile)
+	data, err := os.ReadFile(ws.boardFile)
 	if err != nil {
 		http.Error(w, "failed to read board file", http.StatusInternalServerError)
 		return
 	}
-	s.updateManagerFromBytes(data)
+	s.updateManagerFromBytes(ws, data)
 
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("ETag", boardETag(data))
+	w.Header().Set("X-Board-ID", ws.id)
+	if rev := ws.latestRevisionNumber(); rev > 0 {
+		w.Header().Set("X-Board-Revision", strconv.Itoa(rev))
+	}
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write(data)
 }
 
-func (s *Server) saveBoard(w http.ResponseWriter, r *http.Request) {
+func (s *Server) saveBoard(w http.ResponseWriter, r *http.Request, ws *workspace) {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
 	if err := s.ensureDataDir(); err != nil {
 		http.Error(w, "failed to prepare data directory", http.StatusInternalServerError)
 		return
@@ -206,33 +362,125 @@ func (s *Server) saveBoard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !json.Valid(body) {
+	var board model.Board
+	if err := json.Unmarshal(body, &board); err != nil {
 		http.Error(w, "invalid json", http.StatusBadRequest)
 		return
 	}
-	s.updateManagerFromBytes(body)
-
-	var pretty json.RawMessage
-	if err := json.Unmarshal(body, &pretty); err != nil {
-		http.Error(w, "invalid json", http.StatusBadRequest)
+	if errs := model.ValidateBoard(&board); len(errs) > 0 {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{
+			"error":  "board validation failed",
+			"errors": errs,
+		})
 		return
 	}
 
-	indented, err := json.MarshalIndent(pretty, "", "  ")
-	if err != nil {
-		http.Error(w, "failed to format json", http.StatusInternalServerError)
+	if !s.checkBoardPrecondition(w, r, ws) {
 		return
 	}
 
-	if err := os.WriteFile(s.boardFile, indented, 0o644); err != nil {
-		http.Error(w, "failed to write board file", http.StatusInternalServerError)
+	summary := strings.TrimSpace(r.Header.Get("X-Revision-Summary"))
+	rev, etag, err := s.storeBoard(ws, &board, requestAuthor(r), summary)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]string{
+	w.Header().Set("ETag", etag)
+	payload := map[string]any{
 		"status": "saved",
-		"path":   s.boardFile,
+		"board":  ws.id,
+		"path":   ws.boardFile,
+		"etag":   etag,
+	}
+	if rev != nil {
+		payload["revision"] = rev
+	}
+	writeJSON(w, http.StatusOK, payload)
+}
+
+func (s *Server) checkBoardPrecondition(w http.ResponseWriter, r *http.Request, ws *workspace) bool {
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		return true
+	}
+	current, err := os.ReadFile(ws.boardFile)
+	if os.IsNotExist(err) {
+		writeJSON(w, http.StatusPreconditionFailed, map[string]any{"error": "board does not exist yet"})
+		return false
+	}
+	if err != nil {
+		http.Error(w, "failed to read board file", http.StatusInternalServerError)
+		return false
+	}
+	currentETag := boardETag(current)
+	if etagMatches(ifMatch, currentETag) {
+		return true
+	}
+	conflict := map[string]any{
+		"error": "board was modified by someone else",
+		"etag":  currentETag,
+	}
+	if ws.revisions != nil {
+		if latest, ok, err := ws.revisions.Latest(); err == nil && ok {
+			conflict["revision"] = latest.RevisionMeta
+		}
+	}
+	w.Header().Set("ETag", currentETag)
+	writeJSON(w, http.StatusConflict, conflict)
+	return false
+}
+
+func (s *Server) storeBoard(ws *workspace, board *model.Board, author, summary string) (*storage.RevisionMeta, string, error) {
+	indented, err := json.MarshalIndent(board, "", "  ")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to format json")
+	}
+	if err := os.WriteFile(ws.boardFile, indented, 0o644); err != nil {
+		return nil, "", fmt.Errorf("failed to write board file")
+	}
+	s.updateManagers(ws, board)
+	rev := s.recordRevision(ws, board, author, summary)
+	etag := boardETag(indented)
+	if s.boards != nil {
+		_ = s.boards.Touch(ws.id)
+	}
+	s.publish("board", map[string]any{
+		"board":    ws.id,
+		"etag":     etag,
+		"revision": rev,
+		"author":   author,
 	})
+	return rev, etag, nil
+}
+
+func (ws *workspace) latestRevisionNumber() int {
+	if ws.revisions == nil {
+		return 0
+	}
+	latest, ok, err := ws.revisions.Latest()
+	if err != nil || !ok {
+		return 0
+	}
+	return latest.Number
+}
+
+func boardETag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:12]) + `"`
+}
+
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if !strings.HasPrefix(candidate, "W/") && candidate == etag {
+			return true
+		}
+	}
+	return false
 }
 
 func writeJSON(w http.ResponseWriter, status int, payload any) {
@@ -243,12 +491,13 @@ func writeJSON(w http.ResponseWriter, status int, payload any) {
 	}
 }
 
-func withLogging(next http.Handler) http.Handler {
+func withLogging(next http.Handler, requests *requestCounter) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
 		start := time.Now()
 		next.ServeHTTP(rec, r)
 		duration := time.Since(start)
+		requests.observe(r.Method, rec.status)
 		fmt.Printf("%s %s %d %s\n", r.Method, r.URL.Path, rec.status, duration.Round(time.Millisecond))
 	})
 }
@@ -269,3 +518,7 @@ func (s *statusRecorder) Write(p []byte) (int, error) {
 	}
 	return s.ResponseWriter.Write(p)
 }
+
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"inframap/internal/storage"
+)
+
+func newTestServer(t *testing.T, users *storage.UserStore) (*Server, http.Handler) {
+	t.Helper()
+	return newTestServerConfig(t, Config{Users: users})
+}
+
+func newTestServerConfig(t *testing.T, cfg Config) (*Server, http.Handler) {
+	t.Helper()
+	dir := t.TempDir()
+	boards, err := storage.NewBoardRegistry(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.DataDir, cfg.Boards = dir, boards
+	srv := New(cfg)
+	if err := srv.Bootstrap(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		srv.wsMu.Lock()
+		var ids []string
+		for id := range srv.workspaces {
+			ids = append(ids, id)
+		}
+		srv.wsMu.Unlock()
+		for _, id := range ids {
+			srv.closeWorkspace(id)
+		}
+		srv.sshPool.Close()
+	})
+	return srv, srv.Routes()
+}
+
+type testRequest struct {
+	method  string
+	path    string
+	body    string
+	headers map[string]string
+	cookies []*http.Cookie
+}
+
+func serve(h http.Handler, req testRequest) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(req.method, req.path, strings.NewReader(req.body))
+	for key, value := range req.headers {
+		r.Header.Set(key, value)
+	}
+	for _, cookie := range req.cookies {
+		r.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	return rec
+}
+
+func decode(t *testing.T, rec *httptest.ResponseRecorder) map[string]any {
+	t.Helper()
+	var out map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		t.Fatalf("decode %q: %v", rec.Body.String(), err)
+	}
+	return out
+}
+
+func TestBoardSavePrecondition(t *testing.T) {
+	srv, h := newTestServer(t, nil)
+	get := serve(h, testRequest{method: http.MethodGet, path: "/api/board"})
+	etag := get.Header().Get("ETag")
+	if get.Code != http.StatusOK || etag == "" {
+		t.Fatalf("GET /api/board = %d etag %q", get.Code, etag)
+	}
+	board := get.Body.String()
+
+	tests := []struct {
+		name    string
+		ifMatch string
+		want    int
+	}{
+		{"weak tag", "W/" + etag, http.StatusConflict},
+		{"stale tag", `"0000"`, http.StatusConflict},
+		{"no precondition", "", http.StatusOK},
+		{"wildcard", "*", http.StatusOK},
+	}
+	for _, tt := range tests {
+		headers := map[string]string{}
+		if tt.ifMatch != "" {
+			headers["If-Match"] = tt.ifMatch
+		}
+		rec := serve(h, testRequest{method: http.MethodPost, path: "/api/board", body: board, headers: headers})
+		if rec.Code != tt.want {
+			t.Fatalf("%s: POST = %d %s, want %d", tt.name, rec.Code, rec.Body.String(), tt.want)
+		}
+		if rec.Code == http.StatusConflict && decode(t, rec)["etag"] == nil {
+			t.Fatalf("%s: conflict without current etag", tt.name)
+		}
+	}
+
+	current := serve(h, testRequest{method: http.MethodGet, path: "/api/board"}).Header().Get("ETag")
+	rec := serve(h, testRequest{method: http.MethodPost, path: "/api/board", body: board, headers: map[string]string{"If-Match": `"other", ` + current}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("matching tag in list = %d %s", rec.Code, rec.Body.String())
+	}
+
+	if err := os.Remove(srv.workspace(storage.DefaultBoardID).boardFile); err != nil {
+		t.Fatal(err)
+	}
+	rec = serve(h, testRequest{method: http.MethodPost, path: "/api/board", body: board, headers: map[string]string{"If-Match": "*"}})
+	if rec.Code != http.StatusPreconditionFailed {
+		t.Fatalf("wildcard on missing board = %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestEtagMatches(t *testing.T) {
+	tests := []struct {
+		header string
+		want   bool
+	}{
+		{`"abc"`, true},
+		{`"x", "abc"`, true},
+		{`W/"abc"`, false},
+		{`"abd"`, false},
+		{`*`, true},
+	}
+	for _, tt := range tests {
+		if got := etagMatches(tt.header, `"abc"`); got != tt.want {
+			t.Errorf("etagMatches(%q) = %t, want %t", tt.header, got, tt.want)
+		}
+	}
+}
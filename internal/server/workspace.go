@@ -0,0 +1,216 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"inframap/internal/model"
+	"inframap/internal/monitoring"
+	"inframap/internal/sshutil"
+	"inframap/internal/storage"
+	"inframap/internal/tsdb"
+)
+
+type workspace struct {
+	mu         sync.Mutex
+	id         string
+	boardFile  string
+	revisions  *storage.RevisionStore
+	ping       *monitoring.PingManager
+	ssh        *monitoring.SSHStatusManager
+	checks     *monitoring.CheckManager
+	secrets    *storage.ScopedSecrets
+	knownHosts *storage.ScopedKnownHosts
+	factStore  *storage.ScopedFacts
+	facts      *monitoring.FactsManager
+	metrics    *tsdb.Scoped
+	alertStore *storage.ScopedAlerts
+	channels   *storage.ScopedChannels
+	alerts     *monitoring.AlertManager
+}
+
+type boardContextKey struct{}
+
+func (s *Server) workspace(id string) *workspace {
+	s.wsMu.Lock()
+	defer s.wsMu.Unlock()
+	if ws, ok := s.workspaces[id]; ok {
+		return ws
+	}
+	ws := &workspace{
+		id:        id,
+		boardFile: s.boards.BoardFile(id),
+	}
+	revisions, err := storage.NewRevisionStore(s.boards.RevisionsDir(id), 200)
+	if err != nil {
+		if s.logs != nil {
+			s.logs.Add("warn", "board", fmt.Sprintf("revision history disabled for board %s: %v", id, err))
+		}
+	} else {
+		ws.revisions = revisions
+	}
+	logger := scopedLogger{board: id, logger: s.logs}
+	publisher := scopedPublisher{board: id, events: s.events}
+	var recorder monitoring.Recorders
+	if s.metrics != nil {
+		ws.metrics = s.metrics.Scope(id)
+		recorder = append(recorder, ws.metrics)
+	}
+	if s.alerts != nil {
+		ws.alertStore = s.alerts.Scope(id)
+		var notifier monitoring.AlertNotifier
+		if s.channels != nil {
+			ws.channels = s.channels.Scope(id)
+			notifier = alertNotifier{board: id, channels: ws.channels, notify: s.notify, logger: logger}
+		}
+		ws.alerts = monitoring.NewAlertManager(ws.alertStore, notifier, logger, publisher)
+		recorder = append(recorder, ws.alerts)
+	}
+	ws.ping = monitoring.NewPingManager(s.pinger, logger, publisher, recorder)
+	var checkRecorder monitoring.CheckRecorder
+	if ws.metrics != nil {
+		checkRecorder = ws.metrics
+	}
+	ws.checks = monitoring.NewCheckManager(logger, publisher, checkRecorder)
+	var hostKeys sshutil.HostKeyStore
+	if s.knownHosts != nil {
+		ws.knownHosts = s.knownHosts.Scope(id)
+		hostKeys = ws.knownHosts
+	}
+	if s.secrets != nil {
+		ws.secrets = s.secrets.Scope(id)
+		ws.ssh = monitoring.NewSSHStatusManager(ws.secrets, s.sshPool, id+"/", hostKeys, logger, publisher, recorder)
+		if s.facts != nil {
+			ws.factStore = s.facts.Scope(id)
+			ws.facts = monitoring.NewFactsManager(monitoring.FactsConfig{}, ws.secrets, s.sshPool, id+"/", hostKeys, ws.factStore, logger, publisher)
+		}
+	}
+	s.workspaces[id] = ws
+	return ws
+}
+
+func (ws *workspace) sshTarget(device string, settings model.DeviceSettings) (sshutil.Target, error) {
+	resolver := sshutil.Resolver{Scope: ws.id + "/"}
+	if ws.secrets != nil {
+		resolver.Settings = ws.secrets
+	}
+	if ws.knownHosts != nil {
+		resolver.HostKeys = ws.knownHosts
+	}
+	return resolver.Target(device, settings)
+}
+
+func (s *Server) detectLinkSpeed(ctx context.Context, ws *workspace, device string, settings model.DeviceSettings) (int, string, error) {
+	target, err := ws.sshTarget(device, settings)
+	if err != nil {
+		return 0, "", err
+	}
+	return s.sshPool.DetectLinkSpeed(ctx, target, 8*time.Second)
+}
+
+func (s *Server) detectTailscaleIP(ctx context.Context, ws *workspace, device string, settings model.DeviceSettings) (string, error) {
+	target, err := ws.sshTarget(device, settings)
+	if err != nil {
+		return "", err
+	}
+	return s.sshPool.DetectTailscaleIP(ctx, target, 6*time.Second)
+}
+
+func (s *Server) dropSSH(ws *workspace, device string) {
+	s.sshPool.Evict(ws.id + "/" + device)
+}
+
+func (s *Server) closeWorkspace(id string) {
+	s.wsMu.Lock()
+	ws, ok := s.workspaces[id]
+	delete(s.workspaces, id)
+	s.wsMu.Unlock()
+	if !ok {
+		return
+	}
+	if ws.ping != nil {
+		ws.ping.Stop()
+	}
+	if ws.ssh != nil {
+		ws.ssh.Stop()
+	}
+	if ws.checks != nil {
+		ws.checks.Stop()
+	}
+	if ws.facts != nil {
+		ws.facts.Stop()
+	}
+	s.jobs.CancelBoard(id)
+	s.sshPool.EvictPrefix(id + "/")
+}
+
+func (s *Server) workspaceFor(w http.ResponseWriter, r *http.Request) (*workspace, bool) {
+	id, _ := r.Context().Value(boardContextKey{}).(string)
+	if id == "" {
+		id = strings.TrimSpace(r.URL.Query().Get("board"))
+	}
+	if id == "" {
+		id = storage.DefaultBoardID
+	}
+	if s.boards == nil {
+		http.Error(w, "board registry not available", http.StatusInternalServerError)
+		return nil, false
+	}
+	ws, ok, err := s.existingWorkspace(id)
+	if err != nil {
+		http.Error(w, "failed to read boards", http.StatusInternalServerError)
+		return nil, false
+	}
+	if !ok {
+		http.Error(w, "board not found", http.StatusNotFound)
+		return nil, false
+	}
+	return ws, true
+}
+
+func (s *Server) existingWorkspace(id string) (*workspace, bool, error) {
+	s.boardsMu.RLock()
+	defer s.boardsMu.RUnlock()
+	if _, ok, err := s.boards.Get(id); err != nil || !ok {
+		return nil, false, err
+	}
+	return s.workspace(id), true, nil
+}
+
+func withBoard(r *http.Request, id string) *http.Request {
+	return r.Clone(context.WithValue(r.Context(), boardContextKey{}, id))
+}
+
+type scopedLogger struct {
+	board  string
+	logger *storage.LogStore
+}
+
+func (l scopedLogger) Add(level, source, message string) {
+	if l.logger == nil {
+		return
+	}
+	if l.board != storage.DefaultBoardID {
+		message = "[" + l.board + "] " + message
+	}
+	l.logger.Add(level, source, message)
+}
+
+type scopedPublisher struct {
+	board  string
+	events monitoring.EventPublisher
+}
+
+func (p scopedPublisher) Publish(kind string, payload any) {
+	if p.events == nil {
+		return
+	}
+	if fields, ok := payload.(map[string]any); ok {
+		fields["board"] = p.board
+	}
+	p.events.Publish(kind, payload)
+}
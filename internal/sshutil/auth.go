@@ -0,0 +1,100 @@
+package sshutil
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+
+	"inframap/internal/model"
+)
+
+const (
+	AuthPassword            = "password"
+	AuthKey                 = "ssh_key"
+	AuthAgent               = "agent"
+	AuthCertificate         = "certificate"
+	AuthKeyboardInteractive = "keyboard-interactive"
+)
+
+func ValidAuthMethod(method string) bool {
+	switch method {
+	case AuthPassword, AuthKey, AuthAgent, AuthCertificate, AuthKeyboardInteractive:
+		return true
+	default:
+		return false
+	}
+}
+
+func buildAuth(settings model.DeviceSettings) ([]ssh.AuthMethod, func(), error) {
+	method := strings.ToLower(strings.TrimSpace(settings.AuthMethod))
+	if method == "" {
+		method = AuthPassword
+	}
+	noop := func() {}
+	switch method {
+	case AuthKey:
+		signer, err := parseSigner(settings)
+		if err != nil {
+			return nil, nil, err
+		}
+		return []ssh.AuthMethod{ssh.PublicKeys(signer)}, noop, nil
+	case AuthCertificate:
+		signer, err := certSigner(settings)
+		if err != nil {
+			return nil, nil, err
+		}
+		return []ssh.AuthMethod{ssh.PublicKeys(signer)}, noop, nil
+	case AuthAgent:
+		conn, err := dialAgent()
+		if err != nil {
+			return nil, nil, err
+		}
+		client := agent.NewClient(conn)
+		return []ssh.AuthMethod{ssh.PublicKeysCallback(client.Signers)}, func() { _ = conn.Close() }, nil
+	case AuthKeyboardInteractive:
+		if settings.Password == "" {
+			return nil, nil, errors.New("password is empty")
+		}
+		password := settings.Password
+		answer := ssh.KeyboardInteractive(func(_, _ string, questions []string, _ []bool) ([]string, error) {
+			answers := make([]string, len(questions))
+			for i := range questions {
+				answers[i] = password
+			}
+			return answers, nil
+		})
+		return []ssh.AuthMethod{answer, ssh.Password(password)}, noop, nil
+	case AuthPassword:
+		if settings.Password == "" {
+			return nil, nil, errors.New("password is empty")
+		}
+		return []ssh.AuthMethod{ssh.Password(settings.Password)}, noop, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported auth method %q", method)
+	}
+}
+
+func AgentAvailable() error {
+	conn, err := dialAgent()
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+func dialAgent() (net.Conn, error) {
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return nil, errors.New("ssh-agent not available: SSH_AUTH_SOCK is not set for the InfraMap server")
+	}
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, fmt.Errorf("ssh-agent not available: %w", err)
+	}
+	return conn, nil
+}
@@ -0,0 +1,89 @@
+package sshutil
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"inframap/internal/model"
+)
+
+type HostKeyStore interface {
+	Get(device string) (model.KnownHost, bool, error)
+	Pin(device string, key model.HostKey) error
+	Offer(device string, key model.HostKey) error
+}
+
+type HostKeyVerifier struct {
+	Store  HostKeyStore
+	Device string
+}
+
+type HostKeyMismatchError struct {
+	Device   string
+	Address  string
+	Expected string
+	Got      string
+}
+
+func (e *HostKeyMismatchError) Error() string {
+	return fmt.Sprintf("host key mismatch for %s (%s): pinned %s, device presented %s; accept the new key only if the device was reinstalled", e.Device, e.Address, e.Expected, e.Got)
+}
+
+func IsHostKeyMismatch(err error) bool {
+	var mismatch *HostKeyMismatchError
+	return errors.As(err, &mismatch)
+}
+
+func (v HostKeyVerifier) Callback() ssh.HostKeyCallback {
+	return func(hostname string, _ net.Addr, key ssh.PublicKey) error {
+		if v.Store == nil || v.Device == "" {
+			return errors.New("host key verification unavailable")
+		}
+		presented := model.HostKey{
+			Address:     hostname,
+			Type:        key.Type(),
+			Fingerprint: ssh.FingerprintSHA256(key),
+			Key:         base64.StdEncoding.EncodeToString(key.Marshal()),
+			SeenAt:      time.Now().UTC().Format(time.RFC3339),
+		}
+		known, ok, err := v.Store.Get(v.Device)
+		if err != nil {
+			return fmt.Errorf("failed to read known hosts: %w", err)
+		}
+		if !ok || known.Pinned == nil {
+			if err := v.Store.Pin(v.Device, presented); err != nil {
+				return fmt.Errorf("failed to pin host key: %w", err)
+			}
+			return nil
+		}
+		if known.Pinned.Key == presented.Key {
+			return nil
+		}
+		_ = v.Store.Offer(v.Device, presented)
+		return &HostKeyMismatchError{
+			Device:   v.Device,
+			Address:  hostname,
+			Expected: known.Pinned.Fingerprint,
+			Got:      presented.Fingerprint,
+		}
+	}
+}
+
+func (v HostKeyVerifier) Algorithms() []string {
+	if v.Store == nil || v.Device == "" {
+		return nil
+	}
+	known, ok, err := v.Store.Get(v.Device)
+	if err != nil || !ok || known.Pinned == nil {
+		return nil
+	}
+	if known.Pinned.Type == ssh.KeyAlgoRSA {
+		return []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA}
+	}
+	return []string{known.Pinned.Type}
+}
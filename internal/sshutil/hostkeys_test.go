@@ -0,0 +1,84 @@
+package sshutil
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+
+	"inframap/internal/storage"
+)
+
+func testHostKey(t *testing.T) ssh.PublicKey {
+	t.Helper()
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestHostKeyVerifierTrustOnFirstUse(t *testing.T) {
+	store, err := storage.NewKnownHostsStore(filepath.Join(t.TempDir(), "known_hosts.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	hosts := store.Scope("main")
+	verifier := HostKeyVerifier{Store: hosts, Device: "node-1"}
+	callback := verifier.Callback()
+	original, replaced := testHostKey(t), testHostKey(t)
+
+	if algos := verifier.Algorithms(); algos != nil {
+		t.Fatalf("algorithms before pin = %v", algos)
+	}
+	if err := callback("10.0.0.1:22", nil, original); err != nil {
+		t.Fatalf("first connection: %v", err)
+	}
+	known, ok, _ := hosts.Get("node-1")
+	if !ok || known.Pinned == nil || known.Pinned.Fingerprint != ssh.FingerprintSHA256(original) || known.Pinned.Address != "10.0.0.1:22" {
+		t.Fatalf("pinned = %+v", known)
+	}
+	if algos := verifier.Algorithms(); len(algos) != 1 || algos[0] != ssh.KeyAlgoED25519 {
+		t.Fatalf("algorithms after pin = %v", algos)
+	}
+	if err := callback("10.0.0.1:22", nil, original); err != nil {
+		t.Fatalf("same key: %v", err)
+	}
+
+	err = callback("10.0.0.1:22", nil, replaced)
+	if !IsHostKeyMismatch(err) {
+		t.Fatalf("changed key err = %v", err)
+	}
+	known, _, _ = hosts.Get("node-1")
+	if known.Pinned.Fingerprint != ssh.FingerprintSHA256(original) || known.Offered == nil || known.Offered.Fingerprint != ssh.FingerprintSHA256(replaced) {
+		t.Fatalf("after mismatch = %+v", known)
+	}
+	if err := callback("10.0.0.1:22", nil, replaced); !IsHostKeyMismatch(err) {
+		t.Fatalf("repeated mismatch err = %v", err)
+	}
+
+	if _, err := hosts.Accept("node-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := callback("10.0.0.1:22", nil, replaced); err != nil {
+		t.Fatalf("accepted key: %v", err)
+	}
+	if err := callback("10.0.0.1:22", nil, original); !IsHostKeyMismatch(err) {
+		t.Fatalf("old key after accept err = %v", err)
+	}
+
+	other := HostKeyVerifier{Store: store.Scope("lab"), Device: "node-1"}
+	if err := other.Callback()("10.0.0.1:22", nil, original); err != nil {
+		t.Fatalf("other board pins separately: %v", err)
+	}
+
+	if err := (HostKeyVerifier{Store: hosts}).Callback()("10.0.0.1:22", nil, original); err == nil || IsHostKeyMismatch(err) {
+		t.Fatalf("verifier without device err = %v", err)
+	}
+}
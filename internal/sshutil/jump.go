@@ -0,0 +1,108 @@
+package sshutil
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"inframap/internal/model"
+)
+
+const maxJumpDepth = 5
+
+type SettingsLookup interface {
+	Get(id string) (model.DeviceSettings, bool, error)
+}
+
+type Resolver struct {
+	Scope    string
+	Settings SettingsLookup
+	HostKeys HostKeyStore
+}
+
+func (r Resolver) Target(device string, settings model.DeviceSettings) (Target, error) {
+	return r.resolve(device, settings, []string{device})
+}
+
+func (r Resolver) resolve(device string, settings model.DeviceSettings, path []string) (Target, error) {
+	target := Target{
+		ID:       r.Scope + device,
+		Settings: settings,
+		HostKeys: HostKeyVerifier{Store: r.HostKeys, Device: device},
+	}
+	if len(settings.JumpHosts) == 0 {
+		return target, nil
+	}
+	if len(path)+len(settings.JumpHosts) > maxJumpDepth+1 {
+		return Target{}, fmt.Errorf("jump host chain for %s is longer than %d hops", path[0], maxJumpDepth)
+	}
+	var via *Target
+	for _, jump := range settings.JumpHosts {
+		hop, err := r.jumpTarget(jump, settings, path)
+		if err != nil {
+			return Target{}, err
+		}
+		if via != nil {
+			hop = chainTarget(hop, via)
+		}
+		via = &hop
+	}
+	target.Via = via
+	return target, nil
+}
+
+func (r Resolver) jumpTarget(jump model.JumpHost, owner model.DeviceSettings, path []string) (Target, error) {
+	if jump.Device != "" {
+		for _, seen := range path {
+			if seen == jump.Device {
+				return Target{}, fmt.Errorf("jump host loop: %s -> %s", strings.Join(path, " -> "), jump.Device)
+			}
+		}
+		if r.Settings == nil {
+			return Target{}, fmt.Errorf("jump host %s: settings unavailable", jump.Device)
+		}
+		settings, ok, err := r.Settings.Get(jump.Device)
+		if err != nil {
+			return Target{}, fmt.Errorf("jump host %s: %w", jump.Device, err)
+		}
+		if !ok {
+			return Target{}, fmt.Errorf("jump host %s has no SSH settings", jump.Device)
+		}
+		return r.resolve(jump.Device, settings, append(append([]string{}, path...), jump.Device))
+	}
+
+	host := strings.TrimSpace(jump.Host)
+	if host == "" {
+		return Target{}, fmt.Errorf("jump host for %s has neither device nor host", path[len(path)-1])
+	}
+	port := jump.Port
+	if port == 0 {
+		port = 22
+	}
+	settings := model.DeviceSettings{
+		Host:       host,
+		Port:       port,
+		AuthMethod: AuthAgent,
+		Username:   owner.Username,
+	}
+	if jump.Username != "" {
+		settings.Username = jump.Username
+	}
+	key := "jump:" + net.JoinHostPort(host, strconv.Itoa(port))
+	return Target{
+		ID:       r.Scope + key,
+		Settings: settings,
+		HostKeys: HostKeyVerifier{Store: r.HostKeys, Device: key},
+	}, nil
+}
+
+func chainTarget(target Target, via *Target) Target {
+	if target.Via == nil {
+		target.Via = via
+		return target
+	}
+	parent := chainTarget(*target.Via, via)
+	target.Via = &parent
+	return target
+}
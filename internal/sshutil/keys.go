@@ -0,0 +1,111 @@
+package sshutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"inframap/internal/model"
+)
+
+func parseSigner(settings model.DeviceSettings) (ssh.Signer, error) {
+	key := strings.TrimSpace(settings.PrivateKey)
+	if key == "" {
+		return nil, errors.New("private key is empty")
+	}
+	if settings.PrivateKeyPassphrase != "" {
+		return ssh.ParsePrivateKeyWithPassphrase([]byte(key), []byte(settings.PrivateKeyPassphrase))
+	}
+	return ssh.ParsePrivateKey([]byte(key))
+}
+
+func KeyFingerprint(settings model.DeviceSettings) (string, error) {
+	signer, err := parseSigner(settings)
+	if err != nil {
+		return "", err
+	}
+	return ssh.FingerprintSHA256(signer.PublicKey()), nil
+}
+
+type CertificateInfo struct {
+	KeyID       string   `json:"keyId"`
+	Principals  []string `json:"principals"`
+	ValidAfter  string   `json:"validAfter,omitempty"`
+	ValidBefore string   `json:"validBefore,omitempty"`
+	Expired     bool     `json:"expired"`
+	Fingerprint string   `json:"fingerprint"`
+}
+
+func ParseCertificate(text string) (*ssh.Certificate, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, errors.New("certificate is empty")
+	}
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(text))
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate: %w", err)
+	}
+	cert, ok := pub.(*ssh.Certificate)
+	if !ok {
+		return nil, errors.New("invalid certificate: expected an OpenSSH certificate (*-cert.pub), got a plain public key")
+	}
+	if cert.CertType != ssh.UserCert {
+		return nil, errors.New("invalid certificate: not a user certificate")
+	}
+	return cert, nil
+}
+
+func DescribeCertificate(text string) (CertificateInfo, error) {
+	cert, err := ParseCertificate(text)
+	if err != nil {
+		return CertificateInfo{}, err
+	}
+	info := CertificateInfo{
+		KeyID:       cert.KeyId,
+		Principals:  cert.ValidPrincipals,
+		Fingerprint: ssh.FingerprintSHA256(cert.Key),
+	}
+	if cert.ValidAfter != 0 {
+		info.ValidAfter = certTime(cert.ValidAfter).Format(time.RFC3339)
+	}
+	if cert.ValidBefore != ssh.CertTimeInfinity {
+		before := certTime(cert.ValidBefore)
+		info.ValidBefore = before.Format(time.RFC3339)
+		info.Expired = time.Now().After(before)
+	}
+	return info, nil
+}
+
+func CheckCertificate(settings model.DeviceSettings) error {
+	_, err := certSigner(settings)
+	return err
+}
+
+func certSigner(settings model.DeviceSettings) (ssh.Signer, error) {
+	cert, err := ParseCertificate(settings.Certificate)
+	if err != nil {
+		return nil, err
+	}
+	if cert.ValidBefore != ssh.CertTimeInfinity && time.Now().After(certTime(cert.ValidBefore)) {
+		return nil, fmt.Errorf("certificate %q expired at %s", cert.KeyId, certTime(cert.ValidBefore).Format(time.RFC3339))
+	}
+	signer, err := parseSigner(settings)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(cert.Key.Marshal(), signer.PublicKey().Marshal()) {
+		return nil, errors.New("certificate does not match the private key")
+	}
+	return ssh.NewCertSigner(cert, signer)
+}
+
+func certTime(value uint64) time.Time {
+	if value > 1<<63-1 {
+		value = 1<<63 - 1
+	}
+	return time.Unix(int64(value), 0).UTC()
+}
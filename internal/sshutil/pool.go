@@ -0,0 +1,466 @@
+package sshutil
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"inframap/internal/model"
+)
+
+type Target struct {
+	ID       string
+	Settings model.DeviceSettings
+	HostKeys HostKeyVerifier
+	Via      *Target
+}
+
+type PoolConfig struct {
+	DialTimeout    time.Duration
+	CommandTimeout time.Duration
+	IdleTimeout    time.Duration
+	KeepAlive      time.Duration
+}
+
+type Pool struct {
+	mu       sync.Mutex
+	cfg      PoolConfig
+	clients  map[string]*pooledClient
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+type pooledClient struct {
+	key      string
+	id       string
+	client   *ssh.Client
+	via      *Client
+	err      error
+	refs     int
+	lastUsed time.Time
+	ready    chan struct{}
+	done     chan struct{}
+}
+
+type Client struct {
+	pool   *Pool
+	pc     *pooledClient
+	reused bool
+	ctx    context.Context
+}
+
+func NewPool(cfg PoolConfig) *Pool {
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = 6 * time.Second
+	}
+	if cfg.CommandTimeout <= 0 {
+		cfg.CommandTimeout = 10 * time.Second
+	}
+	if cfg.IdleTimeout <= 0 {
+		cfg.IdleTimeout = 5 * time.Minute
+	}
+	if cfg.KeepAlive <= 0 {
+		cfg.KeepAlive = 30 * time.Second
+	}
+	p := &Pool{
+		cfg:     cfg,
+		clients: make(map[string]*pooledClient),
+		stopCh:  make(chan struct{}),
+	}
+	go p.janitor()
+	return p
+}
+
+func (p *Pool) Acquire(target Target, timeout time.Duration) (*Client, error) {
+	if timeout <= 0 {
+		timeout = p.cfg.DialTimeout
+	}
+	key := poolKey(target)
+
+	p.mu.Lock()
+	pc, ok := p.clients[key]
+	if ok && pc.dead() {
+		delete(p.clients, key)
+		ok = false
+	}
+	if ok {
+		pc.refs++
+		p.mu.Unlock()
+		select {
+		case <-pc.ready:
+		case <-time.After(timeout):
+			p.release(pc)
+			return nil, fmt.Errorf("timed out waiting for ssh connection to %s", target.Settings.Host)
+		}
+		if pc.err != nil {
+			p.release(pc)
+			return nil, pc.err
+		}
+		return &Client{pool: p, pc: pc, reused: true}, nil
+	}
+	pc = &pooledClient{
+		key:   key,
+		id:    target.ID,
+		refs:  1,
+		ready: make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	p.clients[key] = pc
+	p.mu.Unlock()
+
+	pc.client, pc.via, pc.err = p.dial(target, timeout)
+	if pc.err != nil {
+		close(pc.done)
+		close(pc.ready)
+		p.release(pc)
+		return nil, pc.err
+	}
+	close(pc.ready)
+	go p.watch(pc)
+	return &Client{pool: p, pc: pc}, nil
+}
+
+func (p *Pool) Evict(id string) {
+	p.evict(func(pc *pooledClient) bool { return pc.id == id })
+}
+
+func (p *Pool) EvictPrefix(prefix string) {
+	p.evict(func(pc *pooledClient) bool { return strings.HasPrefix(pc.id, prefix) })
+}
+
+func (p *Pool) Close() {
+	p.stopOnce.Do(func() {
+		close(p.stopCh)
+	})
+	p.evict(func(*pooledClient) bool { return true })
+}
+
+func (p *Pool) evict(match func(pc *pooledClient) bool) {
+	p.mu.Lock()
+	var victims []*pooledClient
+	for key, pc := range p.clients {
+		if match(pc) {
+			delete(p.clients, key)
+			victims = append(victims, pc)
+		}
+	}
+	p.mu.Unlock()
+	for _, pc := range victims {
+		pc.close()
+	}
+}
+
+func (p *Pool) release(pc *pooledClient) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pc.refs--
+	pc.lastUsed = time.Now()
+	if pc.err != nil && pc.refs <= 0 && p.clients[pc.key] == pc {
+		delete(p.clients, pc.key)
+	}
+}
+
+func (p *Pool) discard(pc *pooledClient) {
+	p.mu.Lock()
+	if p.clients[pc.key] == pc {
+		delete(p.clients, pc.key)
+	}
+	p.mu.Unlock()
+	pc.close()
+}
+
+func (p *Pool) watch(pc *pooledClient) {
+	go func() {
+		_ = pc.client.Wait()
+		p.finish(pc)
+		close(pc.done)
+	}()
+	ticker := time.NewTicker(p.cfg.KeepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-pc.done:
+			p.discard(pc)
+			return
+		case <-ticker.C:
+			if err := pc.ping(p.cfg.DialTimeout); err != nil {
+				p.discard(pc)
+				return
+			}
+		}
+	}
+}
+
+func (p *Pool) janitor() {
+	interval := p.cfg.IdleTimeout / 2
+	if interval > 30*time.Second {
+		interval = 30 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stopCh:
+			return
+		case <-ticker.C:
+			cutoff := time.Now().Add(-p.cfg.IdleTimeout)
+			p.evict(func(pc *pooledClient) bool {
+				return pc.refs == 0 && pc.lastUsed.Before(cutoff)
+			})
+		}
+	}
+}
+
+func (pc *pooledClient) dead() bool {
+	select {
+	case <-pc.ready:
+	default:
+		return false
+	}
+	select {
+	case <-pc.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (pc *pooledClient) close() {
+	if pc.client != nil {
+		_ = pc.client.Close()
+	}
+}
+
+func (p *Pool) finish(pc *pooledClient) {
+	p.mu.Lock()
+	via := pc.via
+	pc.via = nil
+	p.mu.Unlock()
+	if via != nil {
+		via.Release()
+	}
+}
+
+func (pc *pooledClient) ping(timeout time.Duration) error {
+	result := make(chan error, 1)
+	go func() {
+		_, _, err := pc.client.SendRequest("keepalive@openssh.com", true, nil)
+		result <- err
+	}()
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(timeout):
+		return errors.New("ssh keepalive timed out")
+	}
+}
+
+func (c *Client) Release() {
+	c.pool.release(c.pc)
+}
+
+func (c *Client) Ping(timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = c.pool.cfg.DialTimeout
+	}
+	if err := c.pc.ping(timeout); err != nil {
+		c.pool.discard(c.pc)
+		return err
+	}
+	return nil
+}
+
+func (c *Client) Run(command string, timeout time.Duration) (string, error) {
+	if timeout <= 0 {
+		timeout = c.pool.cfg.CommandTimeout
+	}
+	session, err := c.pc.client.NewSession()
+	if err != nil {
+		c.pool.discard(c.pc)
+		return "", err
+	}
+	defer session.Close()
+	type result struct {
+		output []byte
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		output, err := session.CombinedOutput(command)
+		done <- result{output: output, err: err}
+	}()
+	ctx := c.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	select {
+	case res := <-done:
+		if res.err != nil {
+			return "", res.err
+		}
+		return string(res.output), nil
+	case <-time.After(timeout):
+		_ = session.Close()
+		return "", fmt.Errorf("command timed out after %s", timeout)
+	case <-ctx.Done():
+		_ = session.Close()
+		return "", ctx.Err()
+	}
+}
+
+func (p *Pool) session(ctx context.Context, target Target, timeout time.Duration) (*Client, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	client, err := p.Acquire(target, timeout)
+	if err != nil {
+		return nil, err
+	}
+	if client.reused {
+		if err := client.Ping(timeout); err != nil {
+			client.Release()
+			if client, err = p.Acquire(target, timeout); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		client.Release()
+		return nil, err
+	}
+	client.ctx = ctx
+	return client, nil
+}
+
+func clientConfig(target Target) (*ssh.ClientConfig, string, func(), error) {
+	settings := target.Settings
+	host := strings.TrimSpace(settings.Host)
+	if host == "" {
+		return nil, "", nil, errors.New("host is empty")
+	}
+	user := strings.TrimSpace(settings.Username)
+	if user == "" {
+		return nil, "", nil, errors.New("username is empty")
+	}
+	port := settings.Port
+	if port == 0 {
+		port = 22
+	}
+	auth, cleanup, err := buildAuth(settings)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	config := &ssh.ClientConfig{
+		User:              user,
+		Auth:              auth,
+		HostKeyCallback:   target.HostKeys.Callback(),
+		HostKeyAlgorithms: target.HostKeys.Algorithms(),
+	}
+	return config, net.JoinHostPort(host, strconv.Itoa(port)), cleanup, nil
+}
+
+func (p *Pool) dial(target Target, timeout time.Duration) (*ssh.Client, *Client, error) {
+	config, addr, cleanup, err := clientConfig(target)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cleanup()
+	if target.Via == nil {
+		conn, err := net.DialTimeout("tcp", addr, timeout)
+		if err != nil {
+			return nil, nil, err
+		}
+		client, err := handshake(conn, addr, config, timeout)
+		return client, nil, err
+	}
+	via, err := p.session(context.Background(), *target.Via, timeout)
+	if err != nil {
+		return nil, nil, fmt.Errorf("jump host %s: %w", target.Via.Settings.Host, err)
+	}
+	conn, err := dialVia(via, addr, timeout)
+	if err != nil {
+		via.Release()
+		return nil, nil, fmt.Errorf("dial %s via %s: %w", addr, target.Via.Settings.Host, err)
+	}
+	client, err := handshake(conn, addr, config, timeout)
+	if err != nil {
+		via.Release()
+		return nil, nil, err
+	}
+	return client, via, nil
+}
+
+func dialVia(via *Client, addr string, timeout time.Duration) (net.Conn, error) {
+	type result struct {
+		conn net.Conn
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		conn, err := via.pc.client.Dial("tcp", addr)
+		done <- result{conn: conn, err: err}
+	}()
+	select {
+	case res := <-done:
+		return res.conn, res.err
+	case <-time.After(timeout):
+		go func() {
+			if res := <-done; res.conn != nil {
+				_ = res.conn.Close()
+			}
+		}()
+		return nil, errors.New("timed out opening tunnel")
+	}
+}
+
+func handshake(conn net.Conn, addr string, config *ssh.ClientConfig, timeout time.Duration) (*ssh.Client, error) {
+	_ = conn.SetDeadline(time.Now().Add(timeout))
+	timer := time.AfterFunc(timeout, func() {
+		_ = conn.Close()
+	})
+	clientConn, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if !timer.Stop() && err == nil {
+		_ = clientConn.Close()
+		return nil, fmt.Errorf("ssh handshake with %s timed out", addr)
+	}
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return ssh.NewClient(clientConn, chans, reqs), nil
+}
+
+func poolKey(target Target) string {
+	s := target.Settings
+	parts := []string{
+		target.ID, s.Host, strconv.Itoa(s.Port), s.Username, s.AuthMethod, s.Password, s.PrivateKey, s.PrivateKeyPassphrase,
+		certificateFingerprint(s.Certificate),
+	}
+	if target.Via != nil {
+		parts = append(parts, poolKey(*target.Via))
+	}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+func certificateFingerprint(text string) string {
+	if strings.TrimSpace(text) == "" {
+		return ""
+	}
+	cert, err := ParseCertificate(text)
+	if err != nil {
+		sum := sha256.Sum256([]byte(text))
+		return hex.EncodeToString(sum[:])
+	}
+	return ssh.FingerprintSHA256(cert)
+}
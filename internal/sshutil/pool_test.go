@@ -0,0 +1,306 @@
+package sshutil
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"inframap/internal/model"
+)
+
+type sshServer struct {
+	addr    string
+	hostKey ssh.PublicKey
+	conns   atomic.Int32
+}
+
+func startSSHServer(t *testing.T, password string) *sshServer {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(_ ssh.ConnMetadata, given []byte) (*ssh.Permissions, error) {
+			if string(given) != password {
+				return nil, ssh.ErrNoAuth
+			}
+			return nil, nil
+		},
+	}
+	config.AddHostKey(signer)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	srv := &sshServer{addr: ln.Addr().String(), hostKey: signer.PublicKey()}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go srv.handle(conn, config)
+		}
+	}()
+	return srv
+}
+
+func (srv *sshServer) handle(conn net.Conn, config *ssh.ServerConfig) {
+	defer conn.Close()
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	srv.conns.Add(1)
+	go ssh.DiscardRequests(reqs)
+	for newCh := range chans {
+		switch newCh.ChannelType() {
+		case "session":
+			ch, requests, err := newCh.Accept()
+			if err != nil {
+				continue
+			}
+			go func() {
+				defer ch.Close()
+				for req := range requests {
+					if req.Type != "exec" {
+						_ = req.Reply(false, nil)
+						continue
+					}
+					command := string(req.Payload[4:])
+					_ = req.Reply(true, nil)
+					_, _ = io.WriteString(ch, "ran "+command)
+					_, _ = ch.SendRequest("exit-status", false, binary.BigEndian.AppendUint32(nil, 0))
+					return
+				}
+			}()
+		case "direct-tcpip":
+			payload := newCh.ExtraData()
+			hostLen := binary.BigEndian.Uint32(payload)
+			host := string(payload[4 : 4+hostLen])
+			port := binary.BigEndian.Uint32(payload[4+hostLen:])
+			upstream, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(port))))
+			if err != nil {
+				_ = newCh.Reject(ssh.ConnectionFailed, err.Error())
+				continue
+			}
+			ch, requests, err := newCh.Accept()
+			if err != nil {
+				upstream.Close()
+				continue
+			}
+			go ssh.DiscardRequests(requests)
+			go func() {
+				_, _ = io.Copy(ch, upstream)
+				ch.Close()
+			}()
+			go func() {
+				_, _ = io.Copy(upstream, ch)
+				upstream.Close()
+			}()
+		default:
+			_ = newCh.Reject(ssh.UnknownChannelType, "unsupported")
+		}
+	}
+}
+
+type memoryHostKeys struct {
+	mu    sync.Mutex
+	items map[string]model.KnownHost
+}
+
+func (m *memoryHostKeys) Get(device string) (model.KnownHost, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.items[device]
+	return entry, ok, nil
+}
+
+func (m *memoryHostKeys) Pin(device string, key model.HostKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.items == nil {
+		m.items = make(map[string]model.KnownHost)
+	}
+	m.items[device] = model.KnownHost{Pinned: &key}
+	return nil
+}
+
+func (m *memoryHostKeys) Offer(device string, key model.HostKey) error {
+	return nil
+}
+
+func (srv *sshServer) target(t *testing.T, id, password string, hostKeys HostKeyStore) Target {
+	t.Helper()
+	host, port, err := net.SplitHostPort(srv.addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	portNum, _ := strconv.Atoi(port)
+	return Target{
+		ID: id,
+		Settings: model.DeviceSettings{
+			Host:       host,
+			Port:       portNum,
+			AuthMethod: AuthPassword,
+			Username:   "admin",
+			Password:   password,
+		},
+		HostKeys: HostKeyVerifier{Store: hostKeys, Device: id},
+	}
+}
+
+func TestPoolReusesConnections(t *testing.T) {
+	srv := startSSHServer(t, "secret")
+	pool := NewPool(PoolConfig{})
+	defer pool.Close()
+	target := srv.target(t, "main/node-1", "secret", &memoryHostKeys{})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 4)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client, err := pool.Acquire(target, 5*time.Second)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer client.Release()
+			output, err := client.Run("uname", 5*time.Second)
+			if err == nil && output != "ran uname" {
+				t.Errorf("output = %q", output)
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := srv.conns.Load(); n != 1 {
+		t.Fatalf("concurrent acquires opened %d connections, want 1", n)
+	}
+
+	client, err := pool.Acquire(target, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !client.reused {
+		t.Fatal("second acquire did not reuse the pooled connection")
+	}
+	client.Release()
+
+	changed := target
+	changed.Settings.Password = "other"
+	if _, err := pool.Acquire(changed, 5*time.Second); err == nil {
+		t.Fatal("changed credentials reused the pooled connection")
+	}
+
+	pool.Evict("main/node-1")
+	client, err = pool.Acquire(target, 5*time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Release()
+	if client.reused || srv.conns.Load() != 2 {
+		t.Fatalf("after evict reused %t, connections %d", client.reused, srv.conns.Load())
+	}
+}
+
+func testCertificate(t *testing.T, keyID string) string {
+	t.Helper()
+	_, caKey, _ := ed25519.GenerateKey(rand.Reader)
+	ca, err := ssh.NewSignerFromKey(caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	userPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	pub, err := ssh.NewPublicKey(userPub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := &ssh.Certificate{
+		Key:             pub,
+		CertType:        ssh.UserCert,
+		KeyId:           keyID,
+		ValidPrincipals: []string{"admin"},
+		ValidBefore:     ssh.CertTimeInfinity,
+	}
+	if err := cert.SignCert(rand.Reader, ca); err != nil {
+		t.Fatal(err)
+	}
+	return string(ssh.MarshalAuthorizedKey(cert))
+}
+
+func TestPoolKey(t *testing.T) {
+	cert := testCertificate(t, "first")
+	otherCert := testCertificate(t, "second")
+	base := Target{
+		ID: "main/node-1",
+		Settings: model.DeviceSettings{
+			Host:        "10.0.0.1",
+			Port:        22,
+			Username:    "admin",
+			AuthMethod:  AuthCertificate,
+			PrivateKey:  "key",
+			Certificate: cert,
+		},
+	}
+	key := poolKey(base)
+	tests := []struct {
+		name   string
+		mutate func(*Target)
+		same   bool
+	}{
+		{"identical", func(tg *Target) {}, true},
+		{"certificate comment and whitespace", func(tg *Target) { tg.Settings.Certificate = "  " + cert[:len(cert)-1] + " laptop\n" }, true},
+		{"host key store ignored", func(tg *Target) { tg.HostKeys = HostKeyVerifier{Store: &memoryHostKeys{}, Device: "x"} }, true},
+		{"other certificate", func(tg *Target) { tg.Settings.Certificate = otherCert }, false},
+		{"device id", func(tg *Target) { tg.ID = "lab/node-1" }, false},
+		{"host", func(tg *Target) { tg.Settings.Host = "10.0.0.2" }, false},
+		{"port", func(tg *Target) { tg.Settings.Port = 2222 }, false},
+		{"username", func(tg *Target) { tg.Settings.Username = "root" }, false},
+		{"auth method", func(tg *Target) { tg.Settings.AuthMethod = AuthKey }, false},
+		{"private key", func(tg *Target) { tg.Settings.PrivateKey = "other" }, false},
+		{"passphrase", func(tg *Target) { tg.Settings.PrivateKeyPassphrase = "p" }, false},
+		{"password", func(tg *Target) { tg.Settings.Password = "p" }, false},
+		{"jump host", func(tg *Target) {
+			tg.Via = &Target{ID: "main/bastion", Settings: model.DeviceSettings{Host: "bastion"}}
+		}, false},
+	}
+	for _, tt := range tests {
+		target := base
+		tt.mutate(&target)
+		if got := poolKey(target) == key; got != tt.same {
+			t.Errorf("%s: same key = %t, want %t", tt.name, got, tt.same)
+		}
+	}
+
+	viaA := base
+	viaA.Via = &Target{ID: "main/bastion", Settings: model.DeviceSettings{Host: "bastion", Password: "a"}}
+	viaB := base
+	viaB.Via = &Target{ID: "main/bastion", Settings: model.DeviceSettings{Host: "bastion", Password: "b"}}
+	if poolKey(viaA) == poolKey(viaB) {
+		t.Error("jump host credentials do not change the pool key")
+	}
+}
@@ -0,0 +1,150 @@
+package sshutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Probe struct {
+	Name     string
+	Field    string
+	OS       []string
+	Commands map[string]string
+	Parse    func(osType, output string) (any, error)
+	Collect  func(client *Client, osType string, timeout time.Duration) (any, error)
+}
+
+type ProbeResult struct {
+	Probe string
+	Field string
+	Value any
+	Err   error
+}
+
+var (
+	probesMu sync.RWMutex
+	probes   = make(map[string]Probe)
+)
+
+func RegisterProbe(probe Probe) error {
+	if probe.Name == "" || probe.Field == "" {
+		return errors.New("probe needs a name and a target field")
+	}
+	if len(probe.OS) == 0 {
+		return fmt.Errorf("probe %s does not declare any supported OS", probe.Name)
+	}
+	if probe.Collect == nil {
+		if probe.Parse == nil {
+			return fmt.Errorf("probe %s has no parser", probe.Name)
+		}
+		for _, osType := range probe.OS {
+			if probe.Commands[osType] == "" {
+				return fmt.Errorf("probe %s has no command for %s", probe.Name, osType)
+			}
+		}
+	}
+	probesMu.Lock()
+	defer probesMu.Unlock()
+	if _, ok := probes[probe.Name]; ok {
+		return fmt.Errorf("probe %s is already registered", probe.Name)
+	}
+	probes[probe.Name] = probe
+	return nil
+}
+
+func LookupProbe(name string) (Probe, bool) {
+	probesMu.RLock()
+	defer probesMu.RUnlock()
+	probe, ok := probes[name]
+	return probe, ok
+}
+
+func Probes() []Probe {
+	probesMu.RLock()
+	defer probesMu.RUnlock()
+	out := make([]Probe, 0, len(probes))
+	for _, probe := range probes {
+		out = append(out, probe)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func (p Probe) Supports(osType string) bool {
+	for _, supported := range p.OS {
+		if supported == osType {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Probe) run(client *Client, osType string, timeout time.Duration) (any, error) {
+	if p.Collect != nil {
+		return p.Collect(client, osType, timeout)
+	}
+	output, err := client.Run(p.Commands[osType], timeout)
+	if err != nil {
+		if detail := strings.TrimSpace(output); detail != "" {
+			return nil, fmt.Errorf("%w: %s", err, detail)
+		}
+		return nil, err
+	}
+	return p.Parse(osType, output)
+}
+
+func (p *Pool) RunProbes(ctx context.Context, target Target, names []string, timeout time.Duration) ([]ProbeResult, error) {
+	osType := targetOS(target)
+	var selected []Probe
+	var results []ProbeResult
+	if len(names) == 0 {
+		for _, probe := range Probes() {
+			if probe.Supports(osType) {
+				selected = append(selected, probe)
+			}
+		}
+	} else {
+		for _, name := range names {
+			probe, ok := LookupProbe(name)
+			switch {
+			case !ok:
+				results = append(results, ProbeResult{Probe: name, Err: fmt.Errorf("unknown probe %s", name)})
+			case !probe.Supports(osType):
+				results = append(results, ProbeResult{Probe: name, Field: probe.Field, Err: fmt.Errorf("probe %s does not support %s", name, osType)})
+			default:
+				selected = append(selected, probe)
+			}
+		}
+	}
+	if len(selected) == 0 {
+		return results, nil
+	}
+
+	client, err := p.session(ctx, target, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Release()
+	for _, probe := range selected {
+		value, err := probe.run(client, osType, timeout)
+		results = append(results, ProbeResult{Probe: probe.Name, Field: probe.Field, Value: value, Err: err})
+	}
+	return results, nil
+}
+
+func targetOS(target Target) string {
+	osType := strings.ToLower(strings.TrimSpace(target.Settings.OS))
+	if osType == "" {
+		return "linux"
+	}
+	return osType
+}
+
+func powershell(script string) string {
+	return "powershell -NoProfile -NonInteractive -Command \"" + script + "\""
+}
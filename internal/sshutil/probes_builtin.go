@@ -0,0 +1,380 @@
+package sshutil
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CPULoad struct {
+	Load1   float64 `json:"load1,omitempty"`
+	Load5   float64 `json:"load5,omitempty"`
+	Load15  float64 `json:"load15,omitempty"`
+	Cores   int     `json:"cores,omitempty"`
+	Percent float64 `json:"percent"`
+}
+
+type MemoryUsage struct {
+	TotalBytes     uint64  `json:"totalBytes"`
+	AvailableBytes uint64  `json:"availableBytes"`
+	UsedBytes      uint64  `json:"usedBytes"`
+	UsedPercent    float64 `json:"usedPercent"`
+}
+
+type DiskUsage struct {
+	Mount          string  `json:"mount"`
+	Filesystem     string  `json:"filesystem,omitempty"`
+	SizeBytes      uint64  `json:"sizeBytes"`
+	UsedBytes      uint64  `json:"usedBytes"`
+	AvailableBytes uint64  `json:"availableBytes"`
+	UsedPercent    float64 `json:"usedPercent"`
+}
+
+type ListeningPort struct {
+	Protocol string `json:"protocol"`
+	Address  string `json:"address"`
+	Port     int    `json:"port"`
+}
+
+type LinkSpeed struct {
+	Mbps      int    `json:"mbps"`
+	Interface string `json:"interface,omitempty"`
+}
+
+func init() {
+	for _, probe := range []Probe{
+		{
+			Name:  "cpu_load",
+			Field: "cpuLoad",
+			OS:    []string{"linux", "windows"},
+			Commands: map[string]string{
+				"linux":   "cat /proc/loadavg; getconf _NPROCESSORS_ONLN 2>/dev/null || nproc",
+				"windows": powershell("$cpu = @(Get-CimInstance Win32_Processor); [pscustomobject]@{Percent=($cpu | Measure-Object -Property LoadPercentage -Average).Average; Cores=($cpu | Measure-Object -Property NumberOfLogicalProcessors -Sum).Sum} | ConvertTo-Json -Compress"),
+			},
+			Parse: parseCPULoad,
+		},
+		{
+			Name:  "memory",
+			Field: "memory",
+			OS:    []string{"linux", "windows"},
+			Commands: map[string]string{
+				"linux":   "cat /proc/meminfo",
+				"windows": powershell("Get-CimInstance Win32_OperatingSystem | Select-Object TotalVisibleMemorySize,FreePhysicalMemory | ConvertTo-Json -Compress"),
+			},
+			Parse: parseMemory,
+		},
+		{
+			Name:  "disk_usage",
+			Field: "disks",
+			OS:    []string{"linux", "windows"},
+			Commands: map[string]string{
+				"linux":   "df -P -k -x tmpfs -x devtmpfs -x squashfs -x overlay 2>/dev/null || df -P -k",
+				"windows": powershell("ConvertTo-Json -Compress -InputObject @(Get-CimInstance Win32_LogicalDisk -Filter 'DriveType=3' | Select-Object DeviceID,FileSystem,Size,FreeSpace)"),
+			},
+			Parse: parseDiskUsage,
+		},
+		{
+			Name:  "uptime",
+			Field: "uptimeSeconds",
+			OS:    []string{"linux", "windows"},
+			Commands: map[string]string{
+				"linux":   "cat /proc/uptime",
+				"windows": powershell("[int64]((Get-Date) - (Get-CimInstance Win32_OperatingSystem).LastBootUpTime).TotalSeconds"),
+			},
+			Parse: parseUptime,
+		},
+		{
+			Name:  "kernel",
+			Field: "kernel",
+			OS:    []string{"linux", "windows"},
+			Commands: map[string]string{
+				"linux":   "uname -srm",
+				"windows": powershell("[System.Environment]::OSVersion.VersionString"),
+			},
+			Parse: parseKernel,
+		},
+		{
+			Name:  "listening_ports",
+			Field: "listeningPorts",
+			OS:    []string{"linux", "windows"},
+			Commands: map[string]string{
+				"linux":   "ss -H -tuln 2>/dev/null || netstat -tuln 2>/dev/null",
+				"windows": powershell("$ports = @(Get-NetTCPConnection -State Listen | ForEach-Object { [pscustomobject]@{Protocol='tcp'; Address=$_.LocalAddress; Port=$_.LocalPort} }) + @(Get-NetUDPEndpoint | ForEach-Object { [pscustomobject]@{Protocol='udp'; Address=$_.LocalAddress; Port=$_.LocalPort} }); ConvertTo-Json -Compress -InputObject $ports"),
+			},
+			Parse: parseListeningPorts,
+		},
+		{
+			Name:  "link_speed",
+			Field: "linkSpeed",
+			OS:    []string{"linux", "windows"},
+			Collect: func(client *Client, osType string, timeout time.Duration) (any, error) {
+				speed, iface, err := detectLinkSpeed(client, osType, timeout)
+				if err != nil {
+					return nil, err
+				}
+				return LinkSpeed{Mbps: speed, Interface: iface}, nil
+			},
+		},
+		{
+			Name:  "tailscale_ip",
+			Field: "tailscaleIp",
+			OS:    []string{"linux", "windows"},
+			Collect: func(client *Client, _ string, timeout time.Duration) (any, error) {
+				return detectTailscaleIP(client, timeout)
+			},
+		},
+	} {
+		if err := RegisterProbe(probe); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func parseCPULoad(osType, output string) (any, error) {
+	if osType == "windows" {
+		var payload struct {
+			Percent float64 `json:"Percent"`
+			Cores   int     `json:"Cores"`
+		}
+		if err := json.Unmarshal([]byte(strings.TrimSpace(output)), &payload); err != nil {
+			return nil, fmt.Errorf("unexpected cpu output: %w", err)
+		}
+		return CPULoad{Percent: payload.Percent, Cores: payload.Cores}, nil
+	}
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	fields := strings.Fields(lines[0])
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("unexpected loadavg output: %q", lines[0])
+	}
+	var load CPULoad
+	for i, dst := range []*float64{&load.Load1, &load.Load5, &load.Load15} {
+		value, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected loadavg output: %q", lines[0])
+		}
+		*dst = value
+	}
+	if len(lines) > 1 {
+		load.Cores, _ = strconv.Atoi(strings.TrimSpace(lines[len(lines)-1]))
+	}
+	if load.Cores > 0 {
+		load.Percent = round2(load.Load1 / float64(load.Cores) * 100)
+	}
+	return load, nil
+}
+
+func parseMemory(osType, output string) (any, error) {
+	var total, available uint64
+	if osType == "windows" {
+		var payload struct {
+			TotalVisibleMemorySize uint64 `json:"TotalVisibleMemorySize"`
+			FreePhysicalMemory     uint64 `json:"FreePhysicalMemory"`
+		}
+		if err := json.Unmarshal([]byte(strings.TrimSpace(output)), &payload); err != nil {
+			return nil, fmt.Errorf("unexpected memory output: %w", err)
+		}
+		total, available = payload.TotalVisibleMemorySize*1024, payload.FreePhysicalMemory*1024
+	} else {
+		values := make(map[string]uint64)
+		scanner := bufio.NewScanner(strings.NewReader(output))
+		for scanner.Scan() {
+			key, rest, ok := strings.Cut(scanner.Text(), ":")
+			if !ok {
+				continue
+			}
+			fields := strings.Fields(rest)
+			if len(fields) == 0 {
+				continue
+			}
+			value, err := strconv.ParseUint(fields[0], 10, 64)
+			if err != nil {
+				continue
+			}
+			values[key] = value * 1024
+		}
+		total = values["MemTotal"]
+		var ok bool
+		if available, ok = values["MemAvailable"]; !ok {
+			available = values["MemFree"] + values["Buffers"] + values["Cached"]
+		}
+	}
+	if total == 0 {
+		return nil, errors.New("total memory not reported")
+	}
+	if available > total {
+		available = total
+	}
+	used := total - available
+	return MemoryUsage{
+		TotalBytes:     total,
+		AvailableBytes: available,
+		UsedBytes:      used,
+		UsedPercent:    round2(float64(used) / float64(total) * 100),
+	}, nil
+}
+
+func parseDiskUsage(osType, output string) (any, error) {
+	disks := []DiskUsage{}
+	if osType == "windows" {
+		var payload []struct {
+			DeviceID   string `json:"DeviceID"`
+			FileSystem string `json:"FileSystem"`
+			Size       uint64 `json:"Size"`
+			FreeSpace  uint64 `json:"FreeSpace"`
+		}
+		if err := json.Unmarshal([]byte(strings.TrimSpace(output)), &payload); err != nil {
+			return nil, fmt.Errorf("unexpected disk output: %w", err)
+		}
+		for _, item := range payload {
+			disks = append(disks, diskUsage(item.DeviceID, item.FileSystem, item.Size, item.FreeSpace))
+		}
+		return disks, nil
+	}
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 6 || fields[0] == "Filesystem" {
+			continue
+		}
+		size, errSize := strconv.ParseUint(fields[1], 10, 64)
+		avail, errAvail := strconv.ParseUint(fields[3], 10, 64)
+		if errSize != nil || errAvail != nil || size == 0 {
+			continue
+		}
+		mount := strings.Join(fields[5:], " ")
+		if seen[mount] {
+			continue
+		}
+		seen[mount] = true
+		disks = append(disks, diskUsage(mount, fields[0], size*1024, avail*1024))
+	}
+	if len(disks) == 0 {
+		return nil, errors.New("no filesystems reported")
+	}
+	return disks, nil
+}
+
+func diskUsage(mount, filesystem string, size, available uint64) DiskUsage {
+	if available > size {
+		available = size
+	}
+	disk := DiskUsage{
+		Mount:          mount,
+		Filesystem:     filesystem,
+		SizeBytes:      size,
+		UsedBytes:      size - available,
+		AvailableBytes: available,
+	}
+	if size > 0 {
+		disk.UsedPercent = round2(float64(disk.UsedBytes) / float64(size) * 100)
+	}
+	return disk
+}
+
+func parseUptime(_ string, output string) (any, error) {
+	fields := strings.Fields(output)
+	if len(fields) == 0 {
+		return nil, errors.New("empty uptime output")
+	}
+	seconds, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil || seconds < 0 {
+		return nil, fmt.Errorf("unexpected uptime output: %q", strings.TrimSpace(output))
+	}
+	return int64(seconds), nil
+}
+
+func parseKernel(_ string, output string) (any, error) {
+	value := strings.TrimSpace(output)
+	if value == "" {
+		return nil, errors.New("empty kernel version")
+	}
+	return value, nil
+}
+
+func parseListeningPorts(osType, output string) (any, error) {
+	ports := []ListeningPort{}
+	seen := make(map[ListeningPort]bool)
+	add := func(port ListeningPort) {
+		if port.Port <= 0 || seen[port] {
+			return
+		}
+		seen[port] = true
+		ports = append(ports, port)
+	}
+	if osType == "windows" {
+		var payload []struct {
+			Protocol string `json:"Protocol"`
+			Address  string `json:"Address"`
+			Port     int    `json:"Port"`
+		}
+		if err := json.Unmarshal([]byte(strings.TrimSpace(output)), &payload); err != nil {
+			return nil, fmt.Errorf("unexpected port output: %w", err)
+		}
+		for _, item := range payload {
+			add(ListeningPort{Protocol: item.Protocol, Address: item.Address, Port: item.Port})
+		}
+	} else {
+		for _, line := range strings.Split(output, "\n") {
+			fields := strings.Fields(line)
+			if len(fields) < 4 {
+				continue
+			}
+			proto := strings.ToLower(fields[0])
+			if !strings.HasPrefix(proto, "tcp") && !strings.HasPrefix(proto, "udp") {
+				continue
+			}
+			local := fields[3]
+			if _, err := strconv.Atoi(fields[1]); err != nil && len(fields) >= 5 {
+				local = fields[4]
+			}
+			address, port, ok := splitListenAddress(local)
+			if !ok {
+				continue
+			}
+			add(ListeningPort{Protocol: proto[:3], Address: address, Port: port})
+		}
+	}
+	sort.Slice(ports, func(i, j int) bool {
+		if ports[i].Port != ports[j].Port {
+			return ports[i].Port < ports[j].Port
+		}
+		if ports[i].Protocol != ports[j].Protocol {
+			return ports[i].Protocol < ports[j].Protocol
+		}
+		return ports[i].Address < ports[j].Address
+	})
+	return ports, nil
+}
+
+func splitListenAddress(value string) (string, int, bool) {
+	idx := strings.LastIndex(value, ":")
+	if idx < 0 {
+		return "", 0, false
+	}
+	port, err := strconv.Atoi(value[idx+1:])
+	if err != nil {
+		return "", 0, false
+	}
+	host := strings.Trim(value[:idx], "[]")
+	if zone := strings.Index(host, "%"); zone >= 0 {
+		host = host[:zone]
+	}
+	if host == "*" || host == "" {
+		host = "0.0.0.0"
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		host = ip.String()
+	}
+	return host, port, true
+}
+
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
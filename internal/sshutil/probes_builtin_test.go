@@ -0,0 +1,115 @@
+package sshutil
+
+import (
+	"reflect"
+	"testing"
+)
+
+const (
+	procMeminfo = `MemTotal:        8048316 kB
+MemFree:          512000 kB
+MemAvailable:    2012079 kB
+Buffers:          102400 kB
+Cached:          1024000 kB
+SwapTotal:       2097148 kB
+`
+	procMeminfoOld = `MemTotal:        1000000 kB
+MemFree:          200000 kB
+Buffers:          100000 kB
+Cached:           200000 kB
+`
+	dfOutput = `Filesystem     1024-blocks     Used Available Capacity Mounted on
+/dev/sda1         41152736 30864552  8178760      80% /
+/dev/sdb1        103081248  5242880 92594128       6% /mnt/data backup
+/dev/sda1         41152736 30864552  8178760      80% /
+udev                     0        0        0       -  /dev
+`
+	ssOutput = `tcp   LISTEN 0      4096   127.0.0.53%lo:53        0.0.0.0:*
+tcp   LISTEN 0      128          0.0.0.0:22        0.0.0.0:*
+tcp   LISTEN 0      128             [::]:22           [::]:*
+udp   UNCONN 0      0                  *:123             *:*
+udp   UNCONN 0      0          0.0.0.0:68          0.0.0.0:*
+`
+	netstatOutput = `Active Internet connections (only servers)
+Proto Recv-Q Send-Q Local Address           Foreign Address         State
+tcp        0      0 0.0.0.0:22              0.0.0.0:*               LISTEN
+tcp6       0      0 :::443                  :::*                    LISTEN
+udp        0      0 0.0.0.0:68              0.0.0.0:*
+`
+)
+
+func TestBuiltinProbeParsers(t *testing.T) {
+	tests := []struct {
+		name   string
+		parse  func(string, string) (any, error)
+		osType string
+		output string
+		want   any
+	}{
+		{"loadavg", parseCPULoad, "linux", "0.52 0.61 0.70 2/345 12345\n4\n", CPULoad{Load1: 0.52, Load5: 0.61, Load15: 0.70, Cores: 4, Percent: 13}},
+		{"loadavg without cores", parseCPULoad, "linux", "1.00 0.50 0.25 1/100 1\n", CPULoad{Load1: 1, Load5: 0.5, Load15: 0.25}},
+		{"windows cpu", parseCPULoad, "windows", `{"Percent":37.5,"Cores":8}`, CPULoad{Percent: 37.5, Cores: 8}},
+		{"meminfo", parseMemory, "linux", procMeminfo, MemoryUsage{TotalBytes: 8048316 * 1024, AvailableBytes: 2012079 * 1024, UsedBytes: (8048316 - 2012079) * 1024, UsedPercent: 75}},
+		{"meminfo without MemAvailable", parseMemory, "linux", procMeminfoOld, MemoryUsage{TotalBytes: 1000000 * 1024, AvailableBytes: 500000 * 1024, UsedBytes: 500000 * 1024, UsedPercent: 50}},
+		{"windows memory", parseMemory, "windows", `{"TotalVisibleMemorySize":16000,"FreePhysicalMemory":4000}`, MemoryUsage{TotalBytes: 16000 * 1024, AvailableBytes: 4000 * 1024, UsedBytes: 12000 * 1024, UsedPercent: 75}},
+		{"df", parseDiskUsage, "linux", dfOutput, []DiskUsage{
+			{Mount: "/", Filesystem: "/dev/sda1", SizeBytes: 41152736 * 1024, UsedBytes: (41152736 - 8178760) * 1024, AvailableBytes: 8178760 * 1024, UsedPercent: 80.13},
+			{Mount: "/mnt/data backup", Filesystem: "/dev/sdb1", SizeBytes: 103081248 * 1024, UsedBytes: (103081248 - 92594128) * 1024, AvailableBytes: 92594128 * 1024, UsedPercent: 10.17},
+		}},
+		{"windows disks", parseDiskUsage, "windows", `[{"DeviceID":"C:","FileSystem":"NTFS","Size":1000,"FreeSpace":250}]`, []DiskUsage{
+			{Mount: "C:", Filesystem: "NTFS", SizeBytes: 1000, UsedBytes: 750, AvailableBytes: 250, UsedPercent: 75},
+		}},
+		{"uptime", parseUptime, "linux", "354512.17 1384003.50\n", int64(354512)},
+		{"windows uptime", parseUptime, "windows", "86400\r\n", int64(86400)},
+		{"kernel", parseKernel, "linux", "Linux 6.1.0-18-amd64 x86_64\n", "Linux 6.1.0-18-amd64 x86_64"},
+		{"ss", parseListeningPorts, "linux", ssOutput, []ListeningPort{
+			{Protocol: "tcp", Address: "0.0.0.0", Port: 22},
+			{Protocol: "tcp", Address: "::", Port: 22},
+			{Protocol: "tcp", Address: "127.0.0.53", Port: 53},
+			{Protocol: "udp", Address: "0.0.0.0", Port: 68},
+			{Protocol: "udp", Address: "0.0.0.0", Port: 123},
+		}},
+		{"netstat", parseListeningPorts, "linux", netstatOutput, []ListeningPort{
+			{Protocol: "tcp", Address: "0.0.0.0", Port: 22},
+			{Protocol: "udp", Address: "0.0.0.0", Port: 68},
+			{Protocol: "tcp", Address: "::", Port: 443},
+		}},
+		{"windows ports", parseListeningPorts, "windows", `[{"Protocol":"tcp","Address":"0.0.0.0","Port":3389},{"Protocol":"tcp","Address":"0.0.0.0","Port":3389},{"Protocol":"udp","Address":"::","Port":0}]`, []ListeningPort{
+			{Protocol: "tcp", Address: "0.0.0.0", Port: 3389},
+		}},
+	}
+	for _, tt := range tests {
+		got, err := tt.parse(tt.osType, tt.output)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\n got %+v\nwant %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBuiltinProbeParserErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		parse  func(string, string) (any, error)
+		osType string
+		output string
+	}{
+		{"short loadavg", parseCPULoad, "linux", "0.52 0.61\n"},
+		{"bad loadavg", parseCPULoad, "linux", "a b c\n"},
+		{"windows cpu text", parseCPULoad, "windows", "Access denied"},
+		{"meminfo without total", parseMemory, "linux", "MemFree: 100 kB\n"},
+		{"df header only", parseDiskUsage, "linux", "Filesystem 1024-blocks Used Available Capacity Mounted on\n"},
+		{"empty uptime", parseUptime, "linux", "  \n"},
+		{"negative uptime", parseUptime, "linux", "-5\n"},
+		{"empty kernel", parseKernel, "linux", "\n"},
+		{"windows ports text", parseListeningPorts, "windows", "Get-NetTCPConnection : not recognized"},
+	}
+	for _, tt := range tests {
+		if got, err := tt.parse(tt.osType, tt.output); err == nil {
+			t.Errorf("%s: parsed %+v, want error", tt.name, got)
+		}
+	}
+}
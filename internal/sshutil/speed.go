@@ -1,82 +1,44 @@
 package sshutil
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
-	"net"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
-
-	"golang.org/x/crypto/ssh"
-
-	"inframap/internal/model"
 )
 
 var speedRegex = regexp.MustCompile(`(?i)([0-9]+(?:\.[0-9]+)?)\s*([mg]b(?:/s|ps))`)
 
-func DetectLinkSpeed(settings model.DeviceSettings, timeout time.Duration) (int, string, error) {
-	osType := strings.ToLower(strings.TrimSpace(settings.OS))
-	host := strings.TrimSpace(settings.Host)
-	if host == "" {
-		return 0, "", errors.New("host is empty")
-	}
-	user := strings.TrimSpace(settings.Username)
-	if user == "" {
-		return 0, "", errors.New("username is empty")
-	}
-	port := settings.Port
-	if port == 0 {
-		port = 22
-	}
-	if timeout <= 0 {
-		timeout = 6 * time.Second
-	}
-
-	auth, err := buildAuth(settings)
-	if err != nil {
-		return 0, "", err
-	}
-
-	config := &ssh.ClientConfig{
-		User:            user,
-		Auth:            auth,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-	}
-
-	addr := net.JoinHostPort(host, strconv.Itoa(port))
-	conn, err := net.DialTimeout("tcp", addr, timeout)
-	if err != nil {
-		return 0, "", err
-	}
-	_ = conn.SetDeadline(time.Now().Add(timeout))
-
-	clientConn, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+func (p *Pool) DetectLinkSpeed(ctx context.Context, target Target, timeout time.Duration) (int, string, error) {
+	client, err := p.session(ctx, target, timeout)
 	if err != nil {
-		_ = conn.Close()
 		return 0, "", err
 	}
-	client := ssh.NewClient(clientConn, chans, reqs)
-	defer client.Close()
+	defer client.Release()
+	return detectLinkSpeed(client, targetOS(target), timeout)
+}
 
+func detectLinkSpeed(client *Client, osType string, timeout time.Duration) (int, string, error) {
 	if osType == "windows" {
-		return detectWindowsSpeed(client)
+		return detectWindowsSpeed(client, timeout)
 	}
 
-	iface, err := runCommand(client, "sh -c \"ip route get 1.1.1.1 | sed -n 's/.* dev \\([^ ]*\\).*/\\1/p'\"")
+	iface, err := client.Run("sh -c \"ip route get 1.1.1.1 | sed -n 's/.* dev \\([^ ]*\\).*/\\1/p'\"", timeout)
 	if err != nil {
 		return 0, "", fmt.Errorf("failed to detect interface: %w", err)
 	}
 	iface = strings.TrimSpace(iface)
 	if iface == "" {
-		fallback, _ := runCommand(client, "sh -c \"ip -o link show | awk -F': ' '$2 != \\\"lo\\\" {print $2; exit}'\"")
+		fallback, _ := client.Run("sh -c \"ip -o link show | awk -F': ' '$2 != \\\"lo\\\" {print $2; exit}'\"", timeout)
 		iface = strings.TrimSpace(fallback)
 	}
 	if iface == "" {
-		fallback, _ := runCommand(client, "sh -c \"ls /sys/class/net 2>/dev/null | grep -v '^lo$' | head -n1\"")
+		fallback, _ := client.Run("sh -c \"ls /sys/class/net 2>/dev/null | grep -v '^lo$' | head -n1\"", timeout)
 		iface = strings.TrimSpace(fallback)
 	}
 	if iface == "" {
@@ -84,7 +46,7 @@ func DetectLinkSpeed(settings model.DeviceSettings, timeout time.Duration) (int,
 	}
 
 	cmd := fmt.Sprintf("sh -c \"ethtool %s 2>/dev/null | awk -F': ' '/Speed:/ {print $2; exit}'\"", iface)
-	speedRaw, err := runCommand(client, cmd)
+	speedRaw, err := client.Run(cmd, timeout)
 	if err == nil {
 		speed, parseErr := parseSpeed(speedRaw)
 		if parseErr == nil {
@@ -92,7 +54,7 @@ func DetectLinkSpeed(settings model.DeviceSettings, timeout time.Duration) (int,
 		}
 	}
 
-	sysRaw, sysErr := runCommand(client, fmt.Sprintf("sh -c \"cat /sys/class/net/%s/speed 2>/dev/null\"", iface))
+	sysRaw, sysErr := client.Run(fmt.Sprintf("sh -c \"cat /sys/class/net/%s/speed 2>/dev/null\"", iface), timeout)
 	if sysErr == nil {
 		speed, parseErr := parseSysfsSpeed(sysRaw)
 		if parseErr == nil {
@@ -103,49 +65,6 @@ func DetectLinkSpeed(settings model.DeviceSettings, timeout time.Duration) (int,
 	return 0, iface, errors.New("speed unavailable (ethtool returned empty and sysfs missing)")
 }
 
-func buildAuth(settings model.DeviceSettings) ([]ssh.AuthMethod, error) {
-	method := strings.ToLower(strings.TrimSpace(settings.AuthMethod))
-	if method == "" {
-		method = "password"
-	}
-	switch method {
-	case "ssh_key":
-		key := strings.TrimSpace(settings.PrivateKey)
-		if key == "" {
-			return nil, errors.New("private key is empty")
-		}
-		var signer ssh.Signer
-		var err error
-		if settings.PrivateKeyPassphrase != "" {
-			signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(key), []byte(settings.PrivateKeyPassphrase))
-		} else {
-			signer, err = ssh.ParsePrivateKey([]byte(key))
-		}
-		if err != nil {
-			return nil, err
-		}
-		return []ssh.AuthMethod{ssh.PublicKeys(signer)}, nil
-	default:
-		if settings.Password == "" {
-			return nil, errors.New("password is empty")
-		}
-		return []ssh.AuthMethod{ssh.Password(settings.Password)}, nil
-	}
-}
-
-func runCommand(client *ssh.Client, command string) (string, error) {
-	session, err := client.NewSession()
-	if err != nil {
-		return "", err
-	}
-	defer session.Close()
-	output, err := session.CombinedOutput(command)
-	if err != nil {
-		return "", err
-	}
-	return string(output), nil
-}
-
 func parseSpeed(raw string) (int, error) {
 	value := strings.TrimSpace(raw)
 	if value == "" {
@@ -190,9 +109,9 @@ func parseSysfsSpeed(raw string) (int, error) {
 	return num, nil
 }
 
-func detectWindowsSpeed(client *ssh.Client) (int, string, error) {
+func detectWindowsSpeed(client *Client, timeout time.Duration) (int, string, error) {
 	cmd := "powershell -NoProfile -Command \"$route = Get-NetRoute -DestinationPrefix '0.0.0.0/0' | Sort-Object RouteMetric | Select-Object -First 1; $idx = $null; if ($route) { $idx = $route.InterfaceIndex }; $adapter = $null; if ($idx) { $adapter = Get-NetAdapter -InterfaceIndex $idx -ErrorAction SilentlyContinue }; if (-not $adapter) { $adapter = Get-NetAdapter | Where-Object { $_.Status -eq 'Up' } | Sort-Object LinkSpeed -Descending | Select-Object -First 1 }; if ($adapter) { [pscustomobject]@{Name=$adapter.Name; LinkSpeed=$adapter.LinkSpeed} | ConvertTo-Json -Compress }\""
-	output, err := runCommand(client, cmd)
+	output, err := client.Run(cmd, timeout)
 	if err != nil {
 		return 0, "", err
 	}
@@ -0,0 +1,364 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"inframap/internal/model"
+)
+
+const maxResolvedAlerts = 500
+
+var (
+	ErrAlertRuleNotFound = errors.New("alert rule not found")
+	ErrAlertNotFound     = errors.New("alert not found")
+	ErrSilenceNotFound   = errors.New("silence not found")
+)
+
+type alertsFile struct {
+	Version   int                        `json:"version"`
+	UpdatedAt string                     `json:"updatedAt"`
+	Rules     map[string]model.AlertRule `json:"rules"`
+	Alerts    map[string]model.Alert     `json:"alerts"`
+	Silences  map[string]model.Silence   `json:"silences"`
+}
+
+type AlertStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewAlertStore(path string) (*AlertStore, error) {
+	store := &AlertStore{path: path}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if _, err := store.load(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func (a *AlertStore) CopyScope(from, to string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	file, err := a.load()
+	if err != nil {
+		return err
+	}
+	fromPrefix, toPrefix := from+"/", to+"/"
+	for key, rule := range file.Rules {
+		if strings.HasPrefix(key, fromPrefix) {
+			file.Rules[toPrefix+strings.TrimPrefix(key, fromPrefix)] = rule
+		}
+	}
+	file.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	return a.save(file)
+}
+
+func (a *AlertStore) DeleteScope(board string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	file, err := a.load()
+	if err != nil {
+		return err
+	}
+	prefix := board + "/"
+	for key := range file.Rules {
+		if strings.HasPrefix(key, prefix) {
+			delete(file.Rules, key)
+		}
+	}
+	for key := range file.Alerts {
+		if strings.HasPrefix(key, prefix) {
+			delete(file.Alerts, key)
+		}
+	}
+	for key := range file.Silences {
+		if strings.HasPrefix(key, prefix) {
+			delete(file.Silences, key)
+		}
+	}
+	file.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	return a.save(file)
+}
+
+type ScopedAlerts struct {
+	store  *AlertStore
+	prefix string
+}
+
+func (a *AlertStore) Scope(board string) *ScopedAlerts {
+	return &ScopedAlerts{store: a, prefix: board + "/"}
+}
+
+func (a *ScopedAlerts) Rules() ([]model.AlertRule, error) {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+	file, err := a.store.load()
+	if err != nil {
+		return nil, err
+	}
+	out := []model.AlertRule{}
+	for key, rule := range file.Rules {
+		if strings.HasPrefix(key, a.prefix) {
+			out = append(out, rule)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].CreatedAt != out[j].CreatedAt {
+			return out[i].CreatedAt < out[j].CreatedAt
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+func (a *ScopedAlerts) GetRule(id string) (model.AlertRule, bool, error) {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+	file, err := a.store.load()
+	if err != nil {
+		return model.AlertRule{}, false, err
+	}
+	rule, ok := file.Rules[a.prefix+id]
+	return rule, ok, nil
+}
+
+func (a *ScopedAlerts) PutRule(rule model.AlertRule) (model.AlertRule, error) {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+	file, err := a.store.load()
+	if err != nil {
+		return model.AlertRule{}, err
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	if rule.ID == "" {
+		id, err := newID()
+		if err != nil {
+			return model.AlertRule{}, err
+		}
+		rule.ID = id
+		rule.CreatedAt = now
+	} else {
+		prev, ok := file.Rules[a.prefix+rule.ID]
+		if !ok {
+			return model.AlertRule{}, ErrAlertRuleNotFound
+		}
+		rule.CreatedAt = prev.CreatedAt
+	}
+	rule.UpdatedAt = now
+	file.Rules[a.prefix+rule.ID] = rule
+	file.UpdatedAt = now
+	return rule, a.store.save(file)
+}
+
+func (a *ScopedAlerts) DeleteRule(id string) error {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+	file, err := a.store.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := file.Rules[a.prefix+id]; !ok {
+		return ErrAlertRuleNotFound
+	}
+	delete(file.Rules, a.prefix+id)
+	file.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	return a.store.save(file)
+}
+
+func (a *ScopedAlerts) Alerts() ([]model.Alert, error) {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+	file, err := a.store.load()
+	if err != nil {
+		return nil, err
+	}
+	out := []model.Alert{}
+	for key, alert := range file.Alerts {
+		if strings.HasPrefix(key, a.prefix) {
+			out = append(out, alert)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].StartedAt > out[j].StartedAt })
+	return out, nil
+}
+
+func (a *ScopedAlerts) GetAlert(id string) (model.Alert, bool, error) {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+	file, err := a.store.load()
+	if err != nil {
+		return model.Alert{}, false, err
+	}
+	alert, ok := file.Alerts[a.prefix+id]
+	return alert, ok, nil
+}
+
+func (a *ScopedAlerts) PutAlert(alert model.Alert) (model.Alert, error) {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+	file, err := a.store.load()
+	if err != nil {
+		return model.Alert{}, err
+	}
+	if alert.ID == "" {
+		id, err := newID()
+		if err != nil {
+			return model.Alert{}, err
+		}
+		alert.ID = id
+	}
+	file.Alerts[a.prefix+alert.ID] = alert
+	a.pruneResolved(file)
+	file.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	return alert, a.store.save(file)
+}
+
+func (a *ScopedAlerts) Acknowledge(id, author string) (model.Alert, error) {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+	file, err := a.store.load()
+	if err != nil {
+		return model.Alert{}, err
+	}
+	alert, ok := file.Alerts[a.prefix+id]
+	if !ok {
+		return model.Alert{}, ErrAlertNotFound
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	alert.AckedBy = author
+	alert.AckedAt = now
+	file.Alerts[a.prefix+id] = alert
+	file.UpdatedAt = now
+	return alert, a.store.save(file)
+}
+
+func (a *ScopedAlerts) Silences() ([]model.Silence, error) {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+	file, err := a.store.load()
+	if err != nil {
+		return nil, err
+	}
+	out := []model.Silence{}
+	for key, silence := range file.Silences {
+		if strings.HasPrefix(key, a.prefix) {
+			out = append(out, silence)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt > out[j].CreatedAt })
+	return out, nil
+}
+
+func (a *ScopedAlerts) AddSilence(silence model.Silence) (model.Silence, error) {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+	file, err := a.store.load()
+	if err != nil {
+		return model.Silence{}, err
+	}
+	id, err := newID()
+	if err != nil {
+		return model.Silence{}, err
+	}
+	now := time.Now().UTC()
+	silence.ID = id
+	silence.CreatedAt = now.Format(time.RFC3339)
+	for key, existing := range file.Silences {
+		if until, err := time.Parse(time.RFC3339, existing.Until); err == nil && until.Before(now.Add(-7*24*time.Hour)) {
+			delete(file.Silences, key)
+		}
+	}
+	file.Silences[a.prefix+id] = silence
+	file.UpdatedAt = silence.CreatedAt
+	return silence, a.store.save(file)
+}
+
+func (a *ScopedAlerts) DeleteSilence(id string) error {
+	a.store.mu.Lock()
+	defer a.store.mu.Unlock()
+	file, err := a.store.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := file.Silences[a.prefix+id]; !ok {
+		return ErrSilenceNotFound
+	}
+	delete(file.Silences, a.prefix+id)
+	file.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	return a.store.save(file)
+}
+
+func (a *ScopedAlerts) pruneResolved(file *alertsFile) {
+	var resolved []string
+	for key, alert := range file.Alerts {
+		if strings.HasPrefix(key, a.prefix) && alert.State == model.AlertResolved {
+			resolved = append(resolved, key)
+		}
+	}
+	if len(resolved) <= maxResolvedAlerts {
+		return
+	}
+	sort.Slice(resolved, func(i, j int) bool {
+		return file.Alerts[resolved[i]].ResolvedAt < file.Alerts[resolved[j]].ResolvedAt
+	})
+	for _, key := range resolved[:len(resolved)-maxResolvedAlerts] {
+		delete(file.Alerts, key)
+	}
+}
+
+func (a *AlertStore) load() (*alertsFile, error) {
+	data, err := os.ReadFile(a.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &alertsFile{
+				Version:  1,
+				Rules:    make(map[string]model.AlertRule),
+				Alerts:   make(map[string]model.Alert),
+				Silences: make(map[string]model.Silence),
+			}, nil
+		}
+		return nil, err
+	}
+	var file alertsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if file.Rules == nil {
+		file.Rules = make(map[string]model.AlertRule)
+	}
+	if file.Alerts == nil {
+		file.Alerts = make(map[string]model.Alert)
+	}
+	if file.Silences == nil {
+		file.Silences = make(map[string]model.Silence)
+	}
+	if file.Version == 0 {
+		file.Version = 1
+	}
+	return &file, nil
+}
+
+func (a *AlertStore) save(file *alertsFile) error {
+	payload, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(a.path, payload, 0o644)
+}
+
+func newID() (string, error) {
+	raw := make([]byte, 8)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(raw), nil
+}
@@ -0,0 +1,242 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DefaultBoardID = "default"
+
+var (
+	ErrBoardNotFound = errors.New("board not found")
+	ErrBoardExists   = errors.New("board already exists")
+	ErrInvalidBoard  = errors.New("invalid board id")
+
+	boardIDRegex   = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,47}$`)
+	boardSlugRegex = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+type BoardInfo struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	CreatedAt string `json:"createdAt"`
+	UpdatedAt string `json:"updatedAt"`
+}
+
+type boardsFile struct {
+	Version int                  `json:"version"`
+	Items   map[string]BoardInfo `json:"items"`
+}
+
+type BoardRegistry struct {
+	mu      sync.Mutex
+	dataDir string
+	path    string
+}
+
+func NewBoardRegistry(dataDir string) (*BoardRegistry, error) {
+	r := &BoardRegistry{
+		dataDir: dataDir,
+		path:    filepath.Join(dataDir, "boards.json"),
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	file, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := file.Items[DefaultBoardID]; !ok {
+		now := time.Now().UTC().Format(time.RFC3339)
+		file.Items[DefaultBoardID] = BoardInfo{
+			ID:        DefaultBoardID,
+			Name:      "InfraMap",
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if err := r.save(file); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func ValidBoardID(id string) bool {
+	return boardIDRegex.MatchString(id)
+}
+
+func SlugifyBoardName(name string) string {
+	slug := strings.Trim(boardSlugRegex.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if len(slug) > 48 {
+		slug = strings.TrimRight(slug[:48], "-")
+	}
+	return slug
+}
+
+func (r *BoardRegistry) List() ([]BoardInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	file, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]BoardInfo, 0, len(file.Items))
+	for _, info := range file.Items {
+		out = append(out, info)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].ID == DefaultBoardID || out[j].ID == DefaultBoardID {
+			return out[i].ID == DefaultBoardID
+		}
+		return strings.ToLower(out[i].Name) < strings.ToLower(out[j].Name)
+	})
+	return out, nil
+}
+
+func (r *BoardRegistry) Get(id string) (BoardInfo, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	file, err := r.load()
+	if err != nil {
+		return BoardInfo{}, false, err
+	}
+	info, ok := file.Items[id]
+	return info, ok, nil
+}
+
+func (r *BoardRegistry) Create(id, name string) (BoardInfo, error) {
+	if !ValidBoardID(id) {
+		return BoardInfo{}, ErrInvalidBoard
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	file, err := r.load()
+	if err != nil {
+		return BoardInfo{}, err
+	}
+	if _, ok := file.Items[id]; ok {
+		return BoardInfo{}, ErrBoardExists
+	}
+	if err := os.MkdirAll(r.BoardDir(id), 0o755); err != nil {
+		return BoardInfo{}, err
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	info := BoardInfo{
+		ID:        id,
+		Name:      name,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	file.Items[id] = info
+	if err := r.save(file); err != nil {
+		return BoardInfo{}, err
+	}
+	return info, nil
+}
+
+func (r *BoardRegistry) Rename(id, name string) (BoardInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	file, err := r.load()
+	if err != nil {
+		return BoardInfo{}, err
+	}
+	info, ok := file.Items[id]
+	if !ok {
+		return BoardInfo{}, ErrBoardNotFound
+	}
+	info.Name = name
+	info.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	file.Items[id] = info
+	if err := r.save(file); err != nil {
+		return BoardInfo{}, err
+	}
+	return info, nil
+}
+
+func (r *BoardRegistry) Touch(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	file, err := r.load()
+	if err != nil {
+		return err
+	}
+	info, ok := file.Items[id]
+	if !ok {
+		return ErrBoardNotFound
+	}
+	info.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	file.Items[id] = info
+	return r.save(file)
+}
+
+func (r *BoardRegistry) Delete(id string) error {
+	if id == DefaultBoardID {
+		return fmt.Errorf("the default board cannot be deleted")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	file, err := r.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := file.Items[id]; !ok {
+		return ErrBoardNotFound
+	}
+	delete(file.Items, id)
+	if err := r.save(file); err != nil {
+		return err
+	}
+	return os.RemoveAll(r.BoardDir(id))
+}
+
+func (r *BoardRegistry) BoardDir(id string) string {
+	if id == DefaultBoardID {
+		return r.dataDir
+	}
+	return filepath.Join(r.dataDir, "boards", id)
+}
+
+func (r *BoardRegistry) BoardFile(id string) string {
+	return filepath.Join(r.BoardDir(id), "board.json")
+}
+
+func (r *BoardRegistry) RevisionsDir(id string) string {
+	return filepath.Join(r.BoardDir(id), "revisions")
+}
+
+func (r *BoardRegistry) load() (*boardsFile, error) {
+	data, err := os.ReadFile(r.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &boardsFile{Version: 1, Items: make(map[string]BoardInfo)}, nil
+		}
+		return nil, err
+	}
+	var file boardsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if file.Items == nil {
+		file.Items = make(map[string]BoardInfo)
+	}
+	if file.Version == 0 {
+		file.Version = 1
+	}
+	return &file, nil
+}
+
+func (r *BoardRegistry) save(file *boardsFile) error {
+	payload, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, payload, 0o644)
+}
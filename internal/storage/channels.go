@@ -0,0 +1,220 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"inframap/internal/model"
+)
+
+var ErrChannelNotFound = errors.New("notification channel not found")
+
+type ChannelStore struct {
+	mu   sync.Mutex
+	key  []byte
+	path string
+}
+
+func NewChannelStore(keyPath, dataPath string) (*ChannelStore, error) {
+	key, err := loadOrCreateKey(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	store := &ChannelStore{key: key, path: dataPath}
+	if _, err := store.load(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func (c *ChannelStore) CopyScope(from, to string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	file, err := c.load()
+	if err != nil {
+		return err
+	}
+	fromPrefix, toPrefix := from+"/", to+"/"
+	for key, blob := range file.Items {
+		if strings.HasPrefix(key, fromPrefix) {
+			file.Items[toPrefix+strings.TrimPrefix(key, fromPrefix)] = blob
+		}
+	}
+	file.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	return c.save(file)
+}
+
+func (c *ChannelStore) DeleteScope(board string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	file, err := c.load()
+	if err != nil {
+		return err
+	}
+	prefix := board + "/"
+	for key := range file.Items {
+		if strings.HasPrefix(key, prefix) {
+			delete(file.Items, key)
+		}
+	}
+	file.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	return c.save(file)
+}
+
+type ScopedChannels struct {
+	store  *ChannelStore
+	prefix string
+}
+
+func (c *ChannelStore) Scope(board string) *ScopedChannels {
+	return &ScopedChannels{store: c, prefix: board + "/"}
+}
+
+func (c *ScopedChannels) List() ([]model.NotificationChannel, error) {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	file, err := c.store.load()
+	if err != nil {
+		return nil, err
+	}
+	out := []model.NotificationChannel{}
+	for key, blob := range file.Items {
+		if !strings.HasPrefix(key, c.prefix) {
+			continue
+		}
+		channel, err := c.store.decode(blob)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, channel)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].CreatedAt != out[j].CreatedAt {
+			return out[i].CreatedAt < out[j].CreatedAt
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out, nil
+}
+
+func (c *ScopedChannels) Get(id string) (model.NotificationChannel, bool, error) {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	file, err := c.store.load()
+	if err != nil {
+		return model.NotificationChannel{}, false, err
+	}
+	blob, ok := file.Items[c.prefix+id]
+	if !ok {
+		return model.NotificationChannel{}, false, nil
+	}
+	channel, err := c.store.decode(blob)
+	if err != nil {
+		return model.NotificationChannel{}, false, err
+	}
+	return channel, true, nil
+}
+
+func (c *ScopedChannels) Put(channel model.NotificationChannel) (model.NotificationChannel, error) {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	file, err := c.store.load()
+	if err != nil {
+		return model.NotificationChannel{}, err
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	if channel.ID == "" {
+		id, err := newID()
+		if err != nil {
+			return model.NotificationChannel{}, err
+		}
+		channel.ID = id
+		channel.CreatedAt = now
+	} else {
+		blob, ok := file.Items[c.prefix+channel.ID]
+		if !ok {
+			return model.NotificationChannel{}, ErrChannelNotFound
+		}
+		prev, err := c.store.decode(blob)
+		if err != nil {
+			return model.NotificationChannel{}, err
+		}
+		channel.CreatedAt = prev.CreatedAt
+	}
+	channel.UpdatedAt = now
+	raw, err := json.Marshal(channel)
+	if err != nil {
+		return model.NotificationChannel{}, err
+	}
+	blob, err := encryptPayload(c.store.key, raw)
+	if err != nil {
+		return model.NotificationChannel{}, err
+	}
+	file.Items[c.prefix+channel.ID] = blob
+	file.UpdatedAt = now
+	return channel, c.store.save(file)
+}
+
+func (c *ScopedChannels) Delete(id string) error {
+	c.store.mu.Lock()
+	defer c.store.mu.Unlock()
+	file, err := c.store.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := file.Items[c.prefix+id]; !ok {
+		return ErrChannelNotFound
+	}
+	delete(file.Items, c.prefix+id)
+	file.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	return c.store.save(file)
+}
+
+func (c *ChannelStore) decode(blob string) (model.NotificationChannel, error) {
+	plaintext, err := decryptPayload(c.key, blob)
+	if err != nil {
+		return model.NotificationChannel{}, err
+	}
+	var channel model.NotificationChannel
+	if err := json.Unmarshal(plaintext, &channel); err != nil {
+		return model.NotificationChannel{}, err
+	}
+	return channel, nil
+}
+
+func (c *ChannelStore) load() (*SecretsFile, error) {
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &SecretsFile{
+				Version: 1,
+				Items:   make(map[string]string),
+			}, nil
+		}
+		return nil, err
+	}
+	var file SecretsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if file.Items == nil {
+		file.Items = make(map[string]string)
+	}
+	if file.Version == 0 {
+		file.Version = 1
+	}
+	return &file, nil
+}
+
+func (c *ChannelStore) save(file *SecretsFile) error {
+	payload, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, payload, 0o600)
+}
@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"inframap/internal/model"
+)
+
+type factsFile struct {
+	Version   int                        `json:"version"`
+	UpdatedAt string                     `json:"updatedAt"`
+	Items     map[string]model.NodeFacts `json:"items"`
+}
+
+type FactsStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewFactsStore(path string) (*FactsStore, error) {
+	store := &FactsStore{path: path}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if _, err := store.load(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func (f *FactsStore) CopyScope(from, to string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file, err := f.load()
+	if err != nil {
+		return err
+	}
+	fromPrefix, toPrefix := from+"/", to+"/"
+	for key, entry := range file.Items {
+		if strings.HasPrefix(key, fromPrefix) {
+			file.Items[toPrefix+strings.TrimPrefix(key, fromPrefix)] = entry
+		}
+	}
+	file.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	return f.save(file)
+}
+
+func (f *FactsStore) DeleteScope(board string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file, err := f.load()
+	if err != nil {
+		return err
+	}
+	prefix := board + "/"
+	for key := range file.Items {
+		if strings.HasPrefix(key, prefix) {
+			delete(file.Items, key)
+		}
+	}
+	file.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	return f.save(file)
+}
+
+type ScopedFacts struct {
+	store  *FactsStore
+	prefix string
+}
+
+func (f *FactsStore) Scope(board string) *ScopedFacts {
+	return &ScopedFacts{store: f, prefix: board + "/"}
+}
+
+func (f *ScopedFacts) List() (map[string]model.NodeFacts, error) {
+	f.store.mu.Lock()
+	defer f.store.mu.Unlock()
+	file, err := f.store.load()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]model.NodeFacts)
+	for key, entry := range file.Items {
+		if strings.HasPrefix(key, f.prefix) {
+			out[strings.TrimPrefix(key, f.prefix)] = entry
+		}
+	}
+	return out, nil
+}
+
+func (f *ScopedFacts) Get(node string) (model.NodeFacts, bool, error) {
+	f.store.mu.Lock()
+	defer f.store.mu.Unlock()
+	file, err := f.store.load()
+	if err != nil {
+		return model.NodeFacts{}, false, err
+	}
+	entry, ok := file.Items[f.prefix+node]
+	return entry, ok, nil
+}
+
+func (f *ScopedFacts) Record(node string, facts map[string]model.Fact) (model.NodeFacts, error) {
+	f.store.mu.Lock()
+	defer f.store.mu.Unlock()
+	file, err := f.store.load()
+	if err != nil {
+		return model.NodeFacts{}, err
+	}
+	key := f.prefix + node
+	entry := file.Items[key]
+	if entry.Probes == nil {
+		entry.Probes = make(map[string]model.Fact)
+	}
+	for name, fact := range facts {
+		if fact.Error != "" {
+			if prev, ok := entry.Probes[name]; ok {
+				fact.Value = prev.Value
+				fact.CollectedAt = prev.CollectedAt
+			}
+		}
+		entry.Probes[name] = fact
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	entry.UpdatedAt = now
+	file.Items[key] = entry
+	file.UpdatedAt = now
+	return entry, f.store.save(file)
+}
+
+func (f *ScopedFacts) Delete(node string) error {
+	f.store.mu.Lock()
+	defer f.store.mu.Unlock()
+	file, err := f.store.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := file.Items[f.prefix+node]; !ok {
+		return nil
+	}
+	delete(file.Items, f.prefix+node)
+	file.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	return f.store.save(file)
+}
+
+func (f *FactsStore) load() (*factsFile, error) {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &factsFile{
+				Version: 1,
+				Items:   make(map[string]model.NodeFacts),
+			}, nil
+		}
+		return nil, err
+	}
+	var file factsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if file.Items == nil {
+		file.Items = make(map[string]model.NodeFacts)
+	}
+	if file.Version == 0 {
+		file.Version = 1
+	}
+	return &file, nil
+}
+
+func (f *FactsStore) save(file *factsFile) error {
+	payload, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(f.path, payload, 0o644)
+}
@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"inframap/internal/model"
+)
+
+var ErrNoOfferedKey = errors.New("no new host key has been offered by this device")
+
+type knownHostsFile struct {
+	Version   int                        `json:"version"`
+	UpdatedAt string                     `json:"updatedAt"`
+	Items     map[string]model.KnownHost `json:"items"`
+}
+
+type KnownHostsStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewKnownHostsStore(path string) (*KnownHostsStore, error) {
+	store := &KnownHostsStore{path: path}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if _, err := store.load(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func (k *KnownHostsStore) get(key string) (model.KnownHost, bool, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	file, err := k.load()
+	if err != nil {
+		return model.KnownHost{}, false, err
+	}
+	entry, ok := file.Items[key]
+	return entry, ok, nil
+}
+
+func (k *KnownHostsStore) update(key string, fn func(entry *model.KnownHost) error) (model.KnownHost, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	file, err := k.load()
+	if err != nil {
+		return model.KnownHost{}, err
+	}
+	entry := file.Items[key]
+	if err := fn(&entry); err != nil {
+		return model.KnownHost{}, err
+	}
+	if entry.Pinned == nil && entry.Offered == nil {
+		delete(file.Items, key)
+	} else {
+		file.Items[key] = entry
+	}
+	file.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	return entry, k.save(file)
+}
+
+func (k *KnownHostsStore) CopyScope(from, to string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	file, err := k.load()
+	if err != nil {
+		return err
+	}
+	fromPrefix, toPrefix := from+"/", to+"/"
+	for key, entry := range file.Items {
+		if strings.HasPrefix(key, fromPrefix) {
+			file.Items[toPrefix+strings.TrimPrefix(key, fromPrefix)] = entry
+		}
+	}
+	file.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	return k.save(file)
+}
+
+func (k *KnownHostsStore) DeleteScope(board string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	file, err := k.load()
+	if err != nil {
+		return err
+	}
+	prefix := board + "/"
+	for key := range file.Items {
+		if strings.HasPrefix(key, prefix) {
+			delete(file.Items, key)
+		}
+	}
+	file.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	return k.save(file)
+}
+
+type ScopedKnownHosts struct {
+	store  *KnownHostsStore
+	prefix string
+}
+
+func (k *KnownHostsStore) Scope(board string) *ScopedKnownHosts {
+	return &ScopedKnownHosts{store: k, prefix: board + "/"}
+}
+
+func (k *ScopedKnownHosts) List() (map[string]model.KnownHost, error) {
+	k.store.mu.Lock()
+	defer k.store.mu.Unlock()
+	file, err := k.store.load()
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]model.KnownHost)
+	for key, entry := range file.Items {
+		if strings.HasPrefix(key, k.prefix) {
+			out[strings.TrimPrefix(key, k.prefix)] = entry
+		}
+	}
+	return out, nil
+}
+
+func (k *ScopedKnownHosts) Get(device string) (model.KnownHost, bool, error) {
+	return k.store.get(k.prefix + device)
+}
+
+func (k *ScopedKnownHosts) Pin(device string, key model.HostKey) error {
+	_, err := k.store.update(k.prefix+device, func(entry *model.KnownHost) error {
+		entry.Pinned = &key
+		entry.Offered = nil
+		return nil
+	})
+	return err
+}
+
+func (k *ScopedKnownHosts) Offer(device string, key model.HostKey) error {
+	_, err := k.store.update(k.prefix+device, func(entry *model.KnownHost) error {
+		if entry.Offered != nil && entry.Offered.Key == key.Key {
+			return nil
+		}
+		entry.Offered = &key
+		return nil
+	})
+	return err
+}
+
+func (k *ScopedKnownHosts) Accept(device string) (model.KnownHost, error) {
+	return k.store.update(k.prefix+device, func(entry *model.KnownHost) error {
+		if entry.Offered == nil {
+			return ErrNoOfferedKey
+		}
+		entry.Pinned = entry.Offered
+		entry.Offered = nil
+		return nil
+	})
+}
+
+func (k *ScopedKnownHosts) Reset(device string) error {
+	_, err := k.store.update(k.prefix+device, func(entry *model.KnownHost) error {
+		entry.Pinned = nil
+		entry.Offered = nil
+		return nil
+	})
+	return err
+}
+
+func (k *KnownHostsStore) load() (*knownHostsFile, error) {
+	data, err := os.ReadFile(k.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &knownHostsFile{
+				Version: 1,
+				Items:   make(map[string]model.KnownHost),
+			}, nil
+		}
+		return nil, err
+	}
+	var file knownHostsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if file.Items == nil {
+		file.Items = make(map[string]model.KnownHost)
+	}
+	if file.Version == 0 {
+		file.Version = 1
+	}
+	return &file, nil
+}
+
+func (k *KnownHostsStore) save(file *knownHostsFile) error {
+	payload, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(k.path, payload, 0o600)
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"inframap/internal/model"
+)
+
+func TestKnownHostsLifecycle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "known_hosts.json")
+	store, err := NewKnownHostsStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	main := store.Scope("main")
+	if _, err := main.Accept("node-1"); !errors.Is(err, ErrNoOfferedKey) {
+		t.Fatalf("accept without offer err = %v", err)
+	}
+	old := model.HostKey{Type: "ssh-ed25519", Fingerprint: "SHA256:old", Key: "old"}
+	next := model.HostKey{Type: "ssh-ed25519", Fingerprint: "SHA256:new", Key: "new"}
+	if err := main.Pin("node-1", old); err != nil {
+		t.Fatal(err)
+	}
+	if err := main.Offer("node-1", next); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewKnownHostsStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, ok, _ := reopened.Scope("main").Get("node-1")
+	if !ok || entry.Pinned.Key != "old" || entry.Offered.Key != "new" {
+		t.Fatalf("reopened entry = %+v", entry)
+	}
+
+	if err := store.CopyScope("main", "lab"); err != nil {
+		t.Fatal(err)
+	}
+	entry, err = main.Accept("node-1")
+	if err != nil || entry.Pinned.Key != "new" || entry.Offered != nil {
+		t.Fatalf("accepted = %+v, %v", entry, err)
+	}
+	if lab, _, _ := store.Scope("lab").Get("node-1"); lab.Pinned.Key != "old" {
+		t.Fatalf("copied scope changed with source: %+v", lab)
+	}
+
+	if err := main.Reset("node-1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := main.Get("node-1"); ok {
+		t.Fatal("reset entry still stored")
+	}
+	if err := store.DeleteScope("lab"); err != nil {
+		t.Fatal(err)
+	}
+	if items, _ := store.Scope("lab").List(); len(items) != 0 {
+		t.Fatalf("deleted scope = %v", items)
+	}
+}
@@ -8,9 +8,10 @@ import (
 )
 
 type LogStore struct {
-	mu    sync.Mutex
-	items []model.LogEntry
-	max   int
+	mu       sync.Mutex
+	items    []model.LogEntry
+	max      int
+	listener func(model.LogEntry)
 }
 
 func NewLogStore(max int) *LogStore {
@@ -34,13 +35,26 @@ func (l *LogStore) Add(level, source, message string) {
 		Message: message,
 	}
 	l.mu.Lock()
-	defer l.mu.Unlock()
 	if len(l.items) >= l.max {
 		copy(l.items, l.items[1:])
 		l.items[len(l.items)-1] = entry
+	} else {
+		l.items = append(l.items, entry)
+	}
+	listener := l.listener
+	l.mu.Unlock()
+	if listener != nil {
+		listener(entry)
+	}
+}
+
+func (l *LogStore) SetListener(fn func(model.LogEntry)) {
+	if l == nil {
 		return
 	}
-	l.items = append(l.items, entry)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.listener = fn
 }
 
 func (l *LogStore) List(limit int) []model.LogEntry {
@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"inframap/internal/model"
+)
+
+type RevisionMeta struct {
+	Number    int    `json:"number"`
+	CreatedAt string `json:"createdAt"`
+	Author    string `json:"author"`
+	Summary   string `json:"summary"`
+}
+
+type Revision struct {
+	RevisionMeta
+	Board model.Board `json:"board"`
+}
+
+type revisionIndex struct {
+	Version int            `json:"version"`
+	Next    int            `json:"next"`
+	Items   []RevisionMeta `json:"items"`
+}
+
+type RevisionStore struct {
+	mu  sync.Mutex
+	dir string
+	max int
+}
+
+func NewRevisionStore(dir string, max int) (*RevisionStore, error) {
+	if max <= 0 {
+		max = 200
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &RevisionStore{
+		dir: dir,
+		max: max,
+	}, nil
+}
+
+func (r *RevisionStore) List() ([]RevisionMeta, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	index, err := r.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]RevisionMeta, len(index.Items))
+	copy(out, index.Items)
+	return out, nil
+}
+
+func (r *RevisionStore) Get(number int) (Revision, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.read(number)
+}
+
+func (r *RevisionStore) Latest() (Revision, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	index, err := r.loadIndex()
+	if err != nil {
+		return Revision{}, false, err
+	}
+	if len(index.Items) == 0 {
+		return Revision{}, false, nil
+	}
+	return r.read(index.Items[len(index.Items)-1].Number)
+}
+
+func (r *RevisionStore) Add(board model.Board, author, summary string) (RevisionMeta, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	index, err := r.loadIndex()
+	if err != nil {
+		return RevisionMeta{}, err
+	}
+	meta := RevisionMeta{
+		Number:    index.Next,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+		Author:    author,
+		Summary:   summary,
+	}
+	payload, err := json.MarshalIndent(Revision{RevisionMeta: meta, Board: board}, "", "  ")
+	if err != nil {
+		return RevisionMeta{}, err
+	}
+	if err := os.WriteFile(r.revisionPath(meta.Number), payload, 0o644); err != nil {
+		return RevisionMeta{}, err
+	}
+	index.Items = append(index.Items, meta)
+	index.Next++
+	for len(index.Items) > r.max {
+		_ = os.Remove(r.revisionPath(index.Items[0].Number))
+		index.Items = index.Items[1:]
+	}
+	if err := r.saveIndex(index); err != nil {
+		return RevisionMeta{}, err
+	}
+	return meta, nil
+}
+
+func (r *RevisionStore) read(number int) (Revision, bool, error) {
+	data, err := os.ReadFile(r.revisionPath(number))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Revision{}, false, nil
+		}
+		return Revision{}, false, err
+	}
+	var rev Revision
+	if err := json.Unmarshal(data, &rev); err != nil {
+		return Revision{}, false, err
+	}
+	return rev, true, nil
+}
+
+func (r *RevisionStore) revisionPath(number int) string {
+	return filepath.Join(r.dir, fmt.Sprintf("rev-%06d.json", number))
+}
+
+func (r *RevisionStore) indexPath() string {
+	return filepath.Join(r.dir, "index.json")
+}
+
+func (r *RevisionStore) loadIndex() (*revisionIndex, error) {
+	data, err := os.ReadFile(r.indexPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &revisionIndex{Version: 1, Next: 1, Items: []RevisionMeta{}}, nil
+		}
+		return nil, err
+	}
+	var index revisionIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, err
+	}
+	if index.Items == nil {
+		index.Items = []RevisionMeta{}
+	}
+	if index.Next <= 0 {
+		index.Next = 1
+		for _, item := range index.Items {
+			if item.Number >= index.Next {
+				index.Next = item.Number + 1
+			}
+		}
+	}
+	return &index, nil
+}
+
+func (r *RevisionStore) saveIndex(index *revisionIndex) error {
+	payload, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.indexPath(), payload, 0o644)
+}
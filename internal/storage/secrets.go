@@ -92,6 +92,87 @@ func (s *SecretStore) Delete(id string) error {
 	return s.save(file)
 }
 
+type ScopedSecrets struct {
+	store  *SecretStore
+	prefix string
+}
+
+func (s *SecretStore) Scope(board string) *ScopedSecrets {
+	return &ScopedSecrets{store: s, prefix: board + "/"}
+}
+
+func (s *ScopedSecrets) Get(id string) (model.DeviceSettings, bool, error) {
+	return s.store.Get(s.prefix + id)
+}
+
+func (s *ScopedSecrets) Set(id string, settings model.DeviceSettings) error {
+	return s.store.Set(s.prefix+id, settings)
+}
+
+func (s *ScopedSecrets) Delete(id string) error {
+	return s.store.Delete(s.prefix + id)
+}
+
+func (s *SecretStore) CopyScope(from, to string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := s.load()
+	if err != nil {
+		return err
+	}
+	fromPrefix, toPrefix := from+"/", to+"/"
+	for key, blob := range file.Items {
+		if strings.HasPrefix(key, fromPrefix) {
+			file.Items[toPrefix+strings.TrimPrefix(key, fromPrefix)] = blob
+		}
+	}
+	file.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	return s.save(file)
+}
+
+func (s *SecretStore) DeleteScope(board string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := s.load()
+	if err != nil {
+		return err
+	}
+	prefix := board + "/"
+	for key := range file.Items {
+		if strings.HasPrefix(key, prefix) {
+			delete(file.Items, key)
+		}
+	}
+	file.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	return s.save(file)
+}
+
+func (s *SecretStore) MigrateUnscoped(board string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := s.load()
+	if err != nil {
+		return 0, err
+	}
+	moved := 0
+	for key, blob := range file.Items {
+		if strings.Contains(key, "/") {
+			continue
+		}
+		scoped := board + "/" + key
+		if _, exists := file.Items[scoped]; !exists {
+			file.Items[scoped] = blob
+		}
+		delete(file.Items, key)
+		moved++
+	}
+	if moved == 0 {
+		return 0, nil
+	}
+	file.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	return moved, s.save(file)
+}
+
 func (s *SecretStore) load() (*SecretsFile, error) {
 	if _, err := os.Stat(s.path); err != nil {
 		if os.IsNotExist(err) {
@@ -0,0 +1,435 @@
+package storage
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	RoleViewer   = "viewer"
+	RoleEditor   = "editor"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+)
+
+var (
+	ErrInvalidRole        = errors.New("invalid role (use viewer, editor, operator or admin)")
+	ErrLastAdmin          = errors.New("at least one admin account is required")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrUserExists         = errors.New("user already exists")
+	ErrInvalidUsername    = errors.New("invalid username")
+	ErrWeakPassword       = errors.New("password must be at least 8 characters")
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrTokenNotFound      = errors.New("token not found")
+	ErrSetupDone          = errors.New("setup already completed")
+
+	usernameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._@-]{0,63}$`)
+)
+
+type User struct {
+	Username     string `json:"username"`
+	PasswordHash string `json:"passwordHash,omitempty"`
+	Role         string `json:"role"`
+	CreatedAt    string `json:"createdAt"`
+	UpdatedAt    string `json:"updatedAt"`
+}
+
+type APIToken struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Username  string `json:"username"`
+	Hash      string `json:"hash,omitempty"`
+	CreatedAt string `json:"createdAt"`
+	LastUsed  string `json:"lastUsed,omitempty"`
+}
+
+type usersFile struct {
+	Version int                 `json:"version"`
+	Users   map[string]User     `json:"users"`
+	Tokens  map[string]APIToken `json:"tokens"`
+}
+
+type UserStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+func NewUserStore(path string) (*UserStore, error) {
+	store := &UserStore{path: path}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if _, err := store.load(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func (u *UserStore) Count() (int, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	file, err := u.load()
+	if err != nil {
+		return 0, err
+	}
+	return len(file.Users), nil
+}
+
+func (u *UserStore) List() ([]User, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	file, err := u.load()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]User, 0, len(file.Users))
+	for _, user := range file.Users {
+		user.PasswordHash = ""
+		out = append(out, user)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Username < out[j].Username })
+	return out, nil
+}
+
+func (u *UserStore) Get(username string) (User, bool, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	file, err := u.load()
+	if err != nil {
+		return User{}, false, err
+	}
+	user, ok := file.Users[strings.ToLower(username)]
+	return user, ok, nil
+}
+
+func ValidRole(role string) bool {
+	switch role {
+	case RoleViewer, RoleEditor, RoleOperator, RoleAdmin:
+		return true
+	default:
+		return false
+	}
+}
+
+func (u *UserStore) Create(username, password, role string) (User, error) {
+	return u.create(username, password, role, false)
+}
+
+func (u *UserStore) CreateFirstAdmin(username, password string) (User, error) {
+	return u.create(username, password, RoleAdmin, true)
+}
+
+func (u *UserStore) create(username, password, role string, first bool) (User, error) {
+	username = strings.TrimSpace(username)
+	if !usernameRegex.MatchString(username) {
+		return User{}, ErrInvalidUsername
+	}
+	if !ValidRole(role) {
+		return User{}, ErrInvalidRole
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return User{}, err
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	file, err := u.load()
+	if err != nil {
+		return User{}, err
+	}
+	if first && len(file.Users) > 0 {
+		return User{}, ErrSetupDone
+	}
+	key := strings.ToLower(username)
+	if _, ok := file.Users[key]; ok {
+		return User{}, ErrUserExists
+	}
+	now := time.Now().UTC().Format(time.RFC3339)
+	user := User{
+		Username:     username,
+		PasswordHash: hash,
+		Role:         role,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	file.Users[key] = user
+	if err := u.save(file); err != nil {
+		return User{}, err
+	}
+	return user, nil
+}
+
+func (u *UserStore) SetPassword(username, password string) error {
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	file, err := u.load()
+	if err != nil {
+		return err
+	}
+	key := strings.ToLower(username)
+	user, ok := file.Users[key]
+	if !ok {
+		return ErrUserNotFound
+	}
+	user.PasswordHash = hash
+	user.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	file.Users[key] = user
+	return u.save(file)
+}
+
+func (u *UserStore) SetRole(username, role string) (User, error) {
+	if !ValidRole(role) {
+		return User{}, ErrInvalidRole
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	file, err := u.load()
+	if err != nil {
+		return User{}, err
+	}
+	key := strings.ToLower(username)
+	user, ok := file.Users[key]
+	if !ok {
+		return User{}, ErrUserNotFound
+	}
+	if user.Role == RoleAdmin && role != RoleAdmin && countAdmins(file) <= 1 {
+		return User{}, ErrLastAdmin
+	}
+	user.Role = role
+	user.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	file.Users[key] = user
+	if err := u.save(file); err != nil {
+		return User{}, err
+	}
+	user.PasswordHash = ""
+	return user, nil
+}
+
+func (u *UserStore) Delete(username string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	file, err := u.load()
+	if err != nil {
+		return err
+	}
+	key := strings.ToLower(username)
+	user, ok := file.Users[key]
+	if !ok {
+		return ErrUserNotFound
+	}
+	if user.Role == RoleAdmin && countAdmins(file) <= 1 {
+		return ErrLastAdmin
+	}
+	delete(file.Users, key)
+	for id, token := range file.Tokens {
+		if strings.EqualFold(token.Username, username) {
+			delete(file.Tokens, id)
+		}
+	}
+	return u.save(file)
+}
+
+func (u *UserStore) Authenticate(username, password string) (User, error) {
+	user, ok, err := u.Get(strings.TrimSpace(username))
+	if err != nil {
+		return User{}, err
+	}
+	if !ok {
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(password))
+		return User{}, ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
+		return User{}, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+func (u *UserStore) CreateToken(username, name string) (APIToken, string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return APIToken{}, "", err
+	}
+	idRaw := make([]byte, 8)
+	if _, err := rand.Read(idRaw); err != nil {
+		return APIToken{}, "", err
+	}
+	secret := "imt_" + base64.RawURLEncoding.EncodeToString(raw)
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	file, err := u.load()
+	if err != nil {
+		return APIToken{}, "", err
+	}
+	user, ok := file.Users[strings.ToLower(username)]
+	if !ok {
+		return APIToken{}, "", ErrUserNotFound
+	}
+	token := APIToken{
+		ID:        hex.EncodeToString(idRaw),
+		Name:      strings.TrimSpace(name),
+		Username:  user.Username,
+		Hash:      hashToken(secret),
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+	file.Tokens[token.ID] = token
+	if err := u.save(file); err != nil {
+		return APIToken{}, "", err
+	}
+	return token, secret, nil
+}
+
+func (u *UserStore) ListTokens(username string) ([]APIToken, error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	file, err := u.load()
+	if err != nil {
+		return nil, err
+	}
+	out := []APIToken{}
+	for _, token := range file.Tokens {
+		if username == "" || strings.EqualFold(token.Username, username) {
+			token.Hash = ""
+			out = append(out, token)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt < out[j].CreatedAt })
+	return out, nil
+}
+
+func (u *UserStore) DeleteToken(username, id string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	file, err := u.load()
+	if err != nil {
+		return err
+	}
+	token, ok := file.Tokens[id]
+	if !ok || (username != "" && !strings.EqualFold(token.Username, username)) {
+		return ErrTokenNotFound
+	}
+	delete(file.Tokens, id)
+	return u.save(file)
+}
+
+func (u *UserStore) AuthenticateToken(secret string) (User, error) {
+	hash := hashToken(secret)
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	file, err := u.load()
+	if err != nil {
+		return User{}, err
+	}
+	for id, token := range file.Tokens {
+		if subtle.ConstantTimeCompare([]byte(token.Hash), []byte(hash)) != 1 {
+			continue
+		}
+		user, ok := file.Users[strings.ToLower(token.Username)]
+		if !ok {
+			return User{}, ErrInvalidCredentials
+		}
+		now := time.Now().UTC()
+		if last, err := time.Parse(time.RFC3339, token.LastUsed); err != nil || now.Sub(last) > time.Minute {
+			token.LastUsed = now.Format(time.RFC3339)
+			file.Tokens[id] = token
+			_ = u.save(file)
+		}
+		return user, nil
+	}
+	return User{}, ErrInvalidCredentials
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+func dummyPasswordHash() []byte {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("inframap-dummy-password"), bcrypt.DefaultCost)
+	})
+	return dummyHash
+}
+
+func hashPassword(password string) (string, error) {
+	if len(password) < 8 {
+		return "", ErrWeakPassword
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func countAdmins(file *usersFile) int {
+	count := 0
+	for _, user := range file.Users {
+		if user.Role == RoleAdmin {
+			count++
+		}
+	}
+	return count
+}
+
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func (u *UserStore) load() (*usersFile, error) {
+	data, err := os.ReadFile(u.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &usersFile{
+				Version: 1,
+				Users:   make(map[string]User),
+				Tokens:  make(map[string]APIToken),
+			}, nil
+		}
+		return nil, err
+	}
+	var file usersFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if file.Users == nil {
+		file.Users = make(map[string]User)
+	}
+	if file.Tokens == nil {
+		file.Tokens = make(map[string]APIToken)
+	}
+	for key, user := range file.Users {
+		if user.Role == "" {
+			user.Role = RoleAdmin
+			file.Users[key] = user
+		}
+	}
+	if file.Version == 0 {
+		file.Version = 1
+	}
+	return &file, nil
+}
+
+func (u *UserStore) save(file *usersFile) error {
+	payload, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(u.path, payload, 0o600)
+}
@@ -0,0 +1,135 @@
+package tsdb
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const maxPoints = 2000
+
+type Point struct {
+	Time    string  `json:"t"`
+	Samples int     `json:"samples"`
+	Up      int     `json:"up"`
+	Uptime  float64 `json:"uptime"`
+	RTTAvg  float64 `json:"rttAvg,omitempty"`
+	RTTMin  float64 `json:"rttMin,omitempty"`
+	RTTMax  float64 `json:"rttMax,omitempty"`
+	Error   string  `json:"error,omitempty"`
+}
+
+type Result struct {
+	Kind       string  `json:"kind"`
+	Node       string  `json:"node"`
+	From       string  `json:"from"`
+	To         string  `json:"to"`
+	StepSec    int64   `json:"stepSec"`
+	Resolution string  `json:"resolution"`
+	Points     []Point `json:"points"`
+}
+
+func (s *Store) query(board, kind, node string, from, to time.Time, step time.Duration) (Result, error) {
+	if kind != KindPing && kind != KindSSH && kind != KindCheck {
+		return Result{}, fmt.Errorf("unknown metric %q", kind)
+	}
+	if node == "" {
+		return Result{}, errors.New("node is required")
+	}
+	if !to.After(from) {
+		return Result{}, errors.New("from must be before to")
+	}
+	span := to.Sub(from)
+	if step <= 0 {
+		step = span / 300
+	}
+	if min := span / maxPoints; step < min {
+		step = min
+	}
+	res := s.resolutionFor(from, step)
+	if width := resolutionWidth(res); step < width {
+		step = width
+	}
+	step = step.Round(time.Second)
+	if step < time.Second {
+		step = time.Second
+	}
+
+	buckets, err := s.series(board, kind, res, node, from, to, int64(step.Seconds()))
+	if err != nil {
+		return Result{}, err
+	}
+	points := make([]Point, 0, len(buckets))
+	for _, bucket := range buckets {
+		point := Point{
+			Time:    time.Unix(bucket.T, 0).UTC().Format(time.RFC3339),
+			Samples: bucket.Count,
+			Up:      bucket.Up,
+			Error:   bucket.Error,
+		}
+		if bucket.Count > 0 {
+			point.Uptime = round(float64(bucket.Up) / float64(bucket.Count))
+		}
+		if bucket.RTTCount > 0 {
+			point.RTTAvg = round(bucket.RTTSum / float64(bucket.RTTCount))
+			point.RTTMin = bucket.RTTMin
+			point.RTTMax = bucket.RTTMax
+		}
+		points = append(points, point)
+	}
+
+	return Result{
+		Kind:       kind,
+		Node:       node,
+		From:       from.UTC().Format(time.RFC3339),
+		To:         to.UTC().Format(time.RFC3339),
+		StepSec:    int64(step.Seconds()),
+		Resolution: res,
+		Points:     points,
+	}, nil
+}
+
+func (s *Store) series(board, kind, res, node string, from, to time.Time, width int64) ([]Sample, error) {
+	s.mu.Lock()
+	samples, err := s.read(board, kind, res, node, from, to)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if width <= 0 {
+		width = 1
+	}
+	buckets := make(map[int64]*Sample)
+	for _, sample := range samples {
+		start := sample.T - sample.T%width
+		bucket, ok := buckets[start]
+		if !ok {
+			bucket = &Sample{T: start, Node: node}
+			buckets[start] = bucket
+		}
+		merge(bucket, sample)
+	}
+	out := make([]Sample, 0, len(buckets))
+	for _, bucket := range buckets {
+		out = append(out, *bucket)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].T < out[j].T })
+	return out, nil
+}
+
+func (s *Store) resolutionFor(from time.Time, step time.Duration) string {
+	age := time.Since(from)
+	switch {
+	case step < time.Minute && age <= s.retention.Raw:
+		return ResRaw
+	case step < time.Hour && age <= s.retention.Minute:
+		return ResMinute
+	default:
+		return ResHour
+	}
+}
+
+func round(value float64) float64 {
+	return float64(int64(value*10000+0.5)) / 10000
+}
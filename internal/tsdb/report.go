@@ -0,0 +1,152 @@
+package tsdb
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type Outage struct {
+	Start       string `json:"start"`
+	End         string `json:"end"`
+	DurationSec int64  `json:"durationSec"`
+	Ongoing     bool   `json:"ongoing,omitempty"`
+}
+
+type Availability struct {
+	Samples          int      `json:"samples"`
+	Up               int      `json:"up"`
+	UptimePct        *float64 `json:"uptimePct"`
+	Outages          int      `json:"outages"`
+	DowntimeSec      int64    `json:"downtimeSec"`
+	MTTRSec          int64    `json:"mttrSec"`
+	LongestOutageSec int64    `json:"longestOutageSec"`
+	Resolution       string   `json:"resolution,omitempty"`
+	Incidents        []Outage `json:"incidents,omitempty"`
+
+	resolved    int
+	resolvedSec int64
+}
+
+func (sc *Scoped) Availability(kind, node string, from, to time.Time) (Availability, error) {
+	return sc.store.availability(sc.board, kind, node, from, to)
+}
+
+func (s *Store) availability(board, kind, node string, from, to time.Time) (Availability, error) {
+	if kind != KindPing && kind != KindSSH {
+		return Availability{}, fmt.Errorf("unknown metric %q", kind)
+	}
+	if !to.After(from) {
+		return Availability{}, errors.New("from must be before to")
+	}
+	report := Availability{Resolution: s.resolutionFor(from, 0)}
+	var start, downUntil int64
+	down := false
+	for _, span := range s.spans(from, to) {
+		width := int64(resolutionWidth(span.res).Seconds())
+		buckets, err := s.series(board, kind, span.res, node, span.from, span.to, width)
+		if err != nil {
+			return Availability{}, err
+		}
+		for _, bucket := range buckets {
+			if bucket.Count == 0 {
+				continue
+			}
+			report.Samples += bucket.Count
+			report.Up += bucket.Up
+			if bucket.Up == 0 {
+				if !down {
+					down = true
+					start = max(bucket.T, from.Unix())
+				}
+				downUntil = bucket.T + width
+				continue
+			}
+			if down {
+				report.addOutage(start, bucket.T, false)
+				down = false
+			}
+		}
+	}
+	if down {
+		report.addOutage(start, min(downUntil, to.Unix()), true)
+	}
+	report.finish()
+	return report, nil
+}
+
+type span struct {
+	res      string
+	from, to time.Time
+}
+
+func (s *Store) spans(from, to time.Time) []span {
+	now := time.Now()
+	levels := []struct {
+		res   string
+		until time.Time
+	}{
+		{ResHour, now.Add(-s.retention.Minute).Truncate(time.Hour).Add(time.Hour)},
+		{ResMinute, now.Add(-s.retention.Raw).Truncate(time.Minute).Add(time.Minute)},
+		{ResRaw, to.Add(time.Second)},
+	}
+	var out []span
+	cursor := from
+	for _, level := range levels {
+		until := level.until
+		if until.After(to) {
+			until = to.Add(time.Second)
+		}
+		if !until.After(cursor) {
+			continue
+		}
+		out = append(out, span{res: level.res, from: cursor, to: until.Add(-time.Second)})
+		cursor = until
+	}
+	return out
+}
+
+func (a *Availability) addOutage(start, end int64, ongoing bool) {
+	duration := end - start
+	if duration < 0 {
+		duration = 0
+	}
+	a.Outages++
+	a.DowntimeSec += duration
+	if duration > a.LongestOutageSec {
+		a.LongestOutageSec = duration
+	}
+	if !ongoing {
+		a.resolved++
+		a.resolvedSec += duration
+	}
+	a.Incidents = append(a.Incidents, Outage{
+		Start:       time.Unix(start, 0).UTC().Format(time.RFC3339),
+		End:         time.Unix(end, 0).UTC().Format(time.RFC3339),
+		DurationSec: duration,
+		Ongoing:     ongoing,
+	})
+}
+
+func (a *Availability) Merge(other Availability) {
+	a.Samples += other.Samples
+	a.Up += other.Up
+	a.Outages += other.Outages
+	a.DowntimeSec += other.DowntimeSec
+	a.LongestOutageSec = max(a.LongestOutageSec, other.LongestOutageSec)
+	a.resolved += other.resolved
+	a.resolvedSec += other.resolvedSec
+	a.finish()
+}
+
+func (a *Availability) finish() {
+	a.UptimePct = nil
+	if a.Samples > 0 {
+		pct := round(float64(a.Up) / float64(a.Samples) * 100)
+		a.UptimePct = &pct
+	}
+	a.MTTRSec = 0
+	if a.resolved > 0 {
+		a.MTTRSec = a.resolvedSec / int64(a.resolved)
+	}
+}
@@ -0,0 +1,106 @@
+package tsdb
+
+import (
+	"testing"
+	"time"
+
+	"inframap/internal/model"
+)
+
+func recordPings(sc *Scoped, node string, start time.Time, every time.Duration, count int, down func(i int) bool) {
+	for i := 0; i < count; i++ {
+		sc.RecordPing(map[string]model.PingResult{node: {
+			PingProbe:   model.PingProbe{Online: !down(i)},
+			LastChecked: start.Add(time.Duration(i) * every),
+		}})
+	}
+}
+
+func TestAvailabilityKeepsSubHourOutages(t *testing.T) {
+	store, err := Open(t.TempDir(), Retention{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	sc := store.Scope("main")
+	now := time.Now()
+
+	// 20 days ago only hourly buckets are retained: a two hour outage.
+	hourly := now.Add(-20 * 24 * time.Hour).Truncate(time.Hour)
+	recordPings(sc, "a", hourly, 10*time.Minute, 36, func(i int) bool { return i >= 12 && i < 24 })
+	// 5 days ago minute buckets are used: a 15 minute outage.
+	minutes := now.Add(-5 * 24 * time.Hour).Truncate(time.Minute)
+	recordPings(sc, "a", minutes, time.Minute, 120, func(i int) bool { return i >= 60 && i < 75 })
+	// 3 hours ago raw samples are used: a 5 minute outage.
+	raw := now.Add(-3 * time.Hour).Truncate(time.Minute)
+	recordPings(sc, "a", raw, 30*time.Second, 240, func(i int) bool { return i >= 100 && i < 110 })
+
+	report, err := sc.Availability(KindPing, "a", now.Add(-30*24*time.Hour), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Samples != 36+120+240 || report.Up != 24+105+230 {
+		t.Fatalf("samples = %d up = %d", report.Samples, report.Up)
+	}
+	if report.Outages != 3 || len(report.Incidents) != 3 {
+		t.Fatalf("outages = %d incidents = %+v", report.Outages, report.Incidents)
+	}
+	want := []int64{7200, 900, 300}
+	for i, incident := range report.Incidents {
+		if incident.DurationSec != want[i] || incident.Ongoing {
+			t.Fatalf("incident %d = %+v, want %ds", i, incident, want[i])
+		}
+	}
+	if start := raw.Add(100 * 30 * time.Second).UTC().Format(time.RFC3339); report.Incidents[2].Start != start {
+		t.Fatalf("raw outage start = %s, want %s", report.Incidents[2].Start, start)
+	}
+	if report.DowntimeSec != 8400 || report.MTTRSec != 2800 || report.LongestOutageSec != 7200 {
+		t.Fatalf("downtime = %d mttr = %d longest = %d", report.DowntimeSec, report.MTTRSec, report.LongestOutageSec)
+	}
+	if report.Resolution != ResHour {
+		t.Fatalf("resolution = %s", report.Resolution)
+	}
+}
+
+func TestAvailabilityOngoingOutage(t *testing.T) {
+	store, err := Open(t.TempDir(), Retention{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	sc := store.Scope("main")
+	now := time.Now()
+	start := now.Add(-40 * time.Minute).Truncate(time.Minute)
+	recordPings(sc, "a", start, time.Minute, 30, func(i int) bool { return i >= 20 })
+
+	report, err := sc.Availability(KindPing, "a", now.Add(-30*24*time.Hour), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Outages != 1 || !report.Incidents[0].Ongoing {
+		t.Fatalf("incidents = %+v", report.Incidents)
+	}
+	if report.Incidents[0].DurationSec != 9*60+1 || report.MTTRSec != 0 {
+		t.Fatalf("incident = %+v mttr = %d", report.Incidents[0], report.MTTRSec)
+	}
+	if report.UptimePct == nil || *report.UptimePct != 66.6667 {
+		t.Fatalf("uptime = %v", report.UptimePct)
+	}
+}
+
+func TestSpansFollowRetention(t *testing.T) {
+	store := &Store{retention: Retention{Raw: 48 * time.Hour, Minute: 14 * 24 * time.Hour, Hour: 400 * 24 * time.Hour}}
+	now := time.Now()
+	spans := store.spans(now.Add(-30*24*time.Hour), now)
+	if len(spans) != 3 || spans[0].res != ResHour || spans[1].res != ResMinute || spans[2].res != ResRaw {
+		t.Fatalf("spans = %+v", spans)
+	}
+	for i := 1; i < len(spans); i++ {
+		if !spans[i].from.Equal(spans[i-1].to.Add(time.Second)) {
+			t.Fatalf("span %d starts at %s after %s", i, spans[i].from, spans[i-1].to)
+		}
+	}
+	if old := store.spans(now.Add(-60*24*time.Hour), now.Add(-40*24*time.Hour)); len(old) != 1 || old[0].res != ResHour {
+		t.Fatalf("old spans = %+v", old)
+	}
+}
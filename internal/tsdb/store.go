@@ -0,0 +1,516 @@
+package tsdb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"inframap/internal/model"
+)
+
+const (
+	KindPing  = "ping"
+	KindSSH   = "ssh"
+	KindCheck = "check"
+
+	ResRaw    = "raw"
+	ResMinute = "1m"
+	ResHour   = "1h"
+)
+
+type Retention struct {
+	Raw    time.Duration
+	Minute time.Duration
+	Hour   time.Duration
+}
+
+type Sample struct {
+	T        int64   `json:"t"`
+	Node     string  `json:"n"`
+	Count    int     `json:"c"`
+	Up       int     `json:"u"`
+	RTTSum   float64 `json:"rs,omitempty"`
+	RTTCount int     `json:"rc,omitempty"`
+	RTTMin   float64 `json:"rmin,omitempty"`
+	RTTMax   float64 `json:"rmax,omitempty"`
+	Error    string  `json:"e,omitempty"`
+}
+
+type Store struct {
+	mu        sync.Mutex
+	dir       string
+	retention Retention
+	pending   map[string]map[string]*Sample
+	stopCh    chan struct{}
+	stopOnce  sync.Once
+}
+
+func Open(dir string, retention Retention) (*Store, error) {
+	if retention.Raw <= 0 {
+		retention.Raw = 48 * time.Hour
+	}
+	if retention.Minute <= 0 {
+		retention.Minute = 14 * 24 * time.Hour
+	}
+	if retention.Hour <= 0 {
+		retention.Hour = 400 * 24 * time.Hour
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	s := &Store{
+		dir:       dir,
+		retention: retention,
+		pending:   make(map[string]map[string]*Sample),
+		stopCh:    make(chan struct{}),
+	}
+	if err := s.recover(); err != nil {
+		return nil, err
+	}
+	go s.maintain()
+	return s, nil
+}
+
+func (s *Store) Close() error {
+	s.stopOnce.Do(func() {
+		close(s.stopCh)
+	})
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.flushPending(time.Time{}, true)
+}
+
+func (s *Store) Scope(board string) *Scoped {
+	return &Scoped{store: s, board: board}
+}
+
+func (s *Store) DeleteScope(board string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prefix := board + "/"
+	for key := range s.pending {
+		if strings.HasPrefix(key, prefix) {
+			delete(s.pending, key)
+		}
+	}
+	return os.RemoveAll(filepath.Join(s.dir, board))
+}
+
+type Scoped struct {
+	store *Store
+	board string
+}
+
+func (sc *Scoped) RecordPing(results map[string]model.PingResult) {
+	samples := make([]Sample, 0, len(results))
+	for node, res := range results {
+		sample := Sample{T: res.LastChecked.Unix(), Node: node, Count: 1, Error: res.Error}
+		if res.Online {
+			sample.Up = 1
+			if res.RTTMs > 0 {
+				rtt := float64(res.RTTMs)
+				sample.RTTSum, sample.RTTCount, sample.RTTMin, sample.RTTMax = rtt, 1, rtt, rtt
+			}
+		}
+		samples = append(samples, sample)
+	}
+	_ = sc.store.append(sc.board, KindPing, samples)
+}
+
+func (sc *Scoped) RecordSSH(results map[string]model.SSHStatus) {
+	samples := make([]Sample, 0, len(results))
+	for node, res := range results {
+		sample := Sample{T: res.LastChecked.Unix(), Node: node, Count: 1, Error: res.Error}
+		if res.Online {
+			sample.Up = 1
+		}
+		samples = append(samples, sample)
+	}
+	_ = sc.store.append(sc.board, KindSSH, samples)
+}
+
+func (sc *Scoped) RecordChecks(results map[string]model.CheckResult) {
+	samples := make([]Sample, 0, len(results))
+	for key, res := range results {
+		sample := Sample{T: res.LastChecked.Unix(), Node: key, Count: 1, Error: res.Error}
+		if res.Online {
+			sample.Up = 1
+			if res.LatencyMs > 0 {
+				sample.RTTSum, sample.RTTCount, sample.RTTMin, sample.RTTMax = res.LatencyMs, 1, res.LatencyMs, res.LatencyMs
+			}
+		}
+		samples = append(samples, sample)
+	}
+	_ = sc.store.append(sc.board, KindCheck, samples)
+}
+
+func (sc *Scoped) Query(kind, node string, from, to time.Time, step time.Duration) (Result, error) {
+	return sc.store.query(sc.board, kind, node, from, to, step)
+}
+
+func (s *Store) append(board, kind string, samples []Sample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].T < samples[j].T })
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var errs []error
+	if err := s.write(board, kind, ResRaw, samples); err != nil {
+		errs = append(errs, err)
+	}
+	for _, sample := range samples {
+		sample.Error = ""
+		if err := s.accumulate(board, kind, ResMinute, sample); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Store) accumulate(board, kind, res string, sample Sample) error {
+	width := resolutionWidth(res)
+	sample.T -= sample.T % int64(width.Seconds())
+	key := board + "/" + kind + "/" + res
+	buckets := s.pending[key]
+	if buckets == nil {
+		buckets = make(map[string]*Sample)
+		s.pending[key] = buckets
+	}
+	current, ok := buckets[sample.Node]
+	if ok && current.T == sample.T {
+		merge(current, sample)
+		return nil
+	}
+	var err error
+	if ok {
+		err = s.emit(board, kind, res, *current)
+	}
+	copied := sample
+	buckets[sample.Node] = &copied
+	return err
+}
+
+func (s *Store) emit(board, kind, res string, sample Sample) error {
+	err := s.write(board, kind, res, []Sample{sample})
+	if res == ResMinute {
+		if aggErr := s.accumulate(board, kind, ResHour, sample); aggErr != nil {
+			err = errors.Join(err, aggErr)
+		}
+	}
+	return err
+}
+
+func (s *Store) flushPending(now time.Time, all bool) error {
+	var errs []error
+	for _, res := range []string{ResMinute, ResHour} {
+		width := int64(resolutionWidth(res).Seconds())
+		for key, buckets := range s.pending {
+			parts := strings.Split(key, "/")
+			if len(parts) != 3 || parts[2] != res {
+				continue
+			}
+			for node, bucket := range buckets {
+				if !all && bucket.T+width > now.Unix() {
+					continue
+				}
+				delete(buckets, node)
+				if err := s.emit(parts[0], parts[1], res, *bucket); err != nil {
+					errs = append(errs, err)
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Store) recover() error {
+	boards, err := os.ReadDir(s.dir)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, board := range boards {
+		if !board.IsDir() {
+			continue
+		}
+		for _, kind := range []string{KindPing, KindSSH, KindCheck} {
+			errs = append(errs,
+				s.recoverLevel(board.Name(), kind, ResMinute, ResHour),
+				s.recoverLevel(board.Name(), kind, ResRaw, ResMinute),
+			)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Store) recoverLevel(board, kind, fine, coarse string) error {
+	coarseSamples, err := s.readLatest(board, kind, coarse)
+	if err != nil {
+		return err
+	}
+	last := make(map[string]int64)
+	for _, sample := range coarseSamples {
+		if sample.T > last[sample.Node] {
+			last[sample.Node] = sample.T
+		}
+	}
+	fineSamples, err := s.readLatest(board, kind, fine)
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(fineSamples, func(i, j int) bool { return fineSamples[i].T < fineSamples[j].T })
+	width := int64(resolutionWidth(coarse).Seconds())
+	var errs []error
+	for _, sample := range fineSamples {
+		if t, ok := last[sample.Node]; ok && sample.T < t+width {
+			continue
+		}
+		sample.Error = ""
+		errs = append(errs, s.accumulate(board, kind, coarse, sample))
+	}
+	return errors.Join(errs...)
+}
+
+func (s *Store) readLatest(board, kind, res string) ([]Sample, error) {
+	dir := filepath.Join(s.dir, board, kind, res)
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var names []string
+	for _, file := range files {
+		if _, _, ok := partitionRange(res, strings.TrimSuffix(file.Name(), ".jsonl")); ok {
+			names = append(names, file.Name())
+		}
+	}
+	sort.Strings(names)
+	if len(names) > 2 {
+		names = names[len(names)-2:]
+	}
+	var out []Sample
+	for _, name := range names {
+		samples, err := readFile(filepath.Join(dir, name), "", 0, math.MaxInt64)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", name, err)
+		}
+		out = append(out, samples...)
+	}
+	return out, nil
+}
+
+func (s *Store) maintain() {
+	flush := time.NewTicker(30 * time.Second)
+	defer flush.Stop()
+	prune := time.NewTicker(10 * time.Minute)
+	defer prune.Stop()
+	s.prune(time.Now())
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case now := <-flush.C:
+			s.mu.Lock()
+			_ = s.flushPending(now, false)
+			s.mu.Unlock()
+		case now := <-prune.C:
+			s.prune(now)
+		}
+	}
+}
+
+func (s *Store) prune(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	boards, err := os.ReadDir(s.dir)
+	if err != nil {
+		return
+	}
+	for _, board := range boards {
+		if !board.IsDir() {
+			continue
+		}
+		for _, kind := range []string{KindPing, KindSSH, KindCheck} {
+			for _, res := range []string{ResRaw, ResMinute, ResHour} {
+				dir := filepath.Join(s.dir, board.Name(), kind, res)
+				files, err := os.ReadDir(dir)
+				if err != nil {
+					continue
+				}
+				cutoff := now.Add(-s.retentionFor(res))
+				for _, file := range files {
+					_, end, ok := partitionRange(res, strings.TrimSuffix(file.Name(), ".jsonl"))
+					if ok && end.Before(cutoff) {
+						_ = os.Remove(filepath.Join(dir, file.Name()))
+					}
+				}
+			}
+		}
+	}
+}
+
+func (s *Store) write(board, kind, res string, samples []Sample) error {
+	groups := make(map[string][]Sample)
+	for _, sample := range samples {
+		name := partitionName(res, time.Unix(sample.T, 0))
+		groups[name] = append(groups[name], sample)
+	}
+	dir := filepath.Join(s.dir, board, kind, res)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for name, group := range groups {
+		file, err := os.OpenFile(filepath.Join(dir, name+".jsonl"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			return err
+		}
+		writer := bufio.NewWriter(file)
+		encoder := json.NewEncoder(writer)
+		for _, sample := range group {
+			if err := encoder.Encode(sample); err != nil {
+				_ = file.Close()
+				return err
+			}
+		}
+		if err := writer.Flush(); err != nil {
+			_ = file.Close()
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Store) read(board, kind, res, node string, from, to time.Time) ([]Sample, error) {
+	dir := filepath.Join(s.dir, board, kind, res)
+	files, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	var out []Sample
+	for _, file := range files {
+		start, end, ok := partitionRange(res, strings.TrimSuffix(file.Name(), ".jsonl"))
+		if !ok || !end.After(from) || start.After(to) {
+			continue
+		}
+		samples, err := readFile(filepath.Join(dir, file.Name()), node, from.Unix(), to.Unix())
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", file.Name(), err)
+		}
+		out = append(out, samples...)
+	}
+	if buckets := s.pending[board+"/"+kind+"/"+res]; buckets != nil {
+		if bucket, ok := buckets[node]; ok && bucket.T >= from.Unix() && bucket.T <= to.Unix() {
+			out = append(out, *bucket)
+		}
+	}
+	return out, nil
+}
+
+func readFile(path, node string, from, to int64) ([]Sample, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var out []Sample
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	needle := []byte(`"n":` + quote(node) + `,`)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if node != "" && !bytes.Contains(line, needle) {
+			continue
+		}
+		var sample Sample
+		if err := json.Unmarshal(line, &sample); err != nil {
+			continue
+		}
+		if (node != "" && sample.Node != node) || sample.T < from || sample.T > to {
+			continue
+		}
+		out = append(out, sample)
+	}
+	return out, scanner.Err()
+}
+
+func (s *Store) retentionFor(res string) time.Duration {
+	switch res {
+	case ResRaw:
+		return s.retention.Raw
+	case ResMinute:
+		return s.retention.Minute
+	default:
+		return s.retention.Hour
+	}
+}
+
+func resolutionWidth(res string) time.Duration {
+	switch res {
+	case ResMinute:
+		return time.Minute
+	case ResHour:
+		return time.Hour
+	default:
+		return time.Second
+	}
+}
+
+func partitionName(res string, t time.Time) string {
+	if res == ResHour {
+		return t.UTC().Format("2006-01")
+	}
+	return t.UTC().Format("2006-01-02")
+}
+
+func partitionRange(res, name string) (time.Time, time.Time, bool) {
+	if res == ResHour {
+		start, err := time.Parse("2006-01", name)
+		if err != nil {
+			return time.Time{}, time.Time{}, false
+		}
+		return start, start.AddDate(0, 1, 0), true
+	}
+	start, err := time.Parse("2006-01-02", name)
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	return start, start.AddDate(0, 0, 1), true
+}
+
+func merge(dst *Sample, src Sample) {
+	dst.Count += src.Count
+	dst.Up += src.Up
+	if src.RTTCount > 0 {
+		if dst.RTTCount == 0 || src.RTTMin < dst.RTTMin {
+			dst.RTTMin = src.RTTMin
+		}
+		if src.RTTMax > dst.RTTMax {
+			dst.RTTMax = src.RTTMax
+		}
+		dst.RTTSum += src.RTTSum
+		dst.RTTCount += src.RTTCount
+	}
+	if src.Error != "" {
+		dst.Error = src.Error
+	}
+}
+
+func quote(value string) string {
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
@@ -0,0 +1,140 @@
+package tsdb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"inframap/internal/model"
+)
+
+func recordRTT(sc *Scoped, node string, at time.Time, rtt int) {
+	sc.RecordPing(map[string]model.PingResult{node: {
+		PingProbe:   model.PingProbe{Online: rtt > 0, RTTMs: rtt},
+		LastChecked: at,
+	}})
+}
+
+func readAll(t *testing.T, s *Store, res string) []Sample {
+	t.Helper()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	samples, err := s.read("main", KindPing, res, "a", time.Unix(0, 0), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return samples
+}
+
+func TestDownsampling(t *testing.T) {
+	store, err := Open(t.TempDir(), Retention{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc := store.Scope("main")
+	start := time.Now().Add(-3 * time.Hour).Truncate(time.Hour)
+	// Three minutes of 20 second samples; the fifth one is down.
+	rtts := []int{10, 20, 30, 5, 0, 15, 40, 40, 40}
+	for i, rtt := range rtts {
+		recordRTT(sc, "a", start.Add(time.Duration(i)*20*time.Second), rtt)
+	}
+	recordRTT(sc, "b", start, 99)
+
+	if raw := readAll(t, store, ResRaw); len(raw) != len(rtts) {
+		t.Fatalf("raw samples = %d", len(raw))
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	minutes := readAll(t, store, ResMinute)
+	want := []Sample{
+		{T: start.Unix(), Node: "a", Count: 3, Up: 3, RTTSum: 60, RTTCount: 3, RTTMin: 10, RTTMax: 30},
+		{T: start.Unix() + 60, Node: "a", Count: 3, Up: 2, RTTSum: 20, RTTCount: 2, RTTMin: 5, RTTMax: 15},
+		{T: start.Unix() + 120, Node: "a", Count: 3, Up: 3, RTTSum: 120, RTTCount: 3, RTTMin: 40, RTTMax: 40},
+	}
+	if len(minutes) != len(want) {
+		t.Fatalf("minute samples = %+v", minutes)
+	}
+	for i := range want {
+		if minutes[i] != want[i] {
+			t.Fatalf("minute %d = %+v, want %+v", i, minutes[i], want[i])
+		}
+	}
+	hours := readAll(t, store, ResHour)
+	wantHour := Sample{T: start.Unix(), Node: "a", Count: 9, Up: 8, RTTSum: 200, RTTCount: 8, RTTMin: 5, RTTMax: 40}
+	if len(hours) != 1 || hours[0] != wantHour {
+		t.Fatalf("hour samples = %+v, want %+v", hours, wantHour)
+	}
+}
+
+func TestPendingBucketsRecoverAfterCrash(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(dir, Retention{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sc := store.Scope("main")
+	start := time.Now().Add(-3 * time.Hour).Truncate(time.Hour)
+	for i := 0; i < 6; i++ {
+		recordRTT(sc, "a", start.Add(time.Duration(i)*30*time.Second), 10)
+	}
+	// Simulate a crash: stop the store without flushing its pending buckets.
+	store.stopOnce.Do(func() { close(store.stopCh) })
+	if minutes := readAll(t, store, ResMinute); len(minutes) != 3 {
+		t.Fatalf("minute samples before crash = %+v", minutes)
+	}
+
+	reopened, err := Open(dir, Retention{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reopened.Close(); err != nil {
+		t.Fatal(err)
+	}
+	minutes := readAll(t, reopened, ResMinute)
+	if len(minutes) != 3 {
+		t.Fatalf("minute samples after recovery = %+v", minutes)
+	}
+	for i, sample := range minutes {
+		if sample.T != start.Unix()+int64(i)*60 || sample.Count != 2 {
+			t.Fatalf("minute %d = %+v", i, sample)
+		}
+	}
+	if hours := readAll(t, reopened, ResHour); len(hours) != 1 || hours[0].Count != 6 {
+		t.Fatalf("hour samples after recovery = %+v", hours)
+	}
+}
+
+func TestRetentionPruning(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(dir, Retention{Raw: 24 * time.Hour, Minute: 3 * 24 * time.Hour, Hour: 60 * 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	now := time.Now()
+	samples := map[string][]time.Time{
+		ResRaw:    {now.Add(-3 * 24 * time.Hour), now},
+		ResMinute: {now.Add(-5 * 24 * time.Hour), now.Add(-2 * 24 * time.Hour)},
+		ResHour:   {now.AddDate(0, -4, 0), now.AddDate(0, -1, 0)},
+	}
+	for res, times := range samples {
+		for _, at := range times {
+			if err := store.write("main", KindPing, res, []Sample{{T: at.Unix(), Node: "a", Count: 1}}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	store.prune(now)
+	for res, times := range samples {
+		for i, at := range times {
+			path := filepath.Join(dir, "main", KindPing, res, partitionName(res, at)+".jsonl")
+			_, err := os.Stat(path)
+			if kept := err == nil; kept != (i == 1) {
+				t.Errorf("%s partition %s kept = %t", res, filepath.Base(path), kept)
+			}
+		}
+	}
+}
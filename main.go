@@ -6,18 +6,25 @@ import (
 	"os"
 	"strings"
 
-	"inframap/internal/monitoring"
+	"inframap/internal/events"
+	"inframap/internal/model"
 	"inframap/internal/server"
 	"inframap/internal/storage"
+	"inframap/internal/tsdb"
 )
 
 const (
-	dataDir       = "data"
-	boardFile     = "data/board.json"
-	secretsFile   = "data/secrets.json"
-	secretKeyFile = "data/secrets.key"
-	staticDir     = "public"
-	defaultPort   = "8080"
+	dataDir        = "data"
+	secretsFile    = "data/secrets.json"
+	secretKeyFile  = "data/secrets.key"
+	knownHostsFile = "data/known_hosts.json"
+	factsFile      = "data/facts.json"
+	metricsDir     = "data/metrics"
+	alertsFile     = "data/alerts.json"
+	channelsFile   = "data/channels.json"
+	usersFile      = "data/users.json"
+	staticDir      = "public"
+	defaultPort    = "8080"
 )
 
 func main() {
@@ -28,22 +35,58 @@ func main() {
 		log.Fatalf("failed to prepare data directory: %v", err)
 	}
 
+	eventBroker := events.NewBroker(1000)
 	logStore := storage.NewLogStore(500)
+	logStore.SetListener(func(entry model.LogEntry) {
+		eventBroker.Publish("log", entry)
+	})
 	secretStore, err := storage.NewSecretStore(secretKeyFile, secretsFile)
 	if err != nil {
 		log.Fatalf("failed to init secrets store: %v", err)
 	}
-	pingManager := monitoring.NewPingManager(logStore)
-	sshManager := monitoring.NewSSHStatusManager(secretStore, logStore)
+	knownHosts, err := storage.NewKnownHostsStore(knownHostsFile)
+	if err != nil {
+		log.Fatalf("failed to init known hosts store: %v", err)
+	}
+	factsStore, err := storage.NewFactsStore(factsFile)
+	if err != nil {
+		log.Fatalf("failed to init facts store: %v", err)
+	}
+	metricsStore, err := tsdb.Open(metricsDir, tsdb.Retention{})
+	if err != nil {
+		log.Fatalf("failed to init metrics store: %v", err)
+	}
+	alertStore, err := storage.NewAlertStore(alertsFile)
+	if err != nil {
+		log.Fatalf("failed to init alert store: %v", err)
+	}
+	channelStore, err := storage.NewChannelStore(secretKeyFile, channelsFile)
+	if err != nil {
+		log.Fatalf("failed to init notification channel store: %v", err)
+	}
+	userStore, err := storage.NewUserStore(usersFile)
+	if err != nil {
+		log.Fatalf("failed to init user store: %v", err)
+	}
+	bootstrapAdmin(userStore)
+	boardRegistry, err := storage.NewBoardRegistry(dataDir)
+	if err != nil {
+		log.Fatalf("failed to init board registry: %v", err)
+	}
 
 	srv := server.New(server.Config{
-		DataDir:   dataDir,
-		BoardFile: boardFile,
-		StaticDir: staticDir,
-		Ping:      pingManager,
-		SSH:       sshManager,
-		Secrets:   secretStore,
-		Logs:      logStore,
+		DataDir:    dataDir,
+		StaticDir:  staticDir,
+		Boards:     boardRegistry,
+		Secrets:    secretStore,
+		KnownHosts: knownHosts,
+		Facts:      factsStore,
+		Metrics:    metricsStore,
+		Alerts:     alertStore,
+		Channels:   channelStore,
+		Users:      userStore,
+		Logs:       logStore,
+		Events:     eventBroker,
 	})
 
 	if err := srv.Bootstrap(); err != nil {
@@ -56,6 +99,28 @@ func main() {
 	}
 }
 
+func bootstrapAdmin(users *storage.UserStore) {
+	count, err := users.Count()
+	if err != nil {
+		log.Printf("failed to read users: %v", err)
+		return
+	}
+	if count > 0 {
+		return
+	}
+	username := getEnv("INFRAMAP_ADMIN_USER", "")
+	password := os.Getenv("INFRAMAP_ADMIN_PASSWORD")
+	if username == "" || password == "" {
+		log.Printf("no users configured: open the web UI to create the first account")
+		return
+	}
+	if _, err := users.CreateFirstAdmin(username, password); err != nil {
+		log.Printf("failed to create initial user %s: %v", username, err)
+		return
+	}
+	log.Printf("created initial user %s from environment", username)
+}
+
 func loadDotEnv(path string) {
 	data, err := os.ReadFile(path)
 	if err != nil {
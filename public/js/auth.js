@@ -0,0 +1,66 @@
+const auth = {
+  username: null,
+  role: null,
+  csrfToken: null,
+  ready: null,
+};
+
+const nativeFetch = window.fetch.bind(window);
+
+function redirectToLogin() {
+  const next = encodeURIComponent(window.location.pathname + window.location.search);
+  window.location.href = `/login.html?next=${next}`;
+}
+
+auth.ready = nativeFetch("/api/auth/me", { credentials: "same-origin" })
+  .then(async (res) => {
+    if (res.status === 401) {
+      redirectToLogin();
+      return;
+    }
+    if (!res.ok) return;
+    const data = await res.json();
+    auth.username = data.username || null;
+    auth.role = data.role || null;
+    auth.csrfToken = data.csrfToken || null;
+    if (!hasRole("editor")) {
+      const saveButton = document.getElementById("save-btn");
+      if (saveButton) {
+        saveButton.disabled = true;
+        saveButton.title = "Your role can view the board but not save it.";
+      }
+    }
+  })
+  .catch(() => {});
+
+const roleLevels = { viewer: 1, editor: 2, operator: 3, admin: 4 };
+
+function hasRole(role) {
+  if (!auth.role) return true;
+  return (roleLevels[auth.role] || 0) >= roleLevels[role];
+}
+
+window.fetch = async (input, init = {}) => {
+  const method = (init.method || "GET").toUpperCase();
+  if (method !== "GET" && method !== "HEAD") {
+    await auth.ready;
+    if (auth.csrfToken) {
+      const headers = new Headers(init.headers || {});
+      headers.set("X-CSRF-Token", auth.csrfToken);
+      init = { ...init, headers };
+    }
+  }
+  const res = await nativeFetch(input, init);
+  if (res.status === 401 && !String(input).startsWith("/api/auth/")) {
+    redirectToLogin();
+  }
+  return res;
+};
+
+async function logout() {
+  try {
+    await fetch("/api/auth/logout", { method: "POST" });
+  } finally {
+    redirectToLogin();
+  }
+}
@@ -172,6 +172,9 @@ function buildNodeElement(node) {
     const statusDot = document.createElement("div");
     statusDot.className = "node__status";
     el.appendChild(statusDot);
+    const checkDots = document.createElement("div");
+    checkDots.className = "node__checks";
+    el.appendChild(checkDots);
   }
 
   el.addEventListener("mousedown", (event) => {
@@ -201,6 +204,7 @@ function buildIpLine(node) {
       : "no public ip";
   }
   const parts = [];
+  if (node.hostname) parts.push(`host ${node.hostname}`);
   if (node.ipPrivate) parts.push(`priv ${node.ipPrivate}`);
   if (node.ipTailscale) parts.push(`ts ${node.ipTailscale}`);
   if (node.ipPublic) parts.push(`pub ${node.ipPublic}`);
@@ -215,6 +219,7 @@ function renderIpLines(ipEl, node) {
     return;
   }
   const lines = [];
+  if (node.hostname) lines.push({ type: "hostname", label: "host", value: node.hostname });
   if (node.ipPrivate) lines.push({ type: "private", label: "priv", value: node.ipPrivate });
   if (node.ipTailscale) lines.push({ type: "tailscale", label: "ts", value: node.ipTailscale });
   if (node.ipPublic) lines.push({ type: "public", label: "pub", value: node.ipPublic });
@@ -351,6 +356,9 @@ function updatePropsForm() {
   if (propsForm.elements.network) {
     propsForm.elements.network.value = node.network || "";
   }
+  if (propsForm.elements.hostname) {
+    propsForm.elements.hostname.value = node.hostname || "";
+  }
   if (propsForm.elements.ipPrivate) {
     propsForm.elements.ipPrivate.value = node.ipPrivate || "";
   }
@@ -461,6 +469,7 @@ function addNode(type) {
     x: viewport.x + offset,
     y: viewport.y + offset,
     network: "",
+    hostname: "",
     ipPrivate: "",
     ipTailscale: "",
     ipPublic: "",
@@ -500,8 +509,9 @@ function deleteSelected() {
 async function loadBoard() {
   try {
     setStatus("Loading board...", "info");
-    const res = await fetch("/api/board");
+    const res = await fetch(boardApi("/api/board"));
     if (!res.ok) throw new Error("failed");
+    state.boardETag = res.headers.get("ETag");
     const data = await res.json();
     state.board = normalizeBoard(data);
     setStatus("Board loaded.", "success");
@@ -520,20 +530,49 @@ async function loadBoard() {
 
 async function saveBoard(options = {}) {
   const silent = options.silent === true;
+  if (silent && !hasRole("editor")) return;
   try {
     if (!silent) {
       setStatus("Saving...", "info");
     }
     state.board.meta = state.board.meta || {};
     state.board.meta.updatedAt = new Date().toISOString();
-    const res = await fetch("/api/board", {
+    const headers = { "Content-Type": "application/json" };
+    if (state.boardETag && options.force !== true) {
+      headers["If-Match"] = state.boardETag;
+    }
+    const res = await fetch(boardApi("/api/board"), {
       method: "POST",
-      headers: { "Content-Type": "application/json" },
+      headers,
       body: JSON.stringify(state.board, null, 2),
     });
+    if (res.status === 409) {
+      const payload = await res.json().catch(() => ({}));
+      const rev = payload.revision ? ` (revision #${payload.revision.number} by ${payload.revision.author})` : "";
+      if (!silent && confirm(`The board was changed by someone else${rev}. Overwrite their changes?`)) {
+        return saveBoard({ ...options, force: true });
+      }
+      setStatus(`Save blocked: board changed on the server${rev}. Reload to get the latest version.`, "warn");
+      return;
+    }
+    if (res.status === 403) {
+      const payload = await res.json().catch(() => ({}));
+      if (!silent) {
+        setStatus(`Save denied: ${payload.error || "insufficient permissions"}`, "error");
+      }
+      return;
+    }
+    if (res.status === 422) {
+      const payload = await res.json().catch(() => ({}));
+      const first = Array.isArray(payload.errors) && payload.errors.length ? payload.errors[0] : null;
+      const detail = first ? `${first.field}: ${first.message}` : "invalid board";
+      setStatus(`Save rejected: ${detail}`, "error");
+      return;
+    }
     if (!res.ok) throw new Error("failed");
+    state.boardETag = res.headers.get("ETag") || state.boardETag;
     if (!silent) {
-      setStatus("Saved to data/board.json.", "success");
+      setStatus(`Saved board "${boardId}".`, "success");
     }
     recordHistory();
     markSaved();
@@ -739,8 +778,8 @@ propsForm.addEventListener("input", (event) => {
     assignNodesToNetworks();
     updatePropsForm();
   }
-  if (field === "ipPrivate" || field === "ipPublic" || field === "ipTailscale") {
-    const hasIP = Boolean(node.ipPrivate || node.ipPublic || node.ipTailscale);
+  if (field === "hostname" || field === "ipPrivate" || field === "ipPublic" || field === "ipTailscale") {
+    const hasIP = Boolean(node.hostname || node.ipPrivate || node.ipPublic || node.ipTailscale);
     if (!hasIP && node.pingEnabled) {
       node.pingEnabled = false;
       node.pingShowStatus = node.pingShowStatus !== false;
@@ -1218,6 +1257,21 @@ if (settingsApply) {
     applySettingsFromForm();
   });
 }
+if (settingsClearBtn) {
+  settingsClearBtn.addEventListener("click", () => {
+    clearDeviceCredentials();
+  });
+}
+if (settingsAcceptKeyBtn) {
+  settingsAcceptKeyBtn.addEventListener("click", () => {
+    updateHostKey("accept");
+  });
+}
+if (settingsResetKeyBtn) {
+  settingsResetKeyBtn.addEventListener("click", () => {
+    updateHostKey("reset");
+  });
+}
 if (settingsForm) {
   settingsForm.addEventListener("input", (event) => {
     if (event.target.name === "pingEnabled") {
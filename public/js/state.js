@@ -21,6 +21,9 @@ const settingsBtn = document.getElementById("settings-btn");
 const settingsModal = document.getElementById("settings-modal");
 const settingsClose = document.getElementById("settings-close");
 const settingsApply = document.getElementById("settings-apply");
+const settingsClearBtn = document.getElementById("settings-clear-credentials");
+const settingsAcceptKeyBtn = document.getElementById("settings-accept-hostkey");
+const settingsResetKeyBtn = document.getElementById("settings-reset-hostkey");
 const settingsForm = document.getElementById("settings-form");
 const logsModal = document.getElementById("logs-modal");
 const logsClose = document.getElementById("logs-close");
@@ -38,6 +41,12 @@ const networkMinSize = { width: 200, height: 140 };
 const monitoringDefaults = { intervalSec: 30, showStatus: true };
 const networkHeaderPositions = ["tl", "tc", "tr", "ml", "mc", "mr", "bl", "bc", "br"];
 
+const boardId = new URLSearchParams(window.location.search).get("board") || "default";
+
+function boardApi(path) {
+  return `/api/boards/${encodeURIComponent(boardId)}${path.replace(/^\/api/, "")}`;
+}
+
 const typeLabels = {
   server: "SV",
   pc: "PC",
@@ -62,9 +71,13 @@ const state = {
     restoring: false,
     max: 80,
   },
+  boardETag: null,
   statusById: {},
   sshStatusById: {},
+  checkStatusById: {},
   statusTimer: null,
+  eventSource: null,
+  eventsConnected: false,
 };
 
 function createEmptyBoard() {